@@ -0,0 +1,265 @@
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/bhojpur/license/header"
+	doublestar "github.com/bmatcuk/doublestar/v4"
+	"gopkg.in/yaml.v3"
+)
+
+// configFile is the name of the project configuration file, looked up in
+// the working directory and its parents up to the root of the repository.
+const configFile = ".license.yaml"
+
+// config is the project configuration. Each field corresponds to a command
+// line flag, which takes precedence when given.
+type config struct {
+	Holder   string   `yaml:"holder"`   // -c
+	License  string   `yaml:"license"`  // -l
+	Template string   `yaml:"template"` // -f, relative to the configuration file
+	SPDX     spdxFlag `yaml:"spdx"`     // -s
+	Ignore   []string `yaml:"ignore"`   // like -ignore, relative to the configuration file
+	Check    bool     `yaml:"check"`    // -check
+	Strict   bool     `yaml:"strict"`   // -strict
+	Foreign  bool     `yaml:"foreign"`  // -foreign
+	Sidecar  bool     `yaml:"sidecar"`  // -sidecar
+
+	// Rules select the license of files by path, the first matching rule
+	// wins. Files matching no rule use the settings above.
+	Rules []ruleConfig `yaml:"rules"`
+
+	// Styles add comment styles for new file types, or override the
+	// built-in ones. Later styles take precedence.
+	Styles []styleConfig `yaml:"styles"`
+
+	dir string // directory of the configuration file
+}
+
+// ruleConfig is the configuration of a header.Rule. Settings that are not
+// specified are inherited from the project configuration.
+type ruleConfig struct {
+	Paths    []string  `yaml:"paths"`    // patterns relative to the configuration file
+	License  string    `yaml:"license"`  // license type
+	Holder   string    `yaml:"holder"`   // copyright holder
+	Template string    `yaml:"template"` // license template file
+	SPDX     *spdxFlag `yaml:"spdx"`     // SPDX mode
+	Skip     bool      `yaml:"skip"`     // leave matching files alone
+}
+
+// UnmarshalYAML allows the spdx setting to be either a boolean, "only" or
+// "reuse".
+func (i *spdxFlag) UnmarshalYAML(value *yaml.Node) error {
+	if value.Value == "false" {
+		*i = spdxOff
+		return nil
+	}
+	if err := i.Set(value.Value); err != nil {
+		return fmt.Errorf("line %d: spdx expects true, false, %q or %q", value.Line, spdxOnly, spdxReuse)
+	}
+	return nil
+}
+
+// findConfig looks for the configuration file in dir and its parents,
+// stopping at the root of the git repository containing dir. It returns an
+// empty string if there is no configuration file.
+func findConfig(dir string) (string, error) {
+	return findProjectFile(dir, configFile)
+}
+
+// findProjectFile looks for the file name in dir and its parents, stopping
+// at the root of the git repository containing dir. It returns an empty
+// string if there is no such file.
+func findProjectFile(dir, name string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		} else if !os.IsNotExist(err) {
+			return "", err
+		}
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return "", nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// styleConfig is the configuration of a comment style for the files
+// matching its extensions, file names or patterns.
+type styleConfig struct {
+	Extensions []string `yaml:"extensions"` // such as ".lua"
+	Filenames  []string `yaml:"filenames"`  // such as "Makefile"
+	Patterns   []string `yaml:"patterns"`   // such as "*.cmake.in" or "scripts/**/*.ps1"
+	Top        string   `yaml:"top"`        // first line of the comment
+	Mid        string   `yaml:"mid"`        // prefix of each line of the license
+	Bot        string   `yaml:"bot"`        // last line of the comment
+}
+
+func (c styleConfig) rule() header.StyleRule {
+	return header.StyleRule{
+		Style:      header.CommentStyle{Top: c.Top, Mid: c.Mid, Bot: c.Bot},
+		Extensions: c.Extensions,
+		Filenames:  c.Filenames,
+		Patterns:   c.Patterns,
+	}
+}
+
+// loadConfig reads and validates the configuration file at path.
+func loadConfig(path string) (*config, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	cfg := config{dir: filepath.Dir(abs)}
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	dec.KnownFields(true)
+	if err := dec.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	for _, p := range cfg.Ignore {
+		if !doublestar.ValidatePattern(p) {
+			return nil, fmt.Errorf("%s: ignore pattern %q is not valid", path, p)
+		}
+	}
+	cfg.Template = cfg.resolve(cfg.Template)
+	for i := range cfg.Rules {
+		r := &cfg.Rules[i]
+		if len(r.Paths) == 0 {
+			return nil, fmt.Errorf("%s: rule %d has no paths", path, i+1)
+		}
+		for _, p := range r.Paths {
+			if !doublestar.ValidatePattern(p) {
+				return nil, fmt.Errorf("%s: rule %d: path pattern %q is not valid", path, i+1, p)
+			}
+		}
+		if r.Skip && (r.License != "" || r.Holder != "" || r.Template != "" || r.SPDX != nil) {
+			return nil, fmt.Errorf("%s: rule %d skips files but also sets a license", path, i+1)
+		}
+		r.Template = cfg.resolve(r.Template)
+	}
+	var styles header.StyleRegistry
+	for i, c := range cfg.Styles {
+		if err := styles.Register(c.rule()); err != nil {
+			return nil, fmt.Errorf("%s: style %d: %w", path, i+1, err)
+		}
+	}
+	return &cfg, nil
+}
+
+// resolve returns the file name relative to the configuration file as a
+// path usable from the working directory.
+func (c *config) resolve(name string) string {
+	if name == "" || filepath.IsAbs(name) {
+		return name
+	}
+	return filepath.Join(c.dir, name)
+}
+
+// applyConfig sets the flags that were not given on the command line from
+// the settings in cfg.
+func applyConfig(cfg *config) error {
+	set := setFlags()
+
+	values := []struct {
+		name, value string
+	}{
+		{"c", cfg.Holder},
+		{"l", cfg.License},
+		{"f", cfg.Template},
+		{"s", string(cfg.SPDX)},
+		{"check", fmt.Sprint(cfg.Check)},
+		{"strict", fmt.Sprint(cfg.Strict)},
+		{"foreign", fmt.Sprint(cfg.Foreign)},
+		{"sidecar", fmt.Sprint(cfg.Sidecar)},
+	}
+	for _, v := range values {
+		if set[v.name] || v.value == "" || v.value == "false" {
+			continue
+		}
+		if err := flag.Set(v.name, v.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setFlags returns the names of the flags given on the command line.
+func setFlags() map[string]bool {
+	set := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	return set
+}
+
+// styles returns the built-in comment styles extended with the ones of the
+// configuration.
+func (c *config) styles() (*header.StyleRegistry, error) {
+	styles := header.DefaultStyles()
+	for _, s := range c.Styles {
+		if err := styles.Register(s.rule()); err != nil {
+			return nil, err
+		}
+	}
+	return styles, nil
+}
+
+// loadProjectConfig loads the configuration file given by -config, or the
+// one found from the directory dir, and applies it to the flags. Without a
+// configuration file, it returns an empty configuration for dir.
+func loadProjectConfig(dir string) (*config, error) {
+	path := *configPath
+	if path == "" {
+		var err error
+		if path, err = findConfig(dir); err != nil {
+			return nil, err
+		}
+		if path == "" {
+			dir, err := filepath.Abs(dir)
+			return &config{dir: dir}, err
+		}
+	}
+	cfg, err := loadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	return cfg, applyConfig(cfg)
+}
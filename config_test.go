@@ -0,0 +1,202 @@
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadConfig(t *testing.T) {
+	tmp := tempDir(t)
+	t.Logf("tmp dir: %s", tmp)
+	off := spdxOff
+
+	tests := []struct {
+		description string
+		content     string
+		want        *config
+		wantErr     string
+	}{
+		{
+			"empty file",
+			"",
+			&config{dir: tmp},
+			"",
+		},
+		{
+			"all settings",
+			"holder: Bhojpur Consulting\nlicense: mit\ntemplate: header.tpl\nspdx: true\nignore:\n  - vendor/**\n  - '**/*.pb.go'\ncheck: true\nstrict: true\n",
+			&config{
+				Holder:   "Bhojpur Consulting",
+				License:  "mit",
+				Template: filepath.Join(tmp, "header.tpl"),
+				SPDX:     spdxOn,
+				Ignore:   []string{"vendor/**", "**/*.pb.go"},
+				Check:    true,
+				Strict:   true,
+				dir:      tmp,
+			},
+			"",
+		},
+		{
+			"spdx only",
+			"spdx: only\n",
+			&config{SPDX: spdxOnly, dir: tmp},
+			"",
+		},
+		{
+			"spdx disabled",
+			"spdx: false\n",
+			&config{SPDX: spdxOff, dir: tmp},
+			"",
+		},
+		{
+			"absolute template path",
+			"template: /etc/header.tpl\n",
+			&config{Template: "/etc/header.tpl", dir: tmp},
+			"",
+		},
+		{
+			"rules",
+			"rules:\n  - paths: [sdk/**]\n    license: apache\n    spdx: false\n  - paths: [examples/**]\n    holder: Examples\n    template: examples.tpl\n  - paths: [third_party/**]\n    skip: true\n",
+			&config{
+				Rules: []ruleConfig{
+					{Paths: []string{"sdk/**"}, License: "apache", SPDX: &off},
+					{Paths: []string{"examples/**"}, Holder: "Examples", Template: filepath.Join(tmp, "examples.tpl")},
+					{Paths: []string{"third_party/**"}, Skip: true},
+				},
+				dir: tmp,
+			},
+			"",
+		},
+		{
+			"rule without paths",
+			"rules:\n  - license: mit\n",
+			nil,
+			"rule 1 has no paths",
+		},
+		{
+			"invalid rule pattern",
+			"rules:\n  - paths: ['sdk/[']\n",
+			nil,
+			`rule 1: path pattern "sdk/[" is not valid`,
+		},
+		{
+			"skip rule with license",
+			"rules:\n  - paths: [sdk/**]\n    skip: true\n    license: mit\n",
+			nil,
+			"rule 1 skips files but also sets a license",
+		},
+		{
+			"styles",
+			"styles:\n  - extensions: [.lua]\n    mid: '-- '\n  - filenames: [Makefile]\n    patterns: ['*.mk']\n    mid: '# '\n",
+			&config{
+				Styles: []styleConfig{
+					{Extensions: []string{".lua"}, Mid: "-- "},
+					{Filenames: []string{"Makefile"}, Patterns: []string{"*.mk"}, Mid: "# "},
+				},
+				dir: tmp,
+			},
+			"",
+		},
+		{
+			"style without files",
+			"styles:\n  - mid: '-- '\n",
+			nil,
+			"style 1: comment style",
+		},
+		{
+			"invalid spdx",
+			"spdx: always\n",
+			nil,
+			`line 1: spdx expects true, false, "only" or "reuse"`,
+		},
+		{
+			"invalid ignore pattern",
+			"ignore: ['vendor/[']\n",
+			nil,
+			`ignore pattern "vendor/[" is not valid`,
+		},
+		{
+			"unknown setting",
+			"copyright: Bhojpur Consulting\n",
+			nil,
+			"field copyright not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			path := filepath.Join(tmp, configFile)
+			if err := ioutil.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			got, err := loadConfig(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("loadConfig(%q) returned error %v, want %q", tt.content, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("loadConfig(%q) returned error: %v", tt.content, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("loadConfig(%q) returned %+v, want %+v", tt.content, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindConfig(t *testing.T) {
+	tmp := tempDir(t)
+	t.Logf("tmp dir: %s", tmp)
+
+	repo := filepath.Join(tmp, "repo")
+	sub := filepath.Join(repo, "a", "b")
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(repo, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	// configuration files outside the repository are not used
+	if err := ioutil.WriteFile(filepath.Join(tmp, configFile), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, err := findConfig(sub); err != nil || got != "" {
+		t.Errorf("findConfig(%q) returned (%q, %v), want no configuration", sub, got, err)
+	}
+
+	want := filepath.Join(repo, configFile)
+	if err := ioutil.WriteFile(want, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := findConfig(sub); err != nil || got != want {
+		t.Errorf("findConfig(%q) returned (%q, %v), want %q", sub, got, err, want)
+	}
+}
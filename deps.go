@@ -0,0 +1,323 @@
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/bhojpur/license/header"
+)
+
+// dependency is the license of a module required by the main module,
+// identified in its license files.
+type dependency struct {
+	Module     string   `json:"module"`
+	Version    string   `json:"version"`
+	License    string   `json:"license,omitempty"` // SPDX expression, empty if not identified
+	Closest    string   `json:"closest,omitempty"` // closest license if not identified
+	Confidence float64  `json:"confidence"`
+	Files      []string `json:"files,omitempty"` // license files, relative to the module directory
+	Indirect   bool     `json:"indirect,omitempty"`
+	Error      string   `json:"error,omitempty"` // reason why the module could not be inspected
+}
+
+// runDeps implements the deps command, which prints the licenses of the
+// modules required by the main module, read from the module cache or the
+// vendor directory without accessing the network.
+func runDeps(args []string) error {
+	fset := flag.NewFlagSet("deps", flag.ExitOnError)
+	format := fset.String("format", "text", "output format: text or json")
+	indirect := fset.Bool("indirect", true, "include the modules only required indirectly")
+	fset.Usage = func() {
+		fmt.Fprintf(fset.Output(), "Usage: license deps [flags] [directory]\n\nFlags:\n")
+		fset.PrintDefaults()
+	}
+	fset.Parse(args)
+	if fset.NArg() > 1 {
+		fset.Usage()
+		os.Exit(1)
+	}
+	if *format != "text" && *format != "json" {
+		return fmt.Errorf("-format %q is not one of text, json", *format)
+	}
+	dir := "."
+	if fset.NArg() == 1 {
+		dir = fset.Arg(0)
+	}
+
+	deps, err := loadDependencies(dir, *indirect)
+	if err != nil {
+		return err
+	}
+	if *format == "json" {
+		err = writeJSON(os.Stdout, struct {
+			Modules []dependency `json:"modules"`
+		}{deps})
+	} else {
+		err = writeDependencies(os.Stdout, deps)
+	}
+	if err != nil {
+		return err
+	}
+	missing := 0
+	for _, d := range deps {
+		if d.Error != "" {
+			missing++
+		}
+	}
+	if missing > 0 {
+		return fmt.Errorf("%d of %d modules could not be inspected, run go mod download or go mod vendor", missing, len(deps))
+	}
+	return nil
+}
+
+// loadDependencies returns the licenses of the modules required by the
+// go.mod file in dir, sorted by module path. As go.mod files before Go 1.17
+// only list the direct dependencies, the modules of go.sum missing from them
+// are added as indirect ones; go.sum is not read for newer files, where it
+// also lists modules that are not part of the build. Modules that cannot be
+// found have their Error set.
+func loadDependencies(dir string, indirect bool) ([]dependency, error) {
+	b, err := ioutil.ReadFile(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		return nil, err
+	}
+	mod, err := parseGoMod(b)
+	if err != nil {
+		return nil, err
+	}
+	mods := mod.Requires
+	if !mod.listsAllModules() {
+		sum, err := ioutil.ReadFile(filepath.Join(dir, "go.sum"))
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		required := make(map[string]bool)
+		for _, m := range mods {
+			required[m.Path] = true
+		}
+		for _, m := range parseGoSum(sum) {
+			if !required[m.Path] {
+				m.Indirect = true
+				mods = append(mods, m)
+			}
+		}
+	}
+
+	r, err := newModuleResolver(dir, mod)
+	if err != nil {
+		return nil, err
+	}
+	var deps []dependency
+	for _, m := range mods {
+		if m.Indirect && !indirect {
+			continue
+		}
+		deps = append(deps, r.dependency(m))
+	}
+	sort.Slice(deps, func(i, j int) bool { return deps[i].Module < deps[j].Module })
+	return deps, nil
+}
+
+// moduleResolver finds the directories of modules, in the vendor directory
+// of the main module if it has one, or in the module cache.
+type moduleResolver struct {
+	dir    string // directory of the main module
+	mod    *goModFile
+	vendor string // vendor directory, if the main module has one
+	cache  string // module cache
+}
+
+func newModuleResolver(dir string, mod *goModFile) (*moduleResolver, error) {
+	r := &moduleResolver{dir: dir, mod: mod, cache: moduleCache()}
+	vendor := filepath.Join(dir, "vendor")
+	if _, err := os.Stat(filepath.Join(vendor, "modules.txt")); err == nil {
+		r.vendor = vendor
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+	return r, nil
+}
+
+// moduleCache returns the directory of the module cache, as the go command
+// would without running it.
+func moduleCache() string {
+	if dir := os.Getenv("GOMODCACHE"); dir != "" {
+		return dir
+	}
+	gopath := os.Getenv("GOPATH")
+	if gopath == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		gopath = filepath.Join(home, "go")
+	}
+	return filepath.Join(filepath.SplitList(gopath)[0], "pkg", "mod")
+}
+
+// moduleDir returns the directory holding the contents of the module m.
+func (r *moduleResolver) moduleDir(m goModule) (string, error) {
+	if r.vendor != "" {
+		return filepath.Join(r.vendor, filepath.FromSlash(m.Path)), nil
+	}
+	if repl, ok := r.mod.replacement(m); ok {
+		if repl.Version == "" {
+			// local directory
+			if filepath.IsAbs(repl.Path) {
+				return repl.Path, nil
+			}
+			return filepath.Join(r.dir, filepath.FromSlash(repl.Path)), nil
+		}
+		m = repl
+	}
+	if r.cache == "" {
+		return "", errors.New("no module cache")
+	}
+	return filepath.Join(r.cache, filepath.FromSlash(escapeModulePath(m.Path)+"@"+escapeModulePath(m.Version))), nil
+}
+
+// dependency identifies the license of the module m in its license files.
+// The licenses of several files are combined with the lowest confidence:
+// with OR when each file is named after its license, such as LICENSE-MIT and
+// LICENSE-APACHE in dual licensed modules, and with AND otherwise.
+func (r *moduleResolver) dependency(m goModule) dependency {
+	d := dependency{Module: m.Path, Version: m.Version, Indirect: m.Indirect}
+	dir, err := r.moduleDir(m)
+	if err == nil {
+		_, err = os.Stat(dir)
+	}
+	if os.IsNotExist(err) {
+		err = errors.New("not found in the module cache")
+		if r.vendor != "" {
+			err = errors.New("not found in the vendor directory")
+		}
+	}
+	if err != nil {
+		d.Error = err.Error()
+		return d
+	}
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		d.Error = err.Error()
+		return d
+	}
+
+	// license files that are not identified, such as third party notices,
+	// are listed but only give the closest license if no other file is
+	// identified
+	var licenses []string
+	var closest header.Identification
+	dual := true
+	for _, fi := range entries {
+		if !fi.Mode().IsRegular() || !licenseFileRE.MatchString(fi.Name()) {
+			continue
+		}
+		b, err := ioutil.ReadFile(filepath.Join(dir, fi.Name()))
+		if err != nil {
+			d.Error = err.Error()
+			return d
+		}
+		id := header.IdentifyText(string(b))
+		d.Files = append(d.Files, fi.Name())
+		if !id.Identified() {
+			if id.Confidence > closest.Confidence {
+				closest = id
+			}
+			continue
+		}
+		if len(licenses) == 0 || id.Confidence < d.Confidence {
+			d.Confidence = id.Confidence
+		}
+		licenses = appendUnique(licenses, id.License)
+		dual = dual && namesLicense(fi.Name(), id.License)
+	}
+	if len(licenses) == 0 {
+		d.Closest, d.Confidence = closest.License, closest.Confidence
+	}
+	op := " AND "
+	if dual && len(licenses) > 1 {
+		op = " OR "
+	}
+	d.License = strings.Join(licenses, op)
+	d.Confidence = roundConfidence(d.Confidence)
+	return d
+}
+
+// namesLicense reports whether the license file name, such as LICENSE-APACHE
+// or LICENSE-MIT.txt, is named after the license id. Case, dashes and dots
+// are ignored, so that LICENSE-APACHE2 names Apache-2.0.
+func namesLicense(name, id string) bool {
+	i := strings.IndexByte(name, '-')
+	if i < 0 {
+		return false
+	}
+	suffix := strings.ToLower(name[i+1:])
+	for _, ext := range []string{".txt", ".md"} {
+		suffix = strings.TrimSuffix(suffix, ext)
+	}
+	strip := strings.NewReplacer("-", "", ".", "")
+	suffix = strip.Replace(suffix)
+	return suffix != "" && strings.HasPrefix(strip.Replace(strings.ToLower(id)), suffix)
+}
+
+func appendUnique(list []string, s string) []string {
+	for _, v := range list {
+		if v == s {
+			return list
+		}
+	}
+	return append(list, s)
+}
+
+// writeDependencies writes a table of the licenses of the modules to w.
+func writeDependencies(w io.Writer, deps []dependency) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "MODULE\tVERSION\tLICENSE\tCONFIDENCE")
+	for _, d := range deps {
+		license := d.License
+		switch {
+		case d.Error != "":
+			license = "error: " + d.Error
+		case license == "" && d.Closest != "":
+			license = fmt.Sprintf("unknown (closest: %s)", d.Closest)
+		case license == "" && len(d.Files) == 0:
+			license = "none (no license file)"
+		case license == "":
+			license = "none"
+		}
+		module := d.Module
+		if d.Indirect {
+			module += " (indirect)"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%.0f%%\n", module, d.Version, license, d.Confidence*100)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,214 @@
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/bhojpur/license/header"
+)
+
+func TestLoadDependencies(t *testing.T) {
+	mit, err := header.LicenseText("MIT", header.LicenseData{Year: "2020", Holder: "A"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	apache, err := header.LicenseText("Apache-2.0", header.LicenseData{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmp := tempDir(t)
+	writeFiles(t, tmp, map[string]string{
+		"main/go.mod": `module example.com/main
+
+go 1.17
+
+require (
+	example.com/mit v1.0.0
+	example.com/Dual v1.1.0
+	example.com/both v1.0.0
+	example.com/notice v1.0.0
+	example.com/local v0.1.0
+	example.com/nolicense v1.0.0 // indirect
+	example.com/missing v1.0.0
+)
+
+replace example.com/local => ../local
+`,
+		"main/go.sum": "example.com/old v0.1.0 h1:abc=\nexample.com/old v0.2.0/go.mod h1:abc=\n",
+
+		"cache/example.com/mit@v1.0.0/LICENSE":          string(mit),
+		"cache/example.com/!dual@v1.1.0/LICENSE-MIT":    string(mit),
+		"cache/example.com/!dual@v1.1.0/LICENSE-APACHE": string(apache),
+		"cache/example.com/both@v1.0.0/LICENSE":         string(mit),
+		"cache/example.com/both@v1.0.0/COPYING":         string(apache),
+		"cache/example.com/notice@v1.0.0/COPYING":       "Portions of this software are copyright of their authors.",
+		"cache/example.com/notice@v1.0.0/LICENSE":       string(mit),
+		"cache/example.com/nolicense@v1.0.0/README.md":  "no license",
+		"local/LICENSE.md":                              string(apache),
+	})
+	t.Setenv("GOMODCACHE", filepath.Join(tmp, "cache"))
+
+	deps, err := loadDependencies(filepath.Join(tmp, "main"), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range deps {
+		if deps[i].License != "" && deps[i].Confidence < 0.99 {
+			t.Errorf("%s identified with confidence %v", deps[i].Module, deps[i].Confidence)
+		}
+		deps[i].Confidence, deps[i].Closest = 0, ""
+	}
+	want := []dependency{
+		{Module: "example.com/Dual", Version: "v1.1.0", License: "Apache-2.0 OR MIT", Files: []string{"LICENSE-APACHE", "LICENSE-MIT"}},
+		{Module: "example.com/both", Version: "v1.0.0", License: "Apache-2.0 AND MIT", Files: []string{"COPYING", "LICENSE"}},
+		{Module: "example.com/local", Version: "v0.1.0", License: "Apache-2.0", Files: []string{"LICENSE.md"}},
+		{Module: "example.com/missing", Version: "v1.0.0", Error: "not found in the module cache"},
+		{Module: "example.com/mit", Version: "v1.0.0", License: "MIT", Files: []string{"LICENSE"}},
+		{Module: "example.com/nolicense", Version: "v1.0.0", Indirect: true},
+		{Module: "example.com/notice", Version: "v1.0.0", License: "MIT", Files: []string{"COPYING", "LICENSE"}},
+	}
+	if !reflect.DeepEqual(deps, want) {
+		t.Errorf("loadDependencies returned\n%+v\nwant\n%+v", deps, want)
+	}
+
+	deps, err = loadDependencies(filepath.Join(tmp, "main"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deps) != 6 {
+		t.Errorf("loadDependencies without indirect modules returned %+v", deps)
+	}
+}
+
+func TestLoadDependenciesGoSum(t *testing.T) {
+	tests := []struct {
+		goDirective string
+		wantOld     bool
+	}{
+		{"", true},
+		{"go 1.16", true},
+		{"go 1.17", false},
+		{"go 1.21.0", false},
+	}
+
+	for _, tt := range tests {
+		tmp := tempDir(t)
+		writeFiles(t, tmp, map[string]string{
+			"go.mod":                               "module example.com/main\n\n" + tt.goDirective + "\n\nrequire example.com/mit v1.0.0\n",
+			"go.sum":                               "example.com/mit v1.0.0 h1:abc=\nexample.com/old v0.1.0 h1:abc=\nexample.com/old v0.2.0/go.mod h1:abc=\n",
+			"cache/example.com/old@v0.1.0/COPYING": "Some license of our own.",
+		})
+		t.Setenv("GOMODCACHE", filepath.Join(tmp, "cache"))
+
+		deps, err := loadDependencies(tmp, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var old *dependency
+		for i := range deps {
+			if deps[i].Module == "example.com/old" {
+				old = &deps[i]
+			}
+		}
+		switch {
+		case tt.wantOld && (old == nil || old.Version != "v0.1.0" || !old.Indirect):
+			t.Errorf("%q: loadDependencies returned %+v, want example.com/old v0.1.0 from go.sum", tt.goDirective, deps)
+		case !tt.wantOld && old != nil:
+			t.Errorf("%q: loadDependencies returned example.com/old from go.sum: %+v", tt.goDirective, deps)
+		}
+	}
+}
+
+func TestLoadDependenciesVendor(t *testing.T) {
+	mit, err := header.LicenseText("MIT", header.LicenseData{Year: "2020", Holder: "A"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmp := tempDir(t)
+	writeFiles(t, tmp, map[string]string{
+		"go.mod":                            "module example.com/main\n\nrequire example.com/mit v1.0.0\n",
+		"vendor/modules.txt":                "# example.com/mit v1.0.0\n## explicit\nexample.com/mit\n",
+		"vendor/example.com/mit/LICENSE":    string(mit),
+		"vendor/example.com/mit/mit.go":     "package mit\n",
+		"cache/example.com/mit@v1.0.0/null": "",
+	})
+	t.Setenv("GOMODCACHE", filepath.Join(tmp, "cache"))
+
+	deps, err := loadDependencies(tmp, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deps) != 1 || deps[0].License != "MIT" {
+		t.Errorf("loadDependencies returned %+v, want the MIT license of the vendored module", deps)
+	}
+	if _, err := loadDependencies(filepath.Join(tmp, "missing"), true); !os.IsNotExist(err) {
+		t.Errorf("loadDependencies without go.mod returned %v", err)
+	}
+}
+
+func TestNamesLicense(t *testing.T) {
+	tests := []struct {
+		name, id string
+		want     bool
+	}{
+		{"LICENSE-MIT", "MIT", true},
+		{"LICENSE-APACHE", "Apache-2.0", true},
+		{"LICENSE-APACHE2", "Apache-2.0", true},
+		{"LICENSE-Apache-2.0.txt", "Apache-2.0", true},
+		{"LICENSE-MIT.md", "MIT", true},
+		{"LICENSE-MIT", "Apache-2.0", false},
+		{"LICENSE-THIRD-PARTY", "MIT", false},
+		{"LICENSE", "MIT", false},
+		{"LICENSE.txt", "MIT", false},
+	}
+
+	for _, tt := range tests {
+		if got := namesLicense(tt.name, tt.id); got != tt.want {
+			t.Errorf("namesLicense(%q, %q) = %t, want %t", tt.name, tt.id, got, tt.want)
+		}
+	}
+}
+
+func TestWriteDependencies(t *testing.T) {
+	var buf bytes.Buffer
+	err := writeDependencies(&buf, []dependency{
+		{Module: "example.com/a", Version: "v1.0.0", License: "MIT", Confidence: 1, Files: []string{"LICENSE"}},
+		{Module: "example.com/b", Version: "v1.2.0", Closest: "ISC", Confidence: 0.5, Files: []string{"LICENSE"}, Indirect: true},
+		{Module: "example.com/c", Version: "v0.1.0"},
+		{Module: "example.com/d", Version: "v0.1.0", Error: "not found in the module cache"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "MODULE                    VERSION  LICENSE                               CONFIDENCE\n" +
+		"example.com/a             v1.0.0   MIT                                   100%\n" +
+		"example.com/b (indirect)  v1.2.0   unknown (closest: ISC)                50%\n" +
+		"example.com/c             v0.1.0   none (no license file)                0%\n" +
+		"example.com/d             v0.1.0   error: not found in the module cache  0%\n"
+	if got := buf.String(); got != want {
+		t.Errorf("writeDependencies wrote:\n%s\nwant:\n%s", got, want)
+	}
+}
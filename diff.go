@@ -0,0 +1,248 @@
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// diffContext is the number of unchanged lines shown around changes.
+const diffContext = 3
+
+// diffOp is a line of an edit script: kept (' '), deleted ('-') or
+// inserted ('+').
+type diffOp struct {
+	kind byte
+	line string
+}
+
+// splitLines splits b into lines, keeping their line endings. The last line
+// may have no line ending.
+func splitLines(b []byte) []string {
+	var lines []string
+	for len(b) > 0 {
+		i := bytes.IndexByte(b, '\n') + 1
+		if i == 0 {
+			i = len(b)
+		}
+		lines = append(lines, string(b[:i]))
+		b = b[i:]
+	}
+	return lines
+}
+
+// diffLines returns the shortest edit script turning a into b, using the
+// algorithm from "An O(ND) Difference Algorithm and Its Variations" by
+// Eugene W. Myers.
+func diffLines(a, b []string) []diffOp {
+	n, m := len(a), len(b)
+	max := n + m
+	v := make([]int, 2*max+3)
+	off := max + 1 // v[off+k] is the furthest x reached on diagonal k
+
+	// trace[d] holds v for the diagonals -d..d after step d
+	var trace [][]int
+	for d := 0; d <= max; d++ {
+		done := false
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[off+k-1] < v[off+k+1]) {
+				x = v[off+k+1] // insertion
+			} else {
+				x = v[off+k-1] + 1 // deletion
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[off+k] = x
+			if x >= n && y >= m {
+				done = true
+				break
+			}
+		}
+		trace = append(trace, append([]int(nil), v[off-d:off+d+1]...))
+		if done {
+			break
+		}
+	}
+
+	// walk back from the end to recover the edit script
+	var ops []diffOp
+	x, y := n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		k := x - y
+		var prevK, prevX int
+		if d > 0 {
+			prev := trace[d-1] // diagonals -(d-1)..d-1
+			at := func(k int) int { return prev[k+d-1] }
+			if k == -d || (k != d && at(k-1) < at(k+1)) {
+				prevK = k + 1
+			} else {
+				prevK = k - 1
+			}
+			prevX = at(prevK)
+		}
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x--
+			y--
+			ops = append(ops, diffOp{' ', a[x]})
+		}
+		if d == 0 {
+			break
+		}
+		if x == prevX {
+			y--
+			ops = append(ops, diffOp{'+', b[y]})
+		} else {
+			x--
+			ops = append(ops, diffOp{'-', a[x]})
+		}
+	}
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+	return ops
+}
+
+// unifiedDiff returns the changes from a to b, the old and new contents of
+// the file at path, as a unified diff in the format of git diff, which can
+// be applied with git apply or patch -p1. A nil a or b stands for a file
+// that is created or deleted. It returns nil if a and b are equal.
+func unifiedDiff(path string, a, b []byte) []byte {
+	if (a == nil) == (b == nil) && bytes.Equal(a, b) {
+		return nil
+	}
+	ops := diffLines(splitLines(a), splitLines(b))
+	name := diffPath(path)
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "diff --git a/%s b/%s\n", name, name)
+	switch {
+	case a == nil:
+		fmt.Fprintf(&out, "new file mode 100644\n--- /dev/null\n+++ b/%s\n", name)
+	case b == nil:
+		fmt.Fprintf(&out, "deleted file mode 100644\n--- a/%s\n+++ /dev/null\n", name)
+	default:
+		fmt.Fprintf(&out, "--- a/%s\n", name)
+		fmt.Fprintf(&out, "+++ b/%s\n", name)
+	}
+
+	// line numbers before each op, in a and b
+	ai, bi := make([]int, len(ops)+1), make([]int, len(ops)+1)
+	for i, op := range ops {
+		ai[i+1], bi[i+1] = ai[i], bi[i]
+		if op.kind != '+' {
+			ai[i+1]++
+		}
+		if op.kind != '-' {
+			bi[i+1]++
+		}
+	}
+
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			i++
+			continue
+		}
+		// extend the hunk while changes are close enough to share context
+		start := i - diffContext
+		if start < 0 {
+			start = 0
+		}
+		end := i
+		for j := i; j < len(ops); j++ {
+			if ops[j].kind != ' ' {
+				end = j + 1
+			} else if j-end >= 2*diffContext {
+				break
+			}
+		}
+		if end += diffContext; end > len(ops) {
+			end = len(ops)
+		}
+
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n",
+			hunkRange(ai[start], ai[end]-ai[start]), hunkRange(bi[start], bi[end]-bi[start]))
+		for _, op := range ops[start:end] {
+			out.WriteByte(op.kind)
+			out.WriteString(op.line)
+			if !strings.HasSuffix(op.line, "\n") {
+				out.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		i = end
+	}
+	return out.Bytes()
+}
+
+// hunkRange formats the range of count lines following line start of a
+// hunk header, omitting a count of one as git diff does.
+func hunkRange(start, count int) string {
+	switch count {
+	case 0:
+		return fmt.Sprintf("%d,0", start)
+	case 1:
+		return fmt.Sprint(start + 1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+// diffPath returns path in the form used by diff headers: relative, with
+// forward slashes.
+func diffPath(path string) string {
+	if filepath.IsAbs(path) {
+		if wd, err := os.Getwd(); err == nil {
+			if rel, err := filepath.Rel(wd, path); err == nil {
+				path = rel
+			}
+		}
+	}
+	return filepath.ToSlash(filepath.Clean(path))
+}
+
+// diffWriter writes the diffs of files to w, one file at a time. It is safe
+// for concurrent use.
+type diffWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// write writes the diff between the old contents a and new contents b of
+// the file at path.
+func (d *diffWriter) write(path string, a, b []byte) error {
+	diff := unifiedDiff(path, a, b)
+	if diff == nil {
+		return nil
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	_, err := d.w.Write(diff)
+	return err
+}
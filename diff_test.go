@@ -0,0 +1,161 @@
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDiffLines(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int // number of changed lines
+	}{
+		{"", "", 0},
+		{"a\n", "a\n", 0},
+		{"", "a\nb\n", 2},
+		{"a\nb\n", "", 2},
+		{"a\nb\nc\n", "a\nc\n", 1},
+		{"a\nc\n", "a\nb\nc\n", 1},
+		{"a\nb\nc\nd\n", "x\nb\nc\ny\n", 4},
+		{"a\nb\nc\na\nb\nb\na\n", "c\nb\na\nb\na\nc\n", 5},
+	}
+
+	for _, tt := range tests {
+		ops := diffLines(splitLines([]byte(tt.a)), splitLines([]byte(tt.b)))
+		var a, b strings.Builder
+		changed := 0
+		for _, op := range ops {
+			if op.kind != '+' {
+				a.WriteString(op.line)
+			}
+			if op.kind != '-' {
+				b.WriteString(op.line)
+			}
+			if op.kind != ' ' {
+				changed++
+			}
+		}
+		if a.String() != tt.a || b.String() != tt.b {
+			t.Errorf("diffLines(%q, %q) returned a script from %q to %q", tt.a, tt.b, a.String(), b.String())
+		}
+		if changed != tt.want {
+			t.Errorf("diffLines(%q, %q) changed %d lines, want %d", tt.a, tt.b, changed, tt.want)
+		}
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		description string
+		a, b        string
+		want        string
+	}{
+		{
+			"no change",
+			"a\n",
+			"a\n",
+			"",
+		},
+		{
+			"insertion at the top",
+			"a\nb\nc\nd\ne\n",
+			"// H\n\na\nb\nc\nd\ne\n",
+			"@@ -1,3 +1,5 @@\n+// H\n+\n a\n b\n c\n",
+		},
+		{
+			"insertion after a line without newline",
+			"#!/bin/sh",
+			"#!/bin/sh\n# H\n",
+			"@@ -1 +1,2 @@\n-#!/bin/sh\n\\ No newline at end of file\n+#!/bin/sh\n+# H\n",
+		},
+		{
+			"new file",
+			"",
+			"a\n",
+			"@@ -0,0 +1 @@\n+a\n",
+		},
+		{
+			"separate hunks",
+			"1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n",
+			"0\n1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n13\n",
+			"@@ -1,3 +1,4 @@\n+0\n 1\n 2\n 3\n@@ -10,3 +11,4 @@\n 10\n 11\n 12\n+13\n",
+		},
+		{
+			"merged hunks",
+			"1\n2\n3\n4\n5\n6\n",
+			"0\n1\n2\n3\n4\n5\n6\n9\n",
+			"@@ -1,6 +1,8 @@\n+0\n 1\n 2\n 3\n 4\n 5\n 6\n+9\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			got := string(unifiedDiff("dir/file.go", []byte(tt.a), []byte(tt.b)))
+			want := tt.want
+			if want != "" {
+				want = "diff --git a/dir/file.go b/dir/file.go\n--- a/dir/file.go\n+++ b/dir/file.go\n" + want
+			}
+			if got != want {
+				t.Errorf("unifiedDiff(%q, %q) returned:\n%s\nwant:\n%s", tt.a, tt.b, got, want)
+			}
+		})
+	}
+}
+
+func TestUnifiedDiffCreateDelete(t *testing.T) {
+	tests := []struct {
+		description string
+		a, b        []byte
+		want        string
+	}{
+		{
+			"created file",
+			nil,
+			[]byte("a\nb\n"),
+			"diff --git a/dir/file.go b/dir/file.go\nnew file mode 100644\n--- /dev/null\n+++ b/dir/file.go\n" +
+				"@@ -0,0 +1,2 @@\n+a\n+b\n",
+		},
+		{
+			"deleted file",
+			[]byte("a\n"),
+			nil,
+			"diff --git a/dir/file.go b/dir/file.go\ndeleted file mode 100644\n--- a/dir/file.go\n+++ /dev/null\n" +
+				"@@ -1 +0,0 @@\n-a\n",
+		},
+		{
+			"created empty file",
+			nil,
+			[]byte{},
+			"diff --git a/dir/file.go b/dir/file.go\nnew file mode 100644\n--- /dev/null\n+++ b/dir/file.go\n",
+		},
+		{"absent file", nil, nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			if got := string(unifiedDiff("dir/file.go", tt.a, tt.b)); got != tt.want {
+				t.Errorf("unifiedDiff(%q, %q) returned:\n%s\nwant:\n%s", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// gitYears returns the copyright years of the file at path according to the
+// local git repository: the years of its first and last commits, such as
+// "2018-2022", or a single year if both are the same. It returns an empty
+// string if the file has no commits, including outside of a repository or in
+// a repository without commits.
+func gitYears(path string) (string, error) {
+	dir, name := filepath.Split(path)
+	if dir == "" {
+		dir = "."
+	}
+	out, err := git(dir, "log", "--follow", "--format=%ad", "--date=format:%Y", "--", name)
+	if err != nil {
+		if abs, aerr := filepath.Abs(dir); aerr == nil && repoRoot(abs) == "" {
+			return "", nil
+		}
+		if _, herr := git(dir, "rev-parse", "-q", "--verify", "HEAD"); herr != nil {
+			return "", nil
+		}
+		return "", err
+	}
+	years := strings.Fields(string(out))
+	if len(years) == 0 {
+		return "", nil
+	}
+	// commits are listed from newest to oldest
+	first, last := years[len(years)-1], years[0]
+	if first == last {
+		return first, nil
+	}
+	return first + "-" + last, nil
+}
+
+// git runs the git command with args in dir and returns its standard output.
+func git(dir string, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return nil, fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return out, nil
+}
+
+// trackedFiles returns the set of absolute paths of the files tracked by the
+// git index under path, which may be a directory or a single file.
+func trackedFiles(path string) (map[string]bool, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	dir, args := abs, []string{"ls-files", "-z"}
+	if fi, err := os.Stat(abs); err == nil && !fi.IsDir() {
+		dir = filepath.Dir(abs)
+		args = append(args, "--", filepath.Base(abs))
+	}
+	// ls-files lists the files relative to the directory it runs in
+	out, err := git(dir, args...)
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[string]bool)
+	for _, name := range strings.Split(string(out), "\x00") {
+		if name != "" {
+			files[filepath.Join(dir, filepath.FromSlash(name))] = true
+		}
+	}
+	return files, nil
+}
+
+// changedFiles returns the files under path, which may be a directory or a
+// single file, that were added or modified since the merge base of rev and
+// HEAD, including uncommitted changes. The returned paths are prefixed with
+// the directory of path, like the ones found by walking it.
+func changedFiles(path, rev string) ([]string, error) {
+	dir, pathspec := path, "."
+	if fi, err := os.Stat(path); err == nil && !fi.IsDir() {
+		dir, pathspec = filepath.Dir(path), filepath.Base(path)
+	}
+	base, err := git(dir, "merge-base", rev, "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	out, err := git(dir, "diff", "--name-only", "--relative", "-z", "--diff-filter=ACMR",
+		strings.TrimSpace(string(base)), "--", pathspec)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, name := range strings.Split(string(out), "\x00") {
+		if name != "" {
+			files = append(files, filepath.Join(dir, filepath.FromSlash(name)))
+		}
+	}
+	return files, nil
+}
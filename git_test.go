@@ -0,0 +1,175 @@
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// gitCommit commits all the files in the git repository dir with the given
+// author and committer date.
+func gitCommit(t *testing.T, dir, date string) {
+	for _, args := range [][]string{
+		{"add", "-A"},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", date},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_DATE="+date, "GIT_COMMITTER_DATE="+date)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+}
+
+func TestGitYears(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found")
+	}
+	tmp := tempDir(t)
+	t.Logf("tmp dir: %s", tmp)
+	run(t, "git", "init", "-q", tmp)
+
+	write := func(name, content string) {
+		if err := ioutil.WriteFile(filepath.Join(tmp, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("a.go", "a")
+	write("b.go", "b")
+	gitCommit(t, tmp, "2018-05-01T12:00:00Z")
+	write("a.go", "aa")
+	gitCommit(t, tmp, "2020-05-01T12:00:00Z")
+	write("a.go", "aaa")
+	gitCommit(t, tmp, "2022-05-01T12:00:00Z")
+	write("c.go", "c")
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"a.go", "2018-2022"},
+		{"b.go", "2018"},
+		{"c.go", ""}, // untracked
+	}
+
+	for _, tt := range tests {
+		got, err := gitYears(filepath.Join(tmp, tt.name))
+		if err != nil {
+			t.Errorf("gitYears(%q) returned error: %v", tt.name, err)
+		}
+		if got != tt.want {
+			t.Errorf("gitYears(%q) returned %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestGitYearsWithoutHistory(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found")
+	}
+	plain, empty := tempDir(t), tempDir(t)
+	if repoRoot(plain) != "" {
+		t.Skip("the temporary directory is inside a git repository")
+	}
+	run(t, "git", "init", "-q", empty)
+
+	// outside of a repository, or in a repository without commits, files
+	// have no commits
+	for _, dir := range []string{plain, empty} {
+		path := filepath.Join(dir, "a.go")
+		if err := ioutil.WriteFile(path, []byte("a"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if got, err := gitYears(path); got != "" || err != nil {
+			t.Errorf("gitYears(%q) returned %q, %v, want no years", path, got, err)
+		}
+	}
+}
+
+func TestChangedFiles(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found")
+	}
+	tmp := tempDir(t)
+	t.Logf("tmp dir: %s", tmp)
+	run(t, "git", "init", "-q", tmp)
+
+	writeFiles(t, tmp, map[string]string{
+		"modified.go":       "a",
+		"deleted.go":        "a",
+		"unchanged.go":      "a",
+		"uncommitted.go":    "a",
+		"sub/modified.go":   "a",
+		"other/modified.go": "a",
+	})
+	gitCommit(t, tmp, "2022-01-01T00:00:00Z")
+	run(t, "git", "-C", tmp, "tag", "base")
+
+	writeFiles(t, tmp, map[string]string{
+		"modified.go":       "b",
+		"added.go":          "b",
+		"sub/modified.go":   "b",
+		"other/modified.go": "b",
+	})
+	run(t, "rm", filepath.Join(tmp, "deleted.go"))
+	gitCommit(t, tmp, "2022-02-01T00:00:00Z")
+	writeFiles(t, tmp, map[string]string{
+		"uncommitted.go": "b",
+		"untracked.go":   "b",
+	})
+
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{"", []string{"added.go", "modified.go", "other/modified.go", "sub/modified.go", "uncommitted.go"}},
+		{"sub", []string{"sub/modified.go"}},
+		{"modified.go", []string{"modified.go"}},
+		{"unchanged.go", nil},
+	}
+
+	for _, tt := range tests {
+		got, err := changedFiles(filepath.Join(tmp, tt.path), "base")
+		if err != nil {
+			t.Fatalf("changedFiles(%q) returned error: %v", tt.path, err)
+		}
+		var rel []string
+		for _, f := range got {
+			r, _ := filepath.Rel(tmp, f)
+			rel = append(rel, filepath.ToSlash(r))
+		}
+		sort.Strings(rel)
+		if !reflect.DeepEqual(rel, tt.want) {
+			t.Errorf("changedFiles(%q) returned %q, want %q", tt.path, rel, tt.want)
+		}
+	}
+
+	if _, err := changedFiles(tmp, "unknown"); err == nil {
+		t.Error("changedFiles with an unknown revision returned no error")
+	}
+}
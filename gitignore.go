@@ -0,0 +1,189 @@
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	doublestar "github.com/bmatcuk/doublestar/v4"
+)
+
+// ignoreRule is a pattern read from a gitignore file.
+type ignoreRule struct {
+	pattern string // doublestar pattern, relative to the directory of the file
+	negate  bool   // the pattern re-includes matching files
+	dirOnly bool   // the pattern only matches directories
+}
+
+// parseIgnoreFile parses the contents of a gitignore file.
+func parseIgnoreFile(b []byte) []ignoreRule {
+	var rules []ignoreRule
+	s := bufio.NewScanner(bytes.NewReader(b))
+	for s.Scan() {
+		line := strings.TrimRight(s.Text(), "\r")
+		// trailing spaces are ignored unless escaped
+		for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, `\ `) {
+			line = line[:len(line)-1]
+		}
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		var r ignoreRule
+		if line[0] == '!' {
+			r.negate = true
+			line = line[1:]
+		} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			r.dirOnly = true
+			line = strings.TrimRight(line, "/")
+		}
+		if line == "" {
+			continue
+		}
+		if strings.Contains(line, "/") {
+			// patterns with a slash are relative to the gitignore file
+			line = strings.TrimPrefix(line, "/")
+		} else {
+			line = "**/" + line
+		}
+		if !doublestar.ValidatePattern(line) {
+			continue
+		}
+		r.pattern = line
+		rules = append(rules, r)
+	}
+	return rules
+}
+
+// gitIgnore reports whether paths are ignored by the .gitignore files of a
+// git repository and its .git/info/exclude file. It is not safe for
+// concurrent use.
+type gitIgnore struct {
+	root    string                  // root directory of the repository
+	exclude []ignoreRule            // rules of .git/info/exclude
+	rules   map[string][]ignoreRule // rules of the .gitignore file of each directory loaded so far
+}
+
+// newGitIgnore returns the ignore rules of the git repository containing
+// path, or of the directory path if it is not inside a repository.
+func newGitIgnore(path string) (*gitIgnore, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	root := repoRoot(abs)
+	if root == "" {
+		root = abs
+		if fi, err := os.Stat(abs); err == nil && !fi.IsDir() {
+			root = filepath.Dir(abs)
+		}
+	}
+	g := &gitIgnore{root: root, rules: make(map[string][]ignoreRule)}
+	if b, err := ioutil.ReadFile(filepath.Join(root, ".git", "info", "exclude")); err == nil {
+		g.exclude = parseIgnoreFile(b)
+	}
+	return g, nil
+}
+
+// repoRoot returns the root directory of the git repository containing the
+// absolute path, or an empty string if path is not inside a repository.
+func repoRoot(path string) string {
+	for dir := path; ; {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// dirRules returns the rules of the .gitignore file in dir.
+func (g *gitIgnore) dirRules(dir string) []ignoreRule {
+	rules, ok := g.rules[dir]
+	if !ok {
+		if b, err := ioutil.ReadFile(filepath.Join(dir, ".gitignore")); err == nil {
+			rules = parseIgnoreFile(b)
+		}
+		g.rules[dir] = rules
+	}
+	return rules
+}
+
+// ignored reports whether path is ignored. Files inside ignored directories
+// are ignored, as git does not descend into them.
+func (g *gitIgnore) ignored(path string, isDir bool) bool {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	rel, err := filepath.Rel(g.root, abs)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return false
+	}
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+	for i := range parts {
+		dir := i < len(parts)-1 || isDir
+		if dir && parts[i] == ".git" {
+			return true
+		}
+		if g.match(parts[:i+1], dir) {
+			return true
+		}
+	}
+	return false
+}
+
+// match reports whether the path made of parts, relative to the root of
+// the repository, is ignored by the rules of the directories containing it.
+// The last matching rule wins: rules of .git/info/exclude come first, and
+// deeper .gitignore files take precedence over the ones above them.
+func (g *gitIgnore) match(parts []string, isDir bool) bool {
+	ignored := false
+	apply := func(rules []ignoreRule, rel string) {
+		for _, r := range rules {
+			if r.dirOnly && !isDir {
+				continue
+			}
+			if match, _ := doublestar.Match(r.pattern, rel); match {
+				ignored = !r.negate
+			}
+		}
+	}
+	apply(g.exclude, strings.Join(parts, "/"))
+	dir := g.root
+	for i := range parts {
+		if i > 0 {
+			dir = filepath.Join(dir, parts[i-1])
+		}
+		apply(g.dirRules(dir), strings.Join(parts[i:], "/"))
+	}
+	return ignored
+}
@@ -0,0 +1,176 @@
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestParseIgnoreFile(t *testing.T) {
+	const content = `# comment
+
+*.log
+!keep.log
+build/
+/root.go
+doc/*.txt
+\#hash
+\!bang
+trailing   
+`
+	want := []ignoreRule{
+		{pattern: "**/*.log"},
+		{pattern: "**/keep.log", negate: true},
+		{pattern: "**/build", dirOnly: true},
+		{pattern: "root.go"},
+		{pattern: "doc/*.txt"},
+		{pattern: "**/#hash"},
+		{pattern: "**/!bang"},
+		{pattern: "**/trailing"},
+	}
+	if got := parseIgnoreFile([]byte(content)); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseIgnoreFile returned %+v, want %+v", got, want)
+	}
+}
+
+// writeFiles creates the files with the given contents under dir.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestGitIgnore(t *testing.T) {
+	tmp := tempDir(t)
+	t.Logf("tmp dir: %s", tmp)
+	writeFiles(t, tmp, map[string]string{
+		".git/info/exclude":   "secret.go\n",
+		".gitignore":          "*.log\n!keep.log\nbuild/\n/root.go\nnode_modules\n",
+		"sub/.gitignore":      "gen.go\n!root.go\n/local.go\n*.txt\n",
+		"sub/deep/.gitignore": "!notes.txt\n",
+	})
+
+	tests := []struct {
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{"main.go", false, false},
+		{"app.log", false, true},
+		{"sub/app.log", false, true},
+		{"keep.log", false, false},
+		{"build", true, true},
+		{"build", false, false}, // only directories
+		{"build/main.go", false, true},
+		{"sub/build/main.go", false, true},
+		{"root.go", false, true},
+		{"sub/root.go", false, false},
+		{"node_modules/x/index.js", false, true},
+		{"secret.go", false, true},
+		{"sub/secret.go", false, true},
+		{"sub/gen.go", false, true},
+		{"sub/deep/gen.go", false, true},
+		{"gen.go", false, false},
+		{"sub/local.go", false, true},
+		{"sub/deep/local.go", false, false},
+		{"sub/a.txt", false, true},
+		{"sub/deep/notes.txt", false, false},
+		{".git", true, true},
+		{".git/config", false, true},
+	}
+
+	g, err := newGitIgnore(tmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		path := filepath.Join(tmp, filepath.FromSlash(tt.path))
+		if got := g.ignored(path, tt.isDir); got != tt.want {
+			t.Errorf("ignored(%q, %t) returned %t, want %t", tt.path, tt.isDir, got, tt.want)
+		}
+	}
+}
+
+func TestWalkGit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found")
+	}
+	tmp := tempDir(t)
+	t.Logf("tmp dir: %s", tmp)
+	run(t, "git", "init", "-q", tmp)
+	writeFiles(t, tmp, map[string]string{
+		".gitignore":     "build/\n",
+		"main.go":        "",
+		"sub/tracked.go": "",
+		"sub/ignored.go": "",
+		"sub/.gitignore": "ignored.go\n",
+	})
+	gitCommit(t, tmp, "2022-01-01T00:00:00Z")
+	writeFiles(t, tmp, map[string]string{
+		"untracked.go":  "",
+		"build/main.go": "",
+	})
+
+	tests := []struct {
+		gitignore, gitTracked bool
+		want                  []string
+	}{
+		{false, false, []string{".gitignore", "build/main.go", "main.go", "sub/.gitignore", "sub/ignored.go", "sub/tracked.go", "untracked.go"}},
+		{true, false, []string{".gitignore", "main.go", "sub/.gitignore", "sub/tracked.go", "untracked.go"}},
+		{false, true, []string{".gitignore", "main.go", "sub/.gitignore", "sub/tracked.go"}},
+	}
+
+	defer func(i, t bool) { *gitignore, *gitTracked = i, t }(*gitignore, *gitTracked)
+	for _, tt := range tests {
+		*gitignore, *gitTracked = tt.gitignore, tt.gitTracked
+		ch := make(chan *file, 100)
+		if err := walk(context.Background(), ch, tmp); err != nil {
+			t.Fatal(err)
+		}
+		close(ch)
+		var got []string
+		for f := range ch {
+			rel, _ := filepath.Rel(tmp, f.path)
+			rel = filepath.ToSlash(rel)
+			if rel == ".git" || len(rel) > 5 && rel[:5] == ".git/" {
+				continue
+			}
+			got = append(got, rel)
+		}
+		sort.Strings(got)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("walk with gitignore %t and git-tracked %t returned %q, want %q", tt.gitignore, tt.gitTracked, got, tt.want)
+		}
+	}
+}
@@ -3,6 +3,8 @@ module github.com/bhojpur/license
 go 1.17
 
 require (
+	github.com/BurntSushi/toml v1.2.1
 	github.com/bmatcuk/doublestar/v4 v4.0.2
 	golang.org/x/sync v0.0.0-20190911185100-cd5d95a43a6e
+	gopkg.in/yaml.v3 v3.0.1
 )
@@ -0,0 +1,252 @@
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// goModule is a module version required by a go.mod file.
+type goModule struct {
+	Path     string
+	Version  string
+	Indirect bool // marked with an // indirect comment
+}
+
+// goModFile is the subset of a go.mod file needed to list the dependencies
+// of the main module.
+type goModFile struct {
+	Module   string
+	Go       string // go directive
+	Requires []goModule
+	Replaces map[string]goModule // replacements keyed by module path, or path@version for a single version
+}
+
+// replacement returns the module replacing m, and false if there is none.
+// Local replacements have a directory as their path and no version.
+func (f *goModFile) replacement(m goModule) (goModule, bool) {
+	if r, ok := f.Replaces[m.Path+"@"+m.Version]; ok {
+		return r, true
+	}
+	r, ok := f.Replaces[m.Path]
+	return r, ok
+}
+
+// listsAllModules reports whether the go.mod file lists all the modules
+// providing packages to the build, as done since Go 1.17. Older files, and
+// files without a go directive, only list the direct dependencies.
+func (f *goModFile) listsAllModules() bool {
+	return f.Go != "" && compareVersions(f.Go, "1.17") >= 0
+}
+
+// parseGoMod parses the module, go, require and replace directives of the
+// go.mod file b, ignoring the other ones.
+func parseGoMod(b []byte) (*goModFile, error) {
+	f := &goModFile{Replaces: make(map[string]goModule)}
+	block := "" // verb of the current block
+	s := bufio.NewScanner(bytes.NewReader(b))
+	for n := 1; s.Scan(); n++ {
+		line := s.Text()
+		comment := ""
+		if i := strings.Index(line, "//"); i >= 0 {
+			line, comment = line[:i], strings.TrimSpace(line[i+2:])
+		}
+		fields, err := goModFields(line)
+		if err != nil {
+			return nil, fmt.Errorf("go.mod:%d: %w", n, err)
+		}
+		if len(fields) == 0 {
+			continue
+		}
+		verb := block
+		switch {
+		case block != "" && fields[0] == ")":
+			block = ""
+			continue
+		case block == "" && len(fields) == 2 && fields[1] == "(":
+			block = fields[0]
+			continue
+		case block == "":
+			verb, fields = fields[0], fields[1:]
+		}
+
+		switch verb {
+		case "module":
+			if len(fields) != 1 {
+				return nil, fmt.Errorf("go.mod:%d: usage: module path", n)
+			}
+			f.Module = fields[0]
+		case "go":
+			if len(fields) != 1 {
+				return nil, fmt.Errorf("go.mod:%d: usage: go 1.23", n)
+			}
+			f.Go = fields[0]
+		case "require":
+			if len(fields) != 2 {
+				return nil, fmt.Errorf("go.mod:%d: usage: require module/path v1.2.3", n)
+			}
+			indirect := comment == "indirect" || strings.HasPrefix(comment, "indirect;")
+			f.Requires = append(f.Requires, goModule{Path: fields[0], Version: fields[1], Indirect: indirect})
+		case "replace":
+			arrow := -1
+			for i, field := range fields {
+				if field == "=>" {
+					arrow = i
+				}
+			}
+			old, repl := fields[:maxInt(arrow, 0)], fields[arrow+1:]
+			if arrow < 1 || len(old) > 2 || len(repl) == 0 || len(repl) > 2 {
+				return nil, fmt.Errorf("go.mod:%d: usage: replace module/path [v1.2.3] => other/module v1.4.5 or local/dir", n)
+			}
+			key := old[0]
+			if len(old) == 2 {
+				key += "@" + old[1]
+			}
+			r := goModule{Path: repl[0]}
+			if len(repl) == 2 {
+				r.Version = repl[1]
+			}
+			f.Replaces[key] = r
+		}
+	}
+	return f, s.Err()
+}
+
+// goModFields splits a go.mod line into its fields, which may be quoted.
+func goModFields(line string) ([]string, error) {
+	var fields []string
+	for {
+		line = strings.TrimLeftFunc(line, unicode.IsSpace)
+		if line == "" {
+			return fields, nil
+		}
+		if line[0] == '"' || line[0] == '`' {
+			q, err := strconv.QuotedPrefix(line)
+			if err != nil {
+				return nil, fmt.Errorf("invalid quoted string: %s", line)
+			}
+			v, _ := strconv.Unquote(q)
+			fields, line = append(fields, v), line[len(q):]
+			continue
+		}
+		i := strings.IndexFunc(line, unicode.IsSpace)
+		if i < 0 {
+			i = len(line)
+		}
+		fields, line = append(fields, line[:i]), line[i:]
+	}
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// parseGoSum returns the latest version of each module whose contents are
+// listed in the go.sum file b. Modules only listed for their go.mod file are
+// not part of the build.
+func parseGoSum(b []byte) []goModule {
+	latest := make(map[string]string)
+	for _, line := range strings.Split(string(b), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 3 || strings.HasSuffix(fields[1], "/go.mod") {
+			continue
+		}
+		if v, ok := latest[fields[0]]; !ok || compareVersions(fields[1], v) > 0 {
+			latest[fields[0]] = fields[1]
+		}
+	}
+	mods := make([]goModule, 0, len(latest))
+	for path, v := range latest {
+		mods = append(mods, goModule{Path: path, Version: v})
+	}
+	sort.Slice(mods, func(i, j int) bool { return mods[i].Path < mods[j].Path })
+	return mods
+}
+
+// compareVersions compares the semantic versions a and b, such as v1.2.3,
+// v1.2.3-pre or v0.0.0-20190911185100-cd5d95a43a6e, returning -1, 0 or +1.
+// Pre-releases are compared as strings, which orders pseudo-versions by
+// their timestamp, and build metadata such as +incompatible is ignored.
+func compareVersions(a, b string) int {
+	splitVersion := func(v string) ([]string, string) {
+		v = strings.TrimPrefix(v, "v")
+		if i := strings.Index(v, "+"); i >= 0 {
+			v = v[:i]
+		}
+		pre := ""
+		if i := strings.Index(v, "-"); i >= 0 {
+			v, pre = v[:i], v[i+1:]
+		}
+		return strings.Split(v, "."), pre
+	}
+	an, apre := splitVersion(a)
+	bn, bpre := splitVersion(b)
+	for i := 0; i < len(an) || i < len(bn); i++ {
+		var x, y int
+		if i < len(an) {
+			x, _ = strconv.Atoi(an[i])
+		}
+		if i < len(bn) {
+			y, _ = strconv.Atoi(bn[i])
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	switch {
+	case apre == bpre:
+		return 0
+	case apre == "":
+		return 1 // a release is newer than its pre-releases
+	case bpre == "":
+		return -1
+	case apre < bpre:
+		return -1
+	}
+	return 1
+}
+
+// escapeModulePath escapes the upper case letters of a module path or
+// version as the module cache does, such as "!burnt!sushi" for
+// "BurntSushi".
+func escapeModulePath(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if unicode.IsUpper(r) {
+			b.WriteByte('!')
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,128 @@
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseGoMod(t *testing.T) {
+	f, err := parseGoMod([]byte(`// Copyright notice
+module "example.com/main"
+
+go 1.21
+
+require example.com/a v1.0.0
+
+require (
+	example.com/b v1.2.3 // indirect
+	example.com/C v0.0.0-20190911185100-cd5d95a43a6e // indirect; used by tests
+	example.com/d v2.0.0+incompatible // a comment
+)
+
+exclude example.com/a v0.9.0
+
+replace example.com/b => ../b
+
+replace (
+	example.com/d v2.0.0+incompatible => example.com/e v1.0.0
+)
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &goModFile{
+		Module: "example.com/main",
+		Go:     "1.21",
+		Requires: []goModule{
+			{Path: "example.com/a", Version: "v1.0.0"},
+			{Path: "example.com/b", Version: "v1.2.3", Indirect: true},
+			{Path: "example.com/C", Version: "v0.0.0-20190911185100-cd5d95a43a6e", Indirect: true},
+			{Path: "example.com/d", Version: "v2.0.0+incompatible"},
+		},
+		Replaces: map[string]goModule{
+			"example.com/b":                     {Path: "../b"},
+			"example.com/d@v2.0.0+incompatible": {Path: "example.com/e", Version: "v1.0.0"},
+		},
+	}
+	if !reflect.DeepEqual(f, want) {
+		t.Errorf("parseGoMod returned %+v, want %+v", f, want)
+	}
+	if r, ok := f.replacement(goModule{Path: "example.com/d", Version: "v2.0.0+incompatible"}); !ok || r.Path != "example.com/e" {
+		t.Errorf("replacement of example.com/d returned %+v, %t", r, ok)
+	}
+	if _, ok := f.replacement(goModule{Path: "example.com/a", Version: "v1.0.0"}); ok {
+		t.Error("replacement of example.com/a returned a replacement")
+	}
+}
+
+func TestParseGoModErrors(t *testing.T) {
+	for _, content := range []string{
+		"module a b\n",
+		"require example.com/a\n",
+		"replace example.com/a v1 v2 => b\n",
+		"replace example.com/a\n",
+		"module \"a\n",
+	} {
+		if _, err := parseGoMod([]byte(content)); err == nil {
+			t.Errorf("parseGoMod(%q) returned no error", content)
+		}
+	}
+}
+
+func TestParseGoSum(t *testing.T) {
+	got := parseGoSum([]byte(`example.com/a v1.0.0 h1:abc=
+example.com/a v1.0.0/go.mod h1:def=
+example.com/a v1.10.0 h1:abc=
+example.com/a v1.9.0 h1:abc=
+example.com/b v1.0.0/go.mod h1:def=
+`))
+	want := []goModule{{Path: "example.com/a", Version: "v1.10.0"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseGoSum returned %+v, want %+v", got, want)
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"v1.0.0", "v1.0.0", 0},
+		{"v1.2.0", "v1.10.0", -1},
+		{"v2.0.0", "v1.9.9", 1},
+		{"v1.0.0-rc.1", "v1.0.0", -1},
+		{"v0.0.0-20200101000000-aaaaaaaaaaaa", "v0.0.0-20190101000000-bbbbbbbbbbbb", 1},
+		{"v2.0.0+incompatible", "v2.0.0", 0},
+	}
+	for _, tt := range tests {
+		if got := compareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("compareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestEscapeModulePath(t *testing.T) {
+	if got, want := escapeModulePath("github.com/BurntSushi/toml"), "github.com/!burnt!sushi/toml"; got != want {
+		t.Errorf("escapeModulePath returned %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,186 @@
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package header
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// CheckStatus is the outcome of a license header check.
+type CheckStatus int
+
+const (
+	StatusOK                CheckStatus = iota
+	StatusMissing                       // no license header
+	StatusMismatchedLicense             // header of another license
+	StatusMismatchedHolder              // header of another copyright holder
+	StatusMismatchedYear                // header not covering the copyright year
+	StatusError                         // file could not be checked
+)
+
+func (s CheckStatus) String() string {
+	switch s {
+	case StatusOK:
+		return "ok"
+	case StatusMissing:
+		return "missing"
+	case StatusMismatchedLicense:
+		return "mismatched-license"
+	case StatusMismatchedHolder:
+		return "mismatched-holder"
+	case StatusMismatchedYear:
+		return "mismatched-year"
+	case StatusError:
+		return "error"
+	}
+	return fmt.Sprintf("CheckStatus(%d)", int(s))
+}
+
+// CheckResult describes the outcome of checking the license header of a file.
+type CheckResult struct {
+	Status  CheckStatus
+	Reason  string // human readable explanation of a failure
+	License string // license of the header found in the file, if recognized
+}
+
+// checkPresence checks that the contents b of a file have a license header
+// of any kind. Generated files pass the check.
+func checkPresence(styles *StyleRegistry, b []byte) CheckResult {
+	if !HasLicense(b) && !IsGenerated(b) {
+		return CheckResult{Status: StatusMissing, Reason: "missing license header"}
+	}
+	res := CheckResult{Status: StatusOK}
+	if h := findHeader(styles, b); h != nil {
+		res.License = h.license
+	} else if id := Identify(b); id.Identified() {
+		res.License = id.License
+	}
+	return res
+}
+
+// checkIdentified checks that the license identified in the header of the
+// contents b of a file is the license of data. Generated files pass the
+// check.
+func checkIdentified(styles *StyleRegistry, b []byte, data LicenseData) CheckResult {
+	res := checkPresence(styles, b)
+	if res.Status != StatusOK || IsGenerated(b) {
+		return res
+	}
+	id := Identify(b)
+	switch {
+	case !id.Identified():
+		return CheckResult{
+			Status: StatusMismatchedLicense,
+			Reason: fmt.Sprintf("found unrecognized license header, want %s", data.SPDXID),
+		}
+	case id.License != SPDXLicense(data.SPDXID):
+		return CheckResult{
+			Status:  StatusMismatchedLicense,
+			Reason:  fmt.Sprintf("found %s license header, want %s", id.License, data.SPDXID),
+			License: id.License,
+		}
+	}
+	return CheckResult{Status: StatusOK, License: id.License}
+}
+
+// checkHeader compares the license header at the start of the contents b of
+// the file at path with the header rendered from tmpl with data. Differences
+// in whitespace are ignored, and the copyright years of the file only need
+// to cover the latest year of data.Year.
+func checkHeader(styles *StyleRegistry, path string, b []byte, tmpl *template.Template, data LicenseData) (CheckResult, error) {
+	if IsGenerated(b) {
+		return CheckResult{Status: StatusOK}, nil
+	}
+	d := data
+	d.Year, d.Holder = yearSentinel, holderSentinel
+	lic, err := styles.Render(path, tmpl, d)
+	if err != nil || lic == nil {
+		return CheckResult{Status: StatusOK}, err
+	}
+	re, err := cachedHeaderRegexp(lic)
+	if err != nil {
+		return CheckResult{}, err
+	}
+
+	off := len(hashBang(b))
+	m := re.FindSubmatch(b[off:])
+	if m == nil {
+		if !HasLicense(b) {
+			return CheckResult{Status: StatusMissing, Reason: "missing license header"}, nil
+		}
+		if h := findHeader(styles, b); h != nil {
+			return CheckResult{
+				Status:  StatusMismatchedLicense,
+				Reason:  fmt.Sprintf("found %s license header, want %s", h.license, data.SPDXID),
+				License: h.license,
+			}, nil
+		}
+		if id := Identify(b); id.Identified() {
+			reason := fmt.Sprintf("found %s license header, want %s", id.License, data.SPDXID)
+			if id.License == SPDXLicense(data.SPDXID) {
+				reason = fmt.Sprintf("%s license header does not match the template", id.License)
+			}
+			return CheckResult{Status: StatusMismatchedLicense, Reason: reason, License: id.License}, nil
+		}
+		return CheckResult{
+			Status: StatusMismatchedLicense,
+			Reason: fmt.Sprintf("found unrecognized license header, want %s", data.SPDXID),
+		}, nil
+	}
+
+	res := CheckResult{Status: StatusOK, License: data.SPDXID}
+	if i := re.SubexpIndex("holder"); i >= 0 {
+		if got := string(m[i]); normalizeHolder(got) != normalizeHolder(data.Holder) {
+			res.Status = StatusMismatchedHolder
+			res.Reason = fmt.Sprintf("copyright holder is %q, want %q", got, data.Holder)
+			return res, nil
+		}
+	}
+	if i := re.SubexpIndex("year"); i >= 0 {
+		years := string(m[i])
+		if !coversYear(years, data.Year) {
+			res.Status = StatusMismatchedYear
+			res.Reason = fmt.Sprintf("copyright year %q does not cover %q", years, data.Year)
+			return res, nil
+		}
+	}
+	return res, nil
+}
+
+// coversYear reports whether the copyright years in s cover the latest year
+// of want.
+func coversYear(s, want string) bool {
+	year, ok := lastYear(want)
+	if !ok {
+		return true
+	}
+	first, _ := firstYear(s)
+	last, _ := lastYear(s)
+	return first <= year && year <= last
+}
+
+// normalizeHolder returns holder with whitespace collapsed and without a
+// trailing period, which templates commonly add after the holder.
+func normalizeHolder(holder string) string {
+	return strings.TrimSuffix(strings.Join(strings.Fields(holder), " "), ".")
+}
@@ -0,0 +1,105 @@
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package header
+
+import (
+	"testing"
+	"text/template"
+)
+
+func TestCheckHeader(t *testing.T) {
+	apache := template.Must(template.New("").Parse(tmplApache))
+	mit := template.Must(template.New("").Parse(tmplMIT))
+	data := LicenseData{Year: "2022", Holder: "Bhojpur Consulting.", SPDXID: "Apache-2.0"}
+
+	render := func(tmpl *template.Template, year, holder string) string {
+		d := LicenseData{Year: year, Holder: holder}
+		b, err := Render("file.go", tmpl, d)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+
+	tests := []struct {
+		description string
+		content     string
+		want        CheckStatus
+		wantLicense string
+	}{
+		{"matching header", render(apache, "2022", "Bhojpur Consulting") + "content", StatusOK, "Apache-2.0"},
+		{"year range", render(apache, "2018-2023", "Bhojpur Consulting") + "content", StatusOK, "Apache-2.0"},
+		{"whitespace", "\n" + render(apache, "2022", "Bhojpur   Consulting") + "content", StatusOK, "Apache-2.0"},
+		{"generated", "// Code generated by go generate; DO NOT EDIT.\ncontent", StatusOK, ""},
+		{"missing", "content", StatusMissing, ""},
+		{"other license", render(mit, "2022", "Bhojpur Consulting") + "content", StatusMismatchedLicense, "MIT"},
+		{"unknown license", "// Copyright 2022 Bhojpur Consulting\n\ncontent", StatusMismatchedLicense, ""},
+		{"other holder", render(apache, "2022", "Other") + "content", StatusMismatchedHolder, "Apache-2.0"},
+		{"stale year", render(apache, "2018-2021", "Bhojpur Consulting") + "content", StatusMismatchedYear, "Apache-2.0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			got, err := checkHeader(defaultStyles, "file.go", []byte(tt.content), apache, data)
+			if err != nil {
+				t.Fatalf("checkHeader returned error: %v", err)
+			}
+			if got.Status != tt.want || got.License != tt.wantLicense {
+				t.Errorf("checkHeader(%q) returned (%v, %q), want (%v, %q)", tt.content, got.Status, got.License, tt.want, tt.wantLicense)
+			}
+			if (got.Status == StatusOK) != (got.Reason == "") {
+				t.Errorf("checkHeader(%q) returned status %v with reason %q", tt.content, got.Status, got.Reason)
+			}
+		})
+	}
+}
+
+func TestCheckIdentified(t *testing.T) {
+	data := LicenseData{Year: "2022", Holder: "Bhojpur Consulting", SPDXID: "Apache-2.0"}
+	reworded := "/*\n * Copyright (c) 2019 Someone Else\n *\n * Licensed under the Apache License, Version 2.0 (the \"License\");\n" +
+		" * you may not use this file except in compliance with the License.\n * You may obtain a copy of the License at\n *\n" +
+		" *      https://www.apache.org/licenses/LICENSE-2.0\n *\n * Unless required by applicable law or agreed to in writing, software\n" +
+		" * distributed under the License is distributed on an \"AS IS\" BASIS,\n * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.\n" +
+		" * See the License for the specific language governing permissions and\n * limitations under the License.\n */\n\ncontent"
+
+	tests := []struct {
+		description string
+		content     string
+		want        CheckStatus
+		wantLicense string
+	}{
+		{"reworded header", reworded, StatusOK, "Apache-2.0"},
+		{"SPDX tag", "# Copyright 2020 X\n# SPDX-License-Identifier: apache-2.0\n\ncontent", StatusOK, "Apache-2.0"},
+		{"generated", "// Code generated by go generate; DO NOT EDIT.\ncontent", StatusOK, ""},
+		{"missing", "content", StatusMissing, ""},
+		{"foreign license", "// SPDX-License-Identifier: GPL-3.0-or-later\n\ncontent", StatusMismatchedLicense, "GPL-3.0-or-later"},
+		{"unknown license", "// Copyright 2022 Bhojpur Consulting\n// Proprietary and confidential.\n\ncontent", StatusMismatchedLicense, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			got := checkIdentified(defaultStyles, []byte(tt.content), data)
+			if got.Status != tt.want || got.License != tt.wantLicense {
+				t.Errorf("checkIdentified(%q) returned (%v, %q, %q), want (%v, %q)", tt.content, got.Status, got.License, got.Reason, tt.want, tt.wantLicense)
+			}
+		})
+	}
+}
@@ -0,0 +1,518 @@
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package header
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// The SPDX license list, from https://spdx.org/licenses/, is embedded to
+// validate license expressions without network access.
+var (
+	//go:embed data/spdx-licenses.txt
+	spdxLicensesFile string
+	//go:embed data/spdx-exceptions.txt
+	spdxExceptionsFile string
+
+	spdxOnce       sync.Once
+	spdxLicenses   map[string]spdxEntry // keyed by lower case identifier
+	spdxExceptions map[string]spdxEntry // keyed by lower case identifier
+)
+
+// spdxEntry is an identifier of the SPDX license list.
+type spdxEntry struct {
+	id         string
+	deprecated bool
+}
+
+// parseSPDXList parses an embedded list of SPDX identifiers.
+func parseSPDXList(list string) map[string]spdxEntry {
+	m := make(map[string]spdxEntry)
+	s := bufio.NewScanner(strings.NewReader(list))
+	for s.Scan() {
+		f := strings.Fields(s.Text())
+		if len(f) == 0 || strings.HasPrefix(f[0], "#") {
+			continue
+		}
+		m[strings.ToLower(f[0])] = spdxEntry{id: f[0], deprecated: len(f) > 1 && f[1] == "deprecated"}
+	}
+	return m
+}
+
+func loadSPDXLists() {
+	spdxOnce.Do(func() {
+		spdxLicenses = parseSPDXList(spdxLicensesFile)
+		spdxExceptions = parseSPDXList(spdxExceptionsFile)
+	})
+}
+
+// Expression is a parsed SPDX license expression, such as
+// "Apache-2.0 OR MIT" or "GPL-2.0-only WITH Classpath-exception-2.0".
+// A simple expression names a single license, optionally followed by "+"
+// and an exception, while a compound expression combines its operands with
+// the AND or OR operator.
+type Expression struct {
+	License   string        // license identifier of a simple expression
+	OrLater   bool          // "+" operator of a simple expression
+	Exception string        // exception identifier of a simple expression, if any
+	Op        string        // "AND" or "OR" for compound expressions, "" otherwise
+	Operands  []*Expression // operands of a compound expression
+}
+
+// String returns the expression with canonical identifiers and operators,
+// adding parentheses only around operands using another operator.
+func (e *Expression) String() string {
+	if e.Op == "" {
+		s := e.License
+		if e.OrLater {
+			s += "+"
+		}
+		if e.Exception != "" {
+			s += " WITH " + e.Exception
+		}
+		return s
+	}
+	parts := make([]string, len(e.Operands))
+	for i, o := range e.Operands {
+		parts[i] = o.String()
+		if o.Op != "" {
+			parts[i] = "(" + parts[i] + ")"
+		}
+	}
+	return strings.Join(parts, " "+e.Op+" ")
+}
+
+// Licenses returns the license identifiers of the expression, in order of
+// appearance and without duplicates.
+func (e *Expression) Licenses() []string {
+	var ids []string
+	seen := make(map[string]bool)
+	var walk func(e *Expression)
+	walk = func(e *Expression) {
+		if e.Op == "" {
+			if !seen[e.License] {
+				seen[e.License] = true
+				ids = append(ids, e.License)
+			}
+			return
+		}
+		for _, o := range e.Operands {
+			walk(o)
+		}
+	}
+	walk(e)
+	return ids
+}
+
+// Exceptions returns the exception identifiers of the expression, in order
+// of appearance and without duplicates.
+func (e *Expression) Exceptions() []string {
+	var ids []string
+	seen := make(map[string]bool)
+	var walk func(e *Expression)
+	walk = func(e *Expression) {
+		if e.Exception != "" && !seen[e.Exception] {
+			seen[e.Exception] = true
+			ids = append(ids, e.Exception)
+		}
+		for _, o := range e.Operands {
+			walk(o)
+		}
+	}
+	walk(e)
+	return ids
+}
+
+// LookupSPDXID returns the canonical form of the license or exception
+// identifier id, matched case insensitively against the SPDX license list,
+// and whether it is deprecated. User defined LicenseRef- and AdditionRef-
+// identifiers are returned as is.
+func LookupSPDXID(id string) (canonical string, deprecated bool, ok bool) {
+	loadSPDXLists()
+	if isLicenseRef(id) || strings.HasPrefix(id, "AdditionRef-") && len(id) > len("AdditionRef-") {
+		return id, false, true
+	}
+	if e, ok := spdxLicenses[strings.ToLower(id)]; ok {
+		return e.id, e.deprecated, true
+	}
+	if e, ok := spdxExceptions[strings.ToLower(id)]; ok {
+		return e.id, e.deprecated, true
+	}
+	return "", false, false
+}
+
+// simple reports whether the expression is a single license, without the
+// "+" operator or an exception.
+func (e *Expression) simple() bool {
+	return e.Op == "" && !e.OrLater && e.Exception == ""
+}
+
+// replaceDeprecated replaces the deprecated license identifiers of e that
+// have an -only or -or-later form, such as GPL-3.0 by GPL-3.0-only and
+// GPL-3.0+ by GPL-3.0-or-later.
+func (e *Expression) replaceDeprecated() {
+	for _, o := range e.Operands {
+		o.replaceDeprecated()
+	}
+	if _, dep, _ := LookupSPDXID(e.License); e.Op != "" || !dep {
+		return
+	}
+	suffix := "-only"
+	if e.OrLater {
+		suffix = "-or-later"
+	}
+	if id, dep, ok := LookupSPDXID(e.License + suffix); ok && !dep {
+		e.License, e.OrLater = id, false
+	}
+}
+
+// ParseExpression parses and validates the SPDX license expression s.
+// License and exception identifiers are matched case insensitively against
+// the SPDX license list, and replaced by their canonical form. User defined
+// LicenseRef- and DocumentRef- identifiers are accepted as is. Unknown
+// identifiers are reported with the closest known identifier, if any.
+func ParseExpression(s string) (*Expression, error) {
+	loadSPDXLists()
+	toks, err := tokenize(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid license expression %q: %w", s, err)
+	}
+	if len(toks) == 0 {
+		return nil, fmt.Errorf("empty license expression")
+	}
+	p := &exprParser{toks: toks}
+	e, err := p.or()
+	if err == nil && p.pos < len(p.toks) {
+		err = fmt.Errorf("unexpected %q", p.toks[p.pos])
+	}
+	if err != nil {
+		if len(toks) == 1 {
+			return nil, err
+		}
+		return nil, fmt.Errorf("invalid license expression %q: %w", s, err)
+	}
+	return e, nil
+}
+
+// tokenize splits an expression into parentheses, operators and
+// identifiers, keeping the "+" operator attached to its identifier.
+func tokenize(s string) ([]string, error) {
+	var toks []string
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(' || c == ')':
+			toks = append(toks, string(c))
+			i++
+		case isIDChar(c):
+			j := i
+			for j < len(s) && (isIDChar(s[j]) || s[j] == ':') {
+				j++
+			}
+			if j < len(s) && s[j] == '+' {
+				j++
+			}
+			toks = append(toks, s[i:j])
+			i = j
+		default:
+			return nil, fmt.Errorf("unexpected character %q", rune(c))
+		}
+	}
+	return toks, nil
+}
+
+func isIDChar(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '-' || c == '.'
+}
+
+// exprParser is a recursive descent parser of SPDX license expressions,
+// where WITH binds tighter than AND, which binds tighter than OR.
+type exprParser struct {
+	toks []string
+	pos  int
+}
+
+// peek returns the next token, or "" at the end of the expression.
+func (p *exprParser) peek() string {
+	if p.pos < len(p.toks) {
+		return p.toks[p.pos]
+	}
+	return ""
+}
+
+// operator reports whether the next token is the operator op. Operators
+// must be upper case, and are reported as such when they are not.
+func (p *exprParser) operator(op string) (bool, error) {
+	t := p.peek()
+	if t == op {
+		p.pos++
+		return true, nil
+	}
+	if strings.EqualFold(t, op) {
+		return false, fmt.Errorf("operator %q must be upper case", t)
+	}
+	return false, nil
+}
+
+func (p *exprParser) or() (*Expression, error) {
+	return p.compound("OR", p.and)
+}
+
+func (p *exprParser) and() (*Expression, error) {
+	return p.compound("AND", p.with)
+}
+
+// compound parses operands separated by op, flattening nested expressions
+// using the same operator.
+func (p *exprParser) compound(op string, operand func() (*Expression, error)) (*Expression, error) {
+	e, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		ok, err := p.operator(op)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return e, nil
+		}
+		o, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		if e.Op != op {
+			e = &Expression{Op: op, Operands: []*Expression{e}}
+		}
+		if o.Op == op {
+			e.Operands = append(e.Operands, o.Operands...)
+		} else {
+			e.Operands = append(e.Operands, o)
+		}
+	}
+}
+
+func (p *exprParser) with() (*Expression, error) {
+	t := p.peek()
+	if t == "(" {
+		p.pos++
+		e, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ")" {
+			return nil, fmt.Errorf("missing closing parenthesis")
+		}
+		p.pos++
+		return e, nil
+	}
+	e, err := p.license()
+	if err != nil {
+		return nil, err
+	}
+	ok, err := p.operator("WITH")
+	if err != nil || !ok {
+		return e, err
+	}
+	exc := p.peek()
+	if exc == "" || exc == "(" || exc == ")" {
+		return nil, fmt.Errorf("missing exception after WITH")
+	}
+	p.pos++
+	if e.Exception, err = lookupException(exc); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// license parses a license identifier, optionally followed by "+".
+func (p *exprParser) license() (*Expression, error) {
+	t := p.peek()
+	switch {
+	case t == "":
+		return nil, fmt.Errorf("missing license")
+	case t == ")":
+		return nil, fmt.Errorf("unexpected %q", t)
+	}
+	for _, op := range []string{"AND", "OR", "WITH"} {
+		if strings.EqualFold(t, op) {
+			return nil, fmt.Errorf("missing license before %s", op)
+		}
+	}
+	p.pos++
+	e := &Expression{}
+	if strings.HasSuffix(t, "+") {
+		t, e.OrLater = t[:len(t)-1], true
+	}
+	if isLicenseRef(t) {
+		e.License = t
+		return e, nil
+	}
+	if strings.Contains(t, ":") {
+		return nil, fmt.Errorf("invalid license reference %q", t)
+	}
+	l, ok := spdxLicenses[strings.ToLower(t)]
+	if !ok && e.OrLater {
+		// deprecated identifiers such as GPL-2.0+ include the operator
+		if l, ok = spdxLicenses[strings.ToLower(t)+"+"]; ok {
+			t, e.OrLater = l.id, false
+		}
+	}
+	if !ok {
+		if l := legacyLicenseTypes[strings.ToLower(t)]; l != "" {
+			return nil, fmt.Errorf("unknown license %q, did you mean %q?", t, l)
+		}
+		return nil, unknownIdentifier("license", t, spdxLicenses)
+	}
+	e.License = l.id
+	return e, nil
+}
+
+// isLicenseRef reports whether id is a user defined license reference, of
+// the form LicenseRef-id or DocumentRef-id:LicenseRef-id.
+func isLicenseRef(id string) bool {
+	if strings.HasPrefix(id, "DocumentRef-") {
+		i := strings.Index(id, ":")
+		if i < len("DocumentRef-x") {
+			return false
+		}
+		id = id[i+1:]
+	}
+	return strings.HasPrefix(id, "LicenseRef-") && len(id) > len("LicenseRef-") && !strings.Contains(id, ":")
+}
+
+// lookupException returns the canonical identifier of the exception id.
+func lookupException(id string) (string, error) {
+	if strings.HasPrefix(id, "AdditionRef-") && len(id) > len("AdditionRef-") {
+		return id, nil
+	}
+	if e, ok := spdxExceptions[strings.ToLower(id)]; ok {
+		return e.id, nil
+	}
+	return "", unknownIdentifier("exception", id, spdxExceptions)
+}
+
+// unknownIdentifier returns the error reporting the unknown license or
+// exception id, suggesting the closest identifier of list.
+func unknownIdentifier(kind, id string, list map[string]spdxEntry) error {
+	if s := suggest(id, list); s != "" {
+		return fmt.Errorf("unknown %s %q, did you mean %q?", kind, id, s)
+	}
+	return fmt.Errorf("unknown %s %q", kind, id)
+}
+
+// suggest returns the identifier of list closest to id, ignoring case and
+// punctuation, or "" if none is close enough. Deprecated identifiers are
+// only suggested when no current identifier is as close, and are replaced
+// by their -only and -or-later successors when those exist.
+func suggest(id string, list map[string]spdxEntry) string {
+	norm := normalizeID(id)
+	maxDist := len(norm) / 3
+	if maxDist < 1 {
+		maxDist = 1
+	} else if maxDist > 3 {
+		maxDist = 3
+	}
+	var best *spdxEntry
+	bestDist := maxDist + 1
+	for _, e := range list {
+		e := e
+		d := editDistance(norm, normalizeID(e.id))
+		if d < bestDist || d == bestDist && best != nil && better(e, *best) {
+			best, bestDist = &e, d
+		}
+	}
+	if best == nil {
+		return ""
+	}
+	if best.deprecated {
+		successor := best.id + "-only"
+		if strings.HasSuffix(best.id, "+") {
+			successor = strings.TrimSuffix(best.id, "+") + "-or-later"
+		}
+		if e, ok := list[strings.ToLower(successor)]; ok {
+			return e.id
+		}
+	}
+	return best.id
+}
+
+// better reports whether a should be suggested rather than b, at the same
+// distance: current identifiers first, then in alphabetical order.
+func better(a, b spdxEntry) bool {
+	if a.deprecated != b.deprecated {
+		return !a.deprecated
+	}
+	return a.id < b.id
+}
+
+// normalizeID returns id in lower case, without punctuation.
+func normalizeID(id string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '+' {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, id)
+}
+
+// editDistance returns the number of insertions, deletions, substitutions
+// and transpositions of adjacent characters turning a into b.
+func editDistance(a, b string) int {
+	d := make([][]int, len(a)+1)
+	for i := range d {
+		d[i] = make([]int, len(b)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			d[i][j] = minInt(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				d[i][j] = minInt(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(a)][len(b)]
+}
+
+func minInt(v ...int) int {
+	m := v[0]
+	for _, x := range v[1:] {
+		if x < m {
+			m = x
+		}
+	}
+	return m
+}
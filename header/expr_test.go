@@ -0,0 +1,104 @@
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package header
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseExpression(t *testing.T) {
+	tests := []struct {
+		expr         string
+		want         string   // canonical expression
+		wantLicenses []string // licenses of the expression
+		wantErr      string
+	}{
+		{"MIT", "MIT", []string{"MIT"}, ""},
+		{"apache-2.0", "Apache-2.0", []string{"Apache-2.0"}, ""},
+		{"Apache-2.0 OR MIT", "Apache-2.0 OR MIT", []string{"Apache-2.0", "MIT"}, ""},
+		{"(mit OR apache-2.0) AND bsd-3-clause", "(MIT OR Apache-2.0) AND BSD-3-Clause", []string{"MIT", "Apache-2.0", "BSD-3-Clause"}, ""},
+		{"MIT OR Apache-2.0 AND Zlib", "MIT OR (Apache-2.0 AND Zlib)", []string{"MIT", "Apache-2.0", "Zlib"}, ""},
+		{"MIT OR (ISC OR 0BSD) OR MIT", "MIT OR ISC OR 0BSD OR MIT", []string{"MIT", "ISC", "0BSD"}, ""},
+		{"gpl-2.0-only WITH classpath-exception-2.0", "GPL-2.0-only WITH Classpath-exception-2.0", []string{"GPL-2.0-only"}, ""},
+		{"GPL-2.0-only WITH Classpath-exception-2.0 OR MIT", "GPL-2.0-only WITH Classpath-exception-2.0 OR MIT", []string{"GPL-2.0-only", "MIT"}, ""},
+		{"Apache-1.0+", "Apache-1.0+", []string{"Apache-1.0"}, ""},
+		{"GPL-2.0+", "GPL-2.0+", []string{"GPL-2.0"}, ""},
+		{"LicenseRef-Proprietary OR MIT", "LicenseRef-Proprietary OR MIT", []string{"LicenseRef-Proprietary", "MIT"}, ""},
+		{"DocumentRef-spdx-tool-1.2:LicenseRef-MIT-Style-2", "DocumentRef-spdx-tool-1.2:LicenseRef-MIT-Style-2", []string{"DocumentRef-spdx-tool-1.2:LicenseRef-MIT-Style-2"}, ""},
+		{"MIT WITH AdditionRef-Extra", "MIT WITH AdditionRef-Extra", []string{"MIT"}, ""},
+
+		{"", "", nil, "empty license expression"},
+		{"Apache2", "", nil, `unknown license "Apache2", did you mean "Apache-2.0"?`},
+		{"Apache2 OR MIT", "", nil, `invalid license expression "Apache2 OR MIT": unknown license "Apache2", did you mean "Apache-2.0"?`},
+		{"GPL3", "", nil, `unknown license "GPL3", did you mean "GPL-3.0-only"?`},
+		{"MTI", "", nil, `unknown license "MTI", did you mean "MIT"?`},
+		{"mit OR apache", "", nil, `invalid license expression "mit OR apache": unknown license "apache", did you mean "Apache-2.0"?`},
+		{"Nothing-Like-It", "", nil, `unknown license "Nothing-Like-It"`},
+		{"MIT or Apache-2.0", "", nil, `invalid license expression "MIT or Apache-2.0": operator "or" must be upper case`},
+		{"MIT OR", "", nil, `invalid license expression "MIT OR": missing license`},
+		{"OR MIT", "", nil, `invalid license expression "OR MIT": missing license before OR`},
+		{"(MIT OR ISC", "", nil, `invalid license expression "(MIT OR ISC": missing closing parenthesis`},
+		{"MIT ISC", "", nil, `invalid license expression "MIT ISC": unexpected "ISC"`},
+		{"MIT WITH", "", nil, `invalid license expression "MIT WITH": missing exception after WITH`},
+		{"GPL-2.0-only WITH Classpath-exeption-2.0", "", nil, `invalid license expression "GPL-2.0-only WITH Classpath-exeption-2.0": unknown exception "Classpath-exeption-2.0", did you mean "Classpath-exception-2.0"?`},
+		{"MIT/Apache-2.0", "", nil, `invalid license expression "MIT/Apache-2.0": unexpected character '/'`},
+	}
+
+	for _, tt := range tests {
+		e, err := ParseExpression(tt.expr)
+		if tt.wantErr != "" {
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("ParseExpression(%q) returned error %v, want %q", tt.expr, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseExpression(%q) returned error: %v", tt.expr, err)
+			continue
+		}
+		if got := e.String(); got != tt.want {
+			t.Errorf("ParseExpression(%q) = %q, want %q", tt.expr, got, tt.want)
+		}
+		if got := e.Licenses(); !reflect.DeepEqual(got, tt.wantLicenses) {
+			t.Errorf("ParseExpression(%q).Licenses() = %q, want %q", tt.expr, got, tt.wantLicenses)
+		}
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"mit", "mit", 0},
+		{"mit", "mti", 1},
+		{"apache2", "apache20", 1},
+		{"kitten", "sitting", 3},
+		{"", "isc", 3},
+	}
+	for _, tt := range tests {
+		if got := editDistance(tt.a, tt.b); got != tt.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,325 @@
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package header adds, updates, replaces, removes and checks the copyright
+// license headers of source code files.
+//
+// A Processor renders the header of each file from the template of its
+// license, in the comment style of its file type. It works on file contents
+// held in byte slices, or on the files of an fs.FS, and never writes files
+// itself.
+package header
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"strings"
+)
+
+// Processor fixes and checks the license headers of files. Files are
+// identified by slash separated names, which select their license through
+// Rules and their comment style through Styles. A Processor is safe for
+// concurrent use as long as its fields are not modified.
+type Processor struct {
+	License *License       // license of the files matching no rule
+	Rules   []Rule         // the first rule matching the name of a file selects its license
+	Styles  *StyleRegistry // comment styles of file types, the built-in ones if nil
+	Ignore  []string       // doublestar patterns of the files skipped by FixFS and CheckFS
+
+	Update  bool // extend the copyright year of existing headers
+	Replace bool // replace headers of other built-in licenses
+	Remove  bool // remove headers instead of adding them
+	Strict  bool // check that headers match the license, holder and year instead of only looking for a copyright notice
+	Foreign bool // check that headers are identified as the license of the file, see Identify
+
+	// FileData, if set, returns the license data of the named file, such as
+	// copyright years taken from its history, given the data of its license.
+	FileData func(name string, data LicenseData) (LicenseData, error)
+}
+
+// Action is the kind of change made to the license header of a file.
+type Action int
+
+const (
+	NoChange Action = iota
+	Added           // header added to a file without one
+	Updated         // copyright year of the header extended
+	Replaced        // header of another license replaced
+	Removed         // header removed
+)
+
+// Change describes the change of the license header of a file.
+type Change struct {
+	Action  Action
+	From    string // license of the replaced header
+	To      string // license of the new header
+	Content []byte // new contents of the file
+}
+
+func (c *Change) String() string {
+	switch c.Action {
+	case Added:
+		return "add license header"
+	case Updated:
+		return "update copyright year"
+	case Replaced:
+		return fmt.Sprintf("replace %s header with %s", c.From, c.To)
+	case Removed:
+		return "remove license header"
+	}
+	return "no change"
+}
+
+func (p *Processor) styles() *StyleRegistry {
+	if p.Styles == nil {
+		return defaultStyles
+	}
+	return p.Styles
+}
+
+// LicenseFor returns the license of the named file, or nil if the file
+// matches a rule skipping it.
+func (p *Processor) LicenseFor(name string) *License {
+	for i := range p.Rules {
+		r := &p.Rules[i]
+		if fileMatches(name, r.Patterns) {
+			if r.Skip {
+				return nil
+			}
+			return r.License
+		}
+	}
+	return p.License
+}
+
+// Handles reports whether the named file is subject to a license header:
+// its file type has a known comment style and no rule skips it.
+func (p *Processor) Handles(name string) bool {
+	if _, ok := p.styles().Lookup(name); !ok {
+		return false
+	}
+	return p.LicenseFor(name) != nil
+}
+
+// Ignored reports whether the named file matches one of the Ignore patterns.
+func (p *Processor) Ignored(name string) bool {
+	return fileMatches(name, p.Ignore)
+}
+
+// fileLicense returns the template and data of the named file.
+func (p *Processor) fileLicense(name string) (*License, LicenseData, error) {
+	l := p.LicenseFor(name)
+	if l == nil {
+		return nil, LicenseData{}, nil
+	}
+	data := l.Data
+	if p.FileData != nil {
+		var err error
+		if data, err = p.FileData(name, data); err != nil {
+			return nil, LicenseData{}, err
+		}
+	}
+	return l, data, nil
+}
+
+// Fix returns the change to make to the contents b of the named file to
+// fix its license header: remove it in Remove mode, replace it in Replace
+// mode, add it if missing, or extend its year in Update mode. It returns
+// nil if no change is needed, or if the file is generated or not handled.
+func (p *Processor) Fix(name string, b []byte) (*Change, error) {
+	if !p.Handles(name) || IsGenerated(b) {
+		return nil, nil
+	}
+	l, data, err := p.fileLicense(name)
+	if err != nil {
+		return nil, err
+	}
+	styles, tmpl := p.styles(), l.Template
+
+	if p.Remove {
+		nb, removed, err := removeLicense(styles, name, b, tmpl, data)
+		if err != nil || !removed {
+			return nil, err
+		}
+		return &Change{Action: Removed, Content: nb}, nil
+	}
+	if p.Replace {
+		nb, old, err := replaceLicense(styles, name, b, tmpl, data)
+		if err != nil {
+			return nil, err
+		}
+		if old != "" {
+			return &Change{Action: Replaced, From: old, To: data.SPDXID, Content: nb}, nil
+		}
+	}
+	if !HasLicense(b) {
+		lic, err := styles.Render(name, tmpl, data)
+		if err != nil || lic == nil {
+			return nil, err
+		}
+		return &Change{Action: Added, To: data.SPDXID, Content: insertHeader(b, lic)}, nil
+	}
+	if p.Update {
+		nb, updated, err := updateYear(styles, name, b, tmpl, data)
+		if err != nil || !updated {
+			return nil, err
+		}
+		return &Change{Action: Updated, Content: nb}, nil
+	}
+	return nil, nil
+}
+
+// Check checks the license header of the contents b of the named file. In
+// Strict mode, the header must match the one rendered for the file, with
+// differences in whitespace ignored and copyright years only needing to
+// cover the latest year of the license data. In Foreign mode, the license
+// identified in the header must be the license of the file, whatever the
+// wording of the header. Otherwise, any license header passes. Generated
+// files and files that are not handled pass the check.
+func (p *Processor) Check(name string, b []byte) (CheckResult, error) {
+	if !p.Handles(name) {
+		return CheckResult{Status: StatusOK}, nil
+	}
+	if !p.Strict && !p.Foreign {
+		return checkPresence(p.styles(), b), nil
+	}
+	l, data, err := p.fileLicense(name)
+	if err != nil {
+		return CheckResult{}, err
+	}
+	if p.Strict {
+		return checkHeader(p.styles(), name, b, l.Template, data)
+	}
+	return checkIdentified(p.styles(), b, data), nil
+}
+
+// FixFS calls Fix on the files under root in fsys, skipping the ignored
+// ones and the ones that are not handled, and calls fn with the name and
+// change of each file that needs to be changed. Errors returned by fn stop
+// the walk.
+func (p *Processor) FixFS(fsys fs.FS, root string, fn func(name string, c *Change) error) error {
+	return p.walkFS(fsys, root, func(name string, b []byte) error {
+		c, err := p.Fix(name, b)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		if c == nil {
+			return nil
+		}
+		return fn(name, c)
+	})
+}
+
+// CheckFS calls Check on the files under root in fsys, skipping the ignored
+// ones and the ones that are not handled, and calls fn with the name and
+// result of each file. Errors returned by fn stop the walk.
+func (p *Processor) CheckFS(fsys fs.FS, root string, fn func(name string, res CheckResult) error) error {
+	return p.walkFS(fsys, root, func(name string, b []byte) error {
+		res, err := p.Check(name, b)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		return fn(name, res)
+	})
+}
+
+func (p *Processor) walkFS(fsys fs.FS, root string, fn func(name string, b []byte) error) error {
+	return fs.WalkDir(fsys, root, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() || p.Ignored(name) || !p.Handles(name) {
+			return nil
+		}
+		b, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		return fn(name, b)
+	})
+}
+
+// insertHeader returns b with the license header lic inserted at the top,
+// after the first line if it is one of the head lines that must stay first.
+func insertHeader(b, lic []byte) []byte {
+	line := hashBang(b)
+	if len(line) > 0 {
+		b = b[len(line):]
+		if line[len(line)-1] != '\n' {
+			line = append(line, '\n')
+		}
+		lic = append(line, lic...)
+	}
+	return append(lic, b...)
+}
+
+var head = []string{
+	"#!",                       // shell script
+	"<?xml",                    // XML declaratioon
+	"<!doctype",                // HTML doctype
+	"# encoding:",              // Ruby encoding
+	"# frozen_string_literal:", // Ruby interpreter instruction
+	"<?php",                    // PHP opening tag
+	"# escape",                 // Dockerfile directive
+	"# syntax",                 // Dockerfile directive
+}
+
+func hashBang(b []byte) []byte {
+	var line []byte
+	for _, c := range b {
+		line = append(line, c)
+		if c == '\n' {
+			break
+		}
+	}
+	first := strings.ToLower(string(line))
+	for _, h := range head {
+		if strings.HasPrefix(first, h) {
+			return line
+		}
+	}
+	return nil
+}
+
+// go generate: ^// Code generated by Bhojpur License engine .* DO NOT EDIT\.$
+var goGenerated = regexp.MustCompile(`(?m)^.{1,2} Code generated .* DO NOT EDIT\.$`)
+
+// cargo raze: ^DO NOT EDIT! Replaced on runs of cargo-raze$
+var cargoRazeGenerated = regexp.MustCompile(`(?m)^DO NOT EDIT! Replaced on runs of cargo-raze$`)
+
+// IsGenerated returns true if it contains a string that implies the file was
+// generated.
+func IsGenerated(b []byte) bool {
+	return goGenerated.Match(b) || cargoRazeGenerated.Match(b)
+}
+
+// HasLicense reports whether the first 1000 bytes of b mention a copyright,
+// the Mozilla Public License or an SPDX license identifier.
+func HasLicense(b []byte) bool {
+	n := 1000
+	if len(b) < 1000 {
+		n = len(b)
+	}
+	return bytes.Contains(bytes.ToLower(b[:n]), []byte("copyright")) ||
+		bytes.Contains(bytes.ToLower(b[:n]), []byte("mozilla public")) ||
+		bytes.Contains(bytes.ToLower(b[:n]), []byte("spdx-license-identifier"))
+}
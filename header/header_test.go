@@ -0,0 +1,336 @@
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package header
+
+import (
+	"testing"
+	"testing/fstest"
+	"text/template"
+)
+
+func TestFix(t *testing.T) {
+	tmpl := template.Must(template.New("").Parse("{{.Holder}}{{.Year}}{{.SPDXID}}"))
+	p := &Processor{License: &License{tmpl, LicenseData{Holder: "H", Year: "Y", SPDXID: "S"}}}
+
+	tests := []struct {
+		contents     string
+		wantContents string
+		wantUpdated  bool
+	}{
+		{"", "// HYS\n\n", true},
+		{"content", "// HYS\n\ncontent", true},
+
+		// various headers that should be left intact. Many don't make
+		// sense for our temp file extension, but that doesn't matter.
+		{"#!/bin/bash\ncontent", "#!/bin/bash\n// HYS\n\ncontent", true},
+		{"<?xml version='1.0'?>\ncontent", "<?xml version='1.0'?>\n// HYS\n\ncontent", true},
+		{"<!doctype html>\ncontent", "<!doctype html>\n// HYS\n\ncontent", true},
+		{"<!DOCTYPE HTML>\ncontent", "<!DOCTYPE HTML>\n// HYS\n\ncontent", true},
+		{"# encoding: UTF-8\ncontent", "# encoding: UTF-8\n// HYS\n\ncontent", true},
+		{"# frozen_string_literal: true\ncontent", "# frozen_string_literal: true\n// HYS\n\ncontent", true},
+		{"<?php\ncontent", "<?php\n// HYS\n\ncontent", true},
+		{"# escape: `\ncontent", "# escape: `\n// HYS\n\ncontent", true},
+		{"# syntax: docker/dockerfile:1.3\ncontent", "# syntax: docker/dockerfile:1.3\n// HYS\n\ncontent", true},
+
+		// ensure files with existing license or generated files are
+		// skipped. No need to test all permutations of these, since
+		// there are specific tests below.
+		{"// Copyright 2018 Bhojpur Consulting\ncontent", "// Copyright 2018 Bhojpur Consulting\ncontent", false},
+		{"// Code generated by Bhojpur License; DO NOT EDIT.\ncontent", "// Code generated by Bhojpur License; DO NOT EDIT.\ncontent", false},
+	}
+
+	for _, tt := range tests {
+		c, err := p.Fix("file.go", []byte(tt.contents))
+		if err != nil {
+			t.Error(err)
+		}
+		if updated := c != nil; updated != tt.wantUpdated {
+			t.Errorf("Fix with contents %q returned change %v, want updated %t", tt.contents, c, tt.wantUpdated)
+		}
+		if c != nil && string(c.Content) != tt.wantContents {
+			t.Errorf("Fix with contents %q returned contents: %q, want %q", tt.contents, c.Content, tt.wantContents)
+		}
+	}
+}
+
+func TestFixModes(t *testing.T) {
+	tmpl := template.Must(template.New("").Parse("Copyright {{.Year}} {{.Holder}}\n\nText"))
+	apache := template.Must(template.New("").Parse(tmplApache))
+	lic := &License{tmpl, LicenseData{Holder: "H", Year: "2022", SPDXID: "X"}}
+	old, err := Render("file.go", apache, LicenseData{Holder: "H", Year: "2018"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		description string
+		p           Processor
+		contents    string
+		want        string // description of the change
+		wantContent string
+	}{
+		{"add", Processor{}, "content", "add license header", "// Copyright 2022 H\n//\n// Text\n\ncontent"},
+		{"existing header", Processor{}, "// Copyright 2019 H\n//\n// Text\n\ncontent", "", ""},
+		{"update", Processor{Update: true}, "// Copyright 2019 H\n//\n// Text\n\ncontent", "update copyright year", "// Copyright 2019-2022 H\n//\n// Text\n\ncontent"},
+		{"replace", Processor{Replace: true}, string(old) + "content", "replace Apache-2.0 header with X", "// Copyright 2022 H\n//\n// Text\n\ncontent"},
+		{"remove", Processor{Remove: true}, "// Copyright 2019 H\n//\n// Text\n\ncontent", "remove license header", "content"},
+		{"remove missing", Processor{Remove: true}, "content", "", ""},
+		{"generated", Processor{}, "// Code generated by go generate; DO NOT EDIT.\ncontent", "", ""},
+		{
+			"file data",
+			Processor{FileData: func(name string, d LicenseData) (LicenseData, error) {
+				d.Year = "2018-2022"
+				return d, nil
+			}},
+			"content",
+			"add license header",
+			"// Copyright 2018-2022 H\n//\n// Text\n\ncontent",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			p := tt.p
+			p.License = lic
+			c, err := p.Fix("file.go", []byte(tt.contents))
+			if err != nil {
+				t.Fatalf("Fix returned error: %v", err)
+			}
+			var got, content string
+			if c != nil {
+				got, content = c.String(), string(c.Content)
+			}
+			if got != tt.want || content != tt.wantContent {
+				t.Errorf("Fix(%q) returned (%q, %q), want (%q, %q)", tt.contents, got, content, tt.want, tt.wantContent)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	lic, err := NewLicense("mit", "", SPDXOff, LicenseData{Year: "2022", Holder: "H"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mit, err := Render("file.go", lic.Template, lic.Data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		description string
+		name        string
+		contents    string
+		strict      bool
+		want        CheckStatus
+	}{
+		{"any header", "file.go", "// Copyright 2018 Other\n\ncontent", false, StatusOK},
+		{"missing", "file.go", "content", false, StatusMissing},
+		{"not handled", "file.unknown", "content", false, StatusOK},
+		{"skipped", "third_party/file.go", "content", false, StatusOK},
+		{"strict", "file.go", string(mit) + "content", true, StatusOK},
+		{"strict other header", "file.go", "// Copyright 2018 Other\n\ncontent", true, StatusMismatchedLicense},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			p := &Processor{
+				License: lic,
+				Rules:   []Rule{{Patterns: []string{"third_party/**"}, Skip: true}},
+				Strict:  tt.strict,
+			}
+			got, err := p.Check(tt.name, []byte(tt.contents))
+			if err != nil {
+				t.Fatalf("Check returned error: %v", err)
+			}
+			if got.Status != tt.want {
+				t.Errorf("Check(%q, %q) returned %v, want %v", tt.name, tt.contents, got.Status, tt.want)
+			}
+		})
+	}
+}
+
+func TestFixFS(t *testing.T) {
+	tmpl := template.Must(template.New("").Parse("Copyright {{.Year}} {{.Holder}}"))
+	p := &Processor{
+		License: &License{tmpl, LicenseData{Year: "2022", Holder: "H"}},
+		Ignore:  []string{"vendor/**"},
+	}
+	fsys := fstest.MapFS{
+		"main.go":          {Data: []byte("package main\n")},
+		"licensed.go":      {Data: []byte("// Copyright 2018 H\n\npackage main\n")},
+		"data.bin":         {Data: []byte("binary")},
+		"vendor/lib.go":    {Data: []byte("package lib\n")},
+		"scripts/build.sh": {Data: []byte("#!/bin/sh\necho\n")},
+	}
+
+	got := make(map[string]string)
+	err := p.FixFS(fsys, ".", func(name string, c *Change) error {
+		got[name] = string(c.Content)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("FixFS returned error: %v", err)
+	}
+	want := map[string]string{
+		"main.go":          "// Copyright 2022 H\n\npackage main\n",
+		"scripts/build.sh": "#!/bin/sh\n# Copyright 2022 H\n\necho\n",
+	}
+	if len(got) != len(want) {
+		t.Errorf("FixFS changed %d files, want %d: %q", len(got), len(want), got)
+	}
+	for name, w := range want {
+		if got[name] != w {
+			t.Errorf("FixFS changed %s to %q, want %q", name, got[name], w)
+		}
+	}
+
+	missing := 0
+	err = p.CheckFS(fsys, ".", func(name string, res CheckResult) error {
+		if res.Status != StatusOK {
+			missing++
+		}
+		return nil
+	})
+	if err != nil || missing != 2 {
+		t.Errorf("CheckFS returned error %v and %d failures, want 2", err, missing)
+	}
+}
+
+// Test that Bhojpur License headers are added using the appropriate prefix for
+// different filenames and extensions.
+func TestRender(t *testing.T) {
+	tpl := template.Must(template.New("").Parse("{{.Holder}}{{.Year}}{{.SPDXID}}"))
+	data := LicenseData{Holder: "H", Year: "Y", SPDXID: "S"}
+
+	tests := []struct {
+		paths []string // paths passed to Render
+		want  string   // expected result of executing template
+	}{
+		{
+			[]string{"f.unknown"},
+			"",
+		},
+		{
+			[]string{"f.c", "f.h", "f.gv", "f.java", "f.scala", "f.kt", "f.kts"},
+			"/*\n * HYS\n */\n\n",
+		},
+		{
+			[]string{"f.js", "f.mjs", "f.cjs", "f.jsx", "f.tsx", "f.css", "f.scss", "f.sass", "f.tf", "f.ts"},
+			"/**\n * HYS\n */\n\n",
+		},
+		{
+			[]string{"f.cc", "f.cpp", "f.cs", "f.go", "f.hcl", "f.hh", "f.hpp", "f.m", "f.mm", "f.proto",
+				"f.rs", "f.swift", "f.dart", "f.groovy", "f.v", "f.sv", "f.php"},
+			"// HYS\n\n",
+		},
+		{
+			[]string{"f.py", "f.sh", "f.yaml", "f.yml", "f.dockerfile", "dockerfile", "f.rb", "gemfile", "f.tcl", "f.bzl", "f.pl"},
+			"# HYS\n\n",
+		},
+		{
+			[]string{"f.el", "f.lisp"},
+			";; HYS\n\n",
+		},
+		{
+			[]string{"f.erl"},
+			"% HYS\n\n",
+		},
+		{
+			[]string{"f.hs", "f.sql", "f.sdl"},
+			"-- HYS\n\n",
+		},
+		{
+			[]string{"f.html", "f.xml", "f.vue", "f.wxi", "f.wxl", "f.wxs"},
+			"<!--\n HYS\n-->\n\n",
+		},
+		{
+			[]string{"f.ml", "f.mli", "f.mll", "f.mly"},
+			"(**\n   HYS\n*)\n\n",
+		},
+		{
+			[]string{"cmakelists.txt", "f.cmake", "f.cmake.in"},
+			"# HYS\n\n",
+		},
+
+		// ensure matches are case insenstive
+		{
+			[]string{"F.PY", "DoCkErFiLe"},
+			"# HYS\n\n",
+		},
+	}
+
+	for _, tt := range tests {
+		for _, path := range tt.paths {
+			header, _ := Render(path, tpl, data)
+			if got := string(header); got != tt.want {
+				t.Errorf("Render(%q) returned: %q, want: %q", path, got, tt.want)
+			}
+		}
+	}
+}
+
+// Test that generated files are properly recognized.
+func TestIsGenerated(t *testing.T) {
+	tests := []struct {
+		content string
+		want    bool
+	}{
+		{"", false},
+		{"Generated", false},
+		{"// Code generated by Bhojpur License; DO NOT EDIT.", true},
+		{"/*\n* Code generated by Bhojpur License; DO NOT EDIT.\n*/\n", true},
+		{"DO NOT EDIT! Replaced on runs of cargo-raze", true},
+	}
+
+	for _, tt := range tests {
+		b := []byte(tt.content)
+		if got := IsGenerated(b); got != tt.want {
+			t.Errorf("IsGenerated(%q) returned %v, want %v", tt.content, got, tt.want)
+		}
+	}
+}
+
+// Test that existing license headers are identified.
+func TestHasLicense(t *testing.T) {
+	tests := []struct {
+		content string
+		want    bool
+	}{
+		{"", false},
+		{"This is my license", false},
+		{"This code is released into the public domain.", false},
+		{"SPDX: MIT", false},
+
+		{"Copyright 2018", true},
+		{"CoPyRiGhT 2018", true},
+		{"Subject to the terms of the Mozilla Public License", true},
+		{"SPDX-License-Identifier: MIT", true},
+		{"spdx-license-identifier: MIT", true},
+	}
+
+	for _, tt := range tests {
+		b := []byte(tt.content)
+		if got := HasLicense(b); got != tt.want {
+			t.Errorf("HasLicense(%q) returned %v, want %v", tt.content, got, tt.want)
+		}
+	}
+}
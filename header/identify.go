@@ -0,0 +1,324 @@
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package header
+
+import (
+	"bufio"
+	"bytes"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+)
+
+// IdentifyThreshold is the confidence from which a license identified by
+// Identify or IdentifyText is considered a match.
+const IdentifyThreshold = 0.8
+
+// Identification describes the license identified in a header or license
+// text.
+type Identification struct {
+	License    string  // SPDX identifier or expression of the closest license, "" if none
+	Confidence float64 // between 0 and 1, 1 for an exact or SPDX tagged match
+	Year       string  // copyright year(s), if found
+	Holder     string  // copyright holder, if found
+}
+
+// Identified reports whether the confidence of the identification reaches
+// IdentifyThreshold.
+func (id Identification) Identified() bool {
+	return id.License != "" && id.Confidence >= IdentifyThreshold
+}
+
+// Identify identifies the license of the leading comment block of the
+// contents b of a file, after any hashbang line. The comment decoration of
+// the common comment styles is ignored. A valid SPDX-License-Identifier tag
+// takes precedence over the text of the header, which is otherwise compared
+// with the built-in license templates, ignoring copyright notices. Comments
+// separated from the header by blank lines, such as package documentation,
+// are only taken into account if they make for a closer match.
+func Identify(b []byte) Identification {
+	var best Identification
+	groups := leadingComment(b[len(hashBang(b)):])
+	for i := range groups {
+		id := IdentifyText(strings.Join(groups[:i+1], "\n"))
+		if i == 0 || id.Confidence > best.Confidence {
+			best = id
+		}
+	}
+	return best
+}
+
+// IdentifyText identifies the license of text, such as the contents of a
+// license file or a header without its comment decoration. See Identify.
+func IdentifyText(text string) Identification {
+	var id Identification
+	var body []string
+	s := bufio.NewScanner(strings.NewReader(text))
+	s.Buffer(nil, 1<<20)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if m := spdxTagRE.FindStringSubmatch(line); m != nil {
+			if id.License == "" {
+				if e, err := ParseExpression(strings.TrimSpace(m[1])); err == nil {
+					id.License, id.Confidence = e.String(), 1
+				}
+			}
+			continue
+		}
+		if year, holder, ok := parseCopyright(line); ok {
+			if id.Year == "" && id.Holder == "" {
+				id.Year, id.Holder = year, holder
+			}
+			continue
+		}
+		body = append(body, line)
+	}
+	if id.License != "" {
+		return id
+	}
+	words := licenseWords(strings.Join(body, "\n"))
+	if len(words) == 0 {
+		return id
+	}
+	id.License, id.Confidence = closestReference(words)
+	return id
+}
+
+var (
+	spdxTagRE = regexp.MustCompile(`(?i)SPDX-License-Identifier:\s*(.+?)\s*(?:\*/|-->|\*\)|-\})?$`)
+
+	copyrightRE      = regexp.MustCompile(`(?i)^(SPDX-FileCopyrightText:)?\s*(copyright)?\s*(\(c\)|©)?\s*([0-9]{4}(?:\s*[-,]\s*[0-9]{4})*(?:\s*-\s*present)?)?\s*[,.]?\s*(.*)$`)
+	rightsReservedRE = regexp.MustCompile(`(?i)[.,]?\s*all rights reserved\.?$`)
+)
+
+// parseCopyright parses the copyright notice line, returning its years and
+// holder without the "All rights reserved" statement. Sentences starting
+// with "copyright", such as the "copyright notice and this permission
+// notice" of many licenses, are not notices: the word must be followed by a
+// year or copyright sign, or the line be an SPDX-FileCopyrightText tag.
+func parseCopyright(line string) (year, holder string, ok bool) {
+	m := copyrightRE.FindStringSubmatch(line)
+	if m == nil {
+		return "", "", false
+	}
+	tag, word, sign := m[1] != "", m[2] != "", m[3] != ""
+	year = m[4]
+	if !tag && !(word && (sign || year != "")) && !(sign && year != "") {
+		return "", "", false
+	}
+	holder = rightsReservedRE.ReplaceAllString(m[5], "")
+	return year, strings.TrimRight(strings.TrimSpace(holder), ".,"), true
+}
+
+// leadingComment returns the text of the comment lines at the start of b,
+// without their comment decoration, in groups separated by blank lines. The
+// first line of code, or of another kind of comment, ends the block.
+func leadingComment(b []byte) []string {
+	var groups, out []string
+	var end string  // end marker of the current block comment
+	var kind string // start of the first comment, later ones must use the same
+
+	s := bufio.NewScanner(bytes.NewReader(b))
+	s.Buffer(nil, 1<<20)
+	for n := 0; s.Scan() && n < 500; n++ {
+		line := strings.TrimSpace(s.Text())
+		if end != "" {
+			if i := strings.Index(line, end); i >= 0 {
+				line, end = line[:i], ""
+			}
+			out = append(out, strings.TrimSpace(strings.TrimLeft(line, "*#-=;' ")))
+			continue
+		}
+		if line == "" {
+			if len(out) > 0 {
+				groups = append(groups, strings.Join(out, "\n"))
+				out = nil
+			}
+			continue
+		}
+		if text, blockEnd, ok := blockCommentStart(line); ok {
+			if kind == "" {
+				kind = blockEnd
+			} else if kind != blockEnd {
+				break
+			}
+			if i := strings.Index(text, blockEnd); i >= 0 {
+				text = text[:i]
+			} else {
+				end = blockEnd
+			}
+			out = append(out, strings.TrimSpace(strings.TrimLeft(text, "*!")))
+			continue
+		}
+		prefix, text, ok := lineComment(line)
+		if !ok || kind != "" && kind != prefix {
+			break
+		}
+		kind = prefix
+		out = append(out, text)
+	}
+	if len(out) > 0 {
+		groups = append(groups, strings.Join(out, "\n"))
+	}
+	return groups
+}
+
+// blockComments maps the start of block comments to their end.
+var blockComments = []struct{ start, end string }{
+	{"/*", "*/"},
+	{"<!--", "-->"},
+	{"(*", "*)"},
+	{"{-", "-}"},
+	{`"""`, `"""`},
+	{"'''", "'''"},
+	{"#|", "|#"},
+	{"=begin", "=end"},
+	{"{{/*", "*/}}"},
+}
+
+func blockCommentStart(line string) (text, end string, ok bool) {
+	for _, c := range blockComments {
+		if strings.HasPrefix(line, c.start) {
+			return line[len(c.start):], c.end, true
+		}
+	}
+	return "", "", false
+}
+
+// lineComments are the prefixes of line comments, longest first.
+var lineComments = []string{"REM ", "//", "--", "#", ";", "%", "'", "!", "..", "\""}
+
+// lineComment returns the comment prefix and text of line if it is a line
+// comment.
+func lineComment(line string) (prefix, text string, ok bool) {
+	for _, p := range lineComments {
+		if line == strings.TrimSpace(p) || strings.HasPrefix(line, p) {
+			text := strings.TrimLeft(line[len(strings.TrimSpace(p)):], p[:1])
+			if strings.HasPrefix(text, "go:") || strings.HasPrefix(text, " +build") {
+				// build constraints and compiler directives
+				return p, "", true
+			}
+			return p, strings.TrimSpace(text), true
+		}
+	}
+	return "", "", false
+}
+
+// licenseWords returns the lower case words of text, ignoring punctuation.
+func licenseWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !('a' <= r && r <= 'z' || '0' <= r && r <= '9')
+	})
+}
+
+// reference is a license text that identified licenses are compared with.
+type reference struct {
+	license string
+	bigrams map[string]int
+	total   int
+}
+
+var (
+	referencesOnce sync.Once
+	references     []reference
+)
+
+// loadReferences prepares the built-in license templates and the embedded
+// license texts, without their copyright notices, for comparison. Templates
+// of legacy license types are referenced by their SPDX identifier.
+func loadReferences() {
+	referencesOnce.Do(func() {
+		ids := make([]string, 0, len(licenseTemplate))
+		for id := range licenseTemplate {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		for _, id := range ids {
+			tmpl := template.Must(template.New("").Parse(licenseTemplate[id]))
+			b, err := ExecuteTemplate(tmpl, LicenseData{Year: "2000", Holder: "Holder", SPDXID: id}, "", "", "")
+			if err != nil {
+				panic(err)
+			}
+			addReference(SPDXLicense(id), string(b))
+		}
+
+		texts := textReferences()
+		ids = ids[:0]
+		for id := range texts {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		for _, id := range ids {
+			addReference(id, texts[id])
+		}
+	})
+}
+
+// addReference adds the license text to the references of license.
+func addReference(license, text string) {
+	var body []string
+	for _, line := range strings.Split(text, "\n") {
+		if _, _, ok := parseCopyright(strings.TrimSpace(line)); !ok {
+			body = append(body, line)
+		}
+	}
+	words := licenseWords(strings.Join(body, "\n"))
+	bg, n := bigrams(words)
+	references = append(references, reference{license: license, bigrams: bg, total: n})
+}
+
+// bigrams counts the pairs of consecutive words.
+func bigrams(words []string) (map[string]int, int) {
+	m := make(map[string]int)
+	if len(words) == 1 {
+		m[words[0]]++
+		return m, 1
+	}
+	for i := 1; i < len(words); i++ {
+		m[words[i-1]+" "+words[i]]++
+	}
+	return m, len(words) - 1
+}
+
+// closestReference returns the license of the reference most similar to
+// words, using the Dice coefficient of their word pairs as confidence.
+func closestReference(words []string) (string, float64) {
+	loadReferences()
+	bg, n := bigrams(words)
+	best, bestScore := "", 0.0
+	for _, r := range references {
+		common := 0
+		for k, c := range r.bigrams {
+			if d := bg[k]; d < c {
+				common += d
+			} else {
+				common += c
+			}
+		}
+		score := 2 * float64(common) / float64(n+r.total)
+		if score > bestScore {
+			best, bestScore = r.license, score
+		}
+	}
+	return best, bestScore
+}
@@ -0,0 +1,161 @@
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package header
+
+import (
+	"reflect"
+	"testing"
+	"text/template"
+)
+
+func TestIdentify(t *testing.T) {
+	render := func(license, path string, spdx SPDXMode) string {
+		text, err := Template(license, spdx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		d := LicenseData{Year: "2019-2022", Holder: "Bhojpur Consulting Private Limited, India.", SPDXID: license}
+		b, err := Render(path, template.Must(template.New("").Parse(text)), d)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+
+	tests := []struct {
+		description string
+		content     string
+		want        Identification
+		wantMatch   bool
+	}{
+		{
+			"go header",
+			render("MIT", "main.go", SPDXOff) + "package main\n",
+			Identification{License: "MIT", Confidence: 1, Year: "2019-2022", Holder: "Bhojpur Consulting Private Limited, India"},
+			true,
+		},
+		{
+			"block comment",
+			render("BSD-3-Clause", "main.c", SPDXOff) + "#include <stdio.h>\n",
+			Identification{License: "BSD-3-Clause", Confidence: 1, Year: "2019-2022", Holder: "Bhojpur Consulting Private Limited, India"},
+			true,
+		},
+		{
+			"hashbang and SPDX tag",
+			"#!/bin/sh\n" + render("GPL-2.0-only", "run.sh", SPDXOn) + "echo\n",
+			Identification{License: "GPL-2.0-only", Confidence: 1, Year: "2019-2022", Holder: "Bhojpur Consulting Private Limited, India"},
+			true,
+		},
+		{
+			"package documentation",
+			render("Apache-2.0", "main.go", SPDXOff) + "// Package main does things.\npackage main\n",
+			Identification{License: "Apache-2.0", Confidence: 1, Year: "2019-2022", Holder: "Bhojpur Consulting Private Limited, India"},
+			true,
+		},
+		{
+			"legacy BSD-style notice",
+			render("bsd", "main.go", SPDXOff) + "package main\n",
+			Identification{License: "BSD-3-Clause", Confidence: 1, Year: "2019-2022", Holder: "Bhojpur Consulting Private Limited, India"},
+			true,
+		},
+		{
+			"SPDX expression",
+			"<!--\n SPDX-FileCopyrightText: 2021 Jane Doe\n SPDX-License-Identifier: mit OR Apache-2.0\n-->\n<html>",
+			Identification{License: "MIT OR Apache-2.0", Confidence: 1, Year: "2021", Holder: "Jane Doe"},
+			true,
+		},
+		{
+			"invalid SPDX tag",
+			"// SPDX-License-Identifier: Apache2\n\npackage main\n",
+			Identification{},
+			false,
+		},
+		{
+			"no header",
+			"package main\n",
+			Identification{},
+			false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			got := Identify([]byte(tt.content))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Identify(%q) = %+v, want %+v", tt.content, got, tt.want)
+			}
+			if got.Identified() != tt.wantMatch {
+				t.Errorf("Identify(%q).Identified() = %t, want %t", tt.content, got.Identified(), tt.wantMatch)
+			}
+		})
+	}
+}
+
+func TestIdentifyConfidence(t *testing.T) {
+	// a header with a modified sentence is still identified, with a lower
+	// confidence
+	text := "Copyright (c) 2022 Holder\n\n" +
+		"Permission to use, copy, modify, and/or distribute this software for any\n" +
+		"purpose with or without fee is hereby granted, provided that the above\n" +
+		"copyright notice and this permission notice appear in all copies.\n\n" +
+		"THE SOFTWARE IS PROVIDED \"AS IS\" AND THE AUTHORS DISCLAIM ALL WARRANTIES\n" +
+		"WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF\n" +
+		"MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHORS BE LIABLE FOR\n" +
+		"ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES\n" +
+		"WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN\n" +
+		"ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF\n" +
+		"OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE."
+	got := IdentifyText(text)
+	if got.License != "ISC" || got.Confidence >= 1 || !got.Identified() {
+		t.Errorf("IdentifyText returned %+v, want ISC with a confidence below 1", got)
+	}
+
+	// the closest license is reported along with a low confidence
+	got = IdentifyText("Licensed under the Apache License. Do not redistribute.")
+	if got.Identified() || got.Confidence == 0 {
+		t.Errorf("IdentifyText returned %+v, want an unidentified license with a non-zero confidence", got)
+	}
+}
+
+func TestParseCopyright(t *testing.T) {
+	tests := []struct {
+		line       string
+		wantYear   string
+		wantHolder string
+		wantOK     bool
+	}{
+		{"Copyright 2022 Holder. All rights reserved.", "2022", "Holder", true},
+		{"Copyright (c) 2018-2022 Holder, Inc.", "2018-2022", "Holder, Inc", true},
+		{"Copyright (C) 2019, 2021 Jane Doe", "2019, 2021", "Jane Doe", true},
+		{"© 2020 Holder", "2020", "Holder", true},
+		{"Copyright (c) 2016-present, Facebook, Inc.", "2016-present", "Facebook, Inc", true},
+		{"Copyright © The Authors", "", "The Authors", true},
+		{"SPDX-FileCopyrightText: 2022 Jane Doe <jane@example.com>", "2022", "Jane Doe <jane@example.com>", true},
+		{"copyright notice and this permission notice appear in all copies.", "", "", false},
+		{"The above copyright notice", "", "", false},
+	}
+	for _, tt := range tests {
+		year, holder, ok := parseCopyright(tt.line)
+		if year != tt.wantYear || holder != tt.wantHolder || ok != tt.wantOK {
+			t.Errorf("parseCopyright(%q) = %q, %q, %t, want %q, %q, %t", tt.line, year, holder, ok, tt.wantYear, tt.wantHolder, tt.wantOK)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package header
+
+import (
+	"text/template"
+
+	doublestar "github.com/bmatcuk/doublestar/v4"
+)
+
+// License is a license header template along with the data it is rendered
+// with.
+type License struct {
+	Template *template.Template
+	Data     LicenseData
+}
+
+// NewLicense returns the license rendering the template of the given license
+// with data. If text is not empty, it is used as the template instead of the
+// built-in one, unless only SPDX tags are requested. Otherwise, the
+// license must be a built-in license or a valid SPDX license expression, see
+// Template. Built-in licenses, legacy license types such as "apache" and
+// expressions are mapped to their canonical SPDX identifiers.
+func NewLicense(license, text string, spdx SPDXMode, data LicenseData) (*License, error) {
+	if text == "" || spdx.tagsOnly() {
+		var err error
+		if license, text, err = licenseTemplateFor(license, spdx); err != nil {
+			return nil, err
+		}
+	} else if id, ok := LookupLicense(license); ok {
+		license = id
+	}
+	data.SPDXID = license
+
+	t, err := template.New("").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	return &License{Template: t, Data: data}, nil
+}
+
+// Rule selects the license of the files matching one of its patterns.
+type Rule struct {
+	Patterns []string // doublestar patterns matched against file names
+	Skip     bool     // leave matching files alone
+	License  *License // license of matching files, unless Skip is set
+}
+
+// fileMatches determines if path matches one of the provided file patterns.
+// Patterns are assumed to be valid.
+func fileMatches(path string, patterns []string) bool {
+	for _, p := range patterns {
+		// ignore error, since we assume patterns are valid
+		if match, _ := doublestar.Match(p, path); match {
+			return true
+		}
+	}
+	return false
+}
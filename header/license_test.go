@@ -0,0 +1,161 @@
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package header
+
+import (
+	"testing"
+	"text/template"
+)
+
+func TestNewLicense(t *testing.T) {
+	data := LicenseData{Year: "2022", Holder: "H"}
+	tests := []struct {
+		license, text string
+		spdx          SPDXMode
+		wantSPDXID    string
+		want          string // rendered license
+	}{
+		{"apache", "", SPDXOff, "Apache-2.0", "Copyright 2022 H. All rights reserved."},
+		{"mit", "", SPDXOnly, "MIT", "Copyright 2022 H. All rights resevred.\nSPDX-License-Identifier: MIT"},
+		{"MIT", "{{.Holder}} {{.SPDXID}}", SPDXOff, "MIT", "H MIT"},
+		{"MIT", "{{.Holder}}", SPDXOnly, "MIT", "Copyright 2022 H. All rights resevred.\nSPDX-License-Identifier: MIT"},
+		{"custom", "{{.Holder}}", SPDXOff, "custom", "H"},
+		{"gpl-3.0-or-later", "", SPDXOn, "GPL-3.0-or-later", "Copyright (C) 2022 H\n\nThis program is free software"},
+		{"isc", "", SPDXOnly, "ISC", "Copyright 2022 H. All rights resevred.\nSPDX-License-Identifier: ISC"},
+		{"MIT", "{{.Holder}}", SPDXReuse, "MIT", "SPDX-FileCopyrightText: 2022 H\n\nSPDX-License-Identifier: MIT"},
+		{"GPL-3.0", "", SPDXOff, "GPL-3.0-only", "Copyright (C) 2022 H\n\nThis program is free software"},
+		{"GPL-2.0+", "", SPDXOnly, "GPL-2.0-or-later", "Copyright 2022 H. All rights resevred.\nSPDX-License-Identifier: GPL-2.0-or-later"},
+		{"bsd", "", SPDXOnly, "bsd", "Copyright 2022 H. All rights resevred.\nSPDX-License-Identifier: BSD-3-Clause"},
+	}
+
+	for _, tt := range tests {
+		l, err := NewLicense(tt.license, tt.text, tt.spdx, data)
+		if err != nil {
+			t.Errorf("NewLicense(%q, %q, %q) returned error: %v", tt.license, tt.text, tt.spdx, err)
+			continue
+		}
+		b, err := ExecuteTemplate(l.Template, l.Data, "", "", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if l.Data.SPDXID != tt.wantSPDXID || string(b[:len(tt.want)]) != tt.want {
+			t.Errorf("NewLicense(%q, %q, %q) rendered %q with SPDX ID %q, want %q and %q", tt.license, tt.text, tt.spdx, b, l.Data.SPDXID, tt.want, tt.wantSPDXID)
+		}
+	}
+
+	if _, err := NewLicense("unknown", "", SPDXOff, data); err == nil {
+		t.Error("NewLicense with an unknown license returned no error")
+	}
+	if _, err := NewLicense("MIT", "{{.Holder", SPDXOff, data); err == nil {
+		t.Error("NewLicense with an invalid template returned no error")
+	}
+}
+
+func TestLicenseFor(t *testing.T) {
+	def := &License{Template: template.Must(template.New("").Parse("def"))}
+	mit := &License{Template: template.Must(template.New("").Parse("mit"))}
+	bsd := &License{Template: template.Must(template.New("").Parse("bsd"))}
+	p := &Processor{
+		License: def,
+		Rules: []Rule{
+			{Patterns: []string{"examples/**"}, License: mit},
+			{Patterns: []string{"third_party/**"}, Skip: true},
+			{Patterns: []string{"**/*.go"}, License: bsd},
+		},
+	}
+
+	tests := []struct {
+		name string
+		want *License
+	}{
+		{"main.c", def},
+		{"examples/a/main.go", mit},
+		{"third_party/x/main.go", nil},
+		{"cmd/main.go", bsd},
+		{"../examples/main.c", def},
+	}
+
+	for _, tt := range tests {
+		if got := p.LicenseFor(tt.name); got != tt.want {
+			t.Errorf("LicenseFor(%q) returned %p, want %p", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFileMatches(t *testing.T) {
+	tests := []struct {
+		pattern   string
+		path      string
+		wantMatch bool
+	}{
+		// basic single directory patterns
+		{"", "file.c", false},
+		{"*.c", "file.h", false},
+		{"*.c", "file.c", true},
+
+		// subdirectory patterns
+		{"*.c", "vendor/file.c", false},
+		{"**/*.c", "vendor/file.c", true},
+		{"vendor/**", "vendor/file.c", true},
+		{"vendor/**/*.c", "vendor/file.c", true},
+		{"vendor/**/*.c", "vendor/a/b/file.c", true},
+
+		// single character "?" match
+		{"*.?", "file.c", true},
+		{"*.?", "file.go", false},
+		{"*.??", "file.c", false},
+		{"*.??", "file.go", true},
+
+		// character classes - sets and ranges
+		{"*.[ch]", "file.c", true},
+		{"*.[ch]", "file.h", true},
+		{"*.[ch]", "file.ch", false},
+		{"*.[a-z]", "file.c", true},
+		{"*.[a-z]", "file.h", true},
+		{"*.[a-z]", "file.go", false},
+		{"*.[a-z]", "file.R", false},
+
+		// character classes - negations
+		{"*.[^ch]", "file.c", false},
+		{"*.[^ch]", "file.h", false},
+		{"*.[^ch]", "file.R", true},
+		{"*.[!ch]", "file.c", false},
+		{"*.[!ch]", "file.h", false},
+		{"*.[!ch]", "file.R", true},
+
+		// comma-separated alternative matches
+		{"*.{c,go}", "file.c", true},
+		{"*.{c,go}", "file.go", true},
+		{"*.{c,go}", "file.h", false},
+
+		// negating alternative matches
+		{"*.[^{c,go}]", "file.c", false},
+		{"*.[^{c,go}]", "file.go", false},
+		{"*.[^{c,go}]", "file.h", true},
+	}
+
+	for _, tt := range tests {
+		patterns := []string{tt.pattern}
+		if got := fileMatches(tt.path, patterns); got != tt.wantMatch {
+			t.Errorf("fileMatches(%q, %q) returned %v, want %v", tt.path, patterns, got, tt.wantMatch)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package header
+
+import (
+	"strings"
+	"testing"
+	"text/template"
+)
+
+func TestLookupLicense(t *testing.T) {
+	tests := []struct {
+		id     string
+		want   string
+		wantOK bool
+	}{
+		{"Apache-2.0", "Apache-2.0", true},
+		{"apache-2.0", "Apache-2.0", true},
+		{"apache", "Apache-2.0", true},
+		{"MIT", "MIT", true},
+		{"mit", "MIT", true},
+		{"mpl", "MPL-2.0", true},
+		{"bsd", "bsd", true},
+		{"BSD", "bsd", true},
+		{"bsd-3-clause", "BSD-3-Clause", true},
+		{"GPL-2.0-ONLY", "GPL-2.0-only", true},
+		{"0bsd", "0BSD", true},
+		{"unlicense", "Unlicense", true},
+		{"cc-by-4.0", "CC-BY-4.0", true},
+		{"GPL-3.0", "", false},
+		{"unknown", "", false},
+		{"", "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := LookupLicense(tt.id)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("LookupLicense(%q) returned %q, %t, want %q, %t", tt.id, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestLicenses(t *testing.T) {
+	infos := Licenses()
+	if len(infos) != len(licenseTemplate) {
+		t.Fatalf("Licenses returned %d licenses, want %d", len(infos), len(licenseTemplate))
+	}
+	for i, info := range infos {
+		if info.Name == "" {
+			t.Errorf("license %q has no name", info.ID)
+		}
+		if i > 0 && strings.ToLower(infos[i-1].ID) >= strings.ToLower(info.ID) {
+			t.Errorf("Licenses not sorted: %q before %q", infos[i-1].ID, info.ID)
+		}
+	}
+}
+
+// TestLicenseCatalogue renders every built-in template and verifies that the
+// resulting header is recognized as a license and identified as the license
+// it was rendered from, so that headers are neither added twice nor mistaken
+// for one another.
+func TestLicenseCatalogue(t *testing.T) {
+	styles := DefaultStyles()
+	data := LicenseData{Year: "2022", Holder: "Bhojpur Consulting Private Limited, India."}
+	for _, info := range Licenses() {
+		for _, spdx := range []SPDXMode{SPDXOff, SPDXOn} {
+			tmpl, err := Template(info.ID, spdx)
+			if err != nil {
+				t.Fatalf("Template(%q) returned error: %v", info.ID, err)
+			}
+			d := data
+			d.SPDXID = info.ID
+			lic, err := styles.Render("main.go", template.Must(template.New("").Parse(tmpl)), d)
+			if err != nil {
+				t.Fatalf("%s: %v", info.ID, err)
+			}
+			b := append(lic, "package main\n"...)
+			if !HasLicense(b) {
+				t.Errorf("%s header (spdx %q) not recognized as a license", info.ID, spdx)
+			}
+			h := findHeader(styles, b)
+			if h == nil {
+				t.Errorf("%s header (spdx %q) not found", info.ID, spdx)
+				continue
+			}
+			// the SPDX tag of legacy license types holds their SPDX identifier
+			want := info.ID
+			if spdx == SPDXOn {
+				want = SPDXLicense(info.ID)
+			}
+			if h.license != want {
+				t.Errorf("%s header (spdx %q) identified as %q, want %q", info.ID, spdx, h.license, want)
+			}
+			if string(b[h.end:]) != "package main\n" {
+				t.Errorf("%s header (spdx %q) ends at %d, leaving %q", info.ID, spdx, h.end, b[h.end:])
+			}
+		}
+	}
+}
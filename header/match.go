@@ -0,0 +1,135 @@
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package header
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Sentinel values substituted for LicenseData fields when rendering a
+// template that is used to recognize existing headers. They consist of
+// letters only, so that they survive regexp.QuoteMeta unchanged.
+const (
+	yearSentinel   = "LICENSEYEARSENTINEL"
+	holderSentinel = "LICENSEHOLDERSENTINEL"
+	spdxSentinel   = "LICENSESPDXSENTINEL"
+)
+
+// sentinelPatterns maps each sentinel to the named group that replaces it
+// in a header regular expression.
+var sentinelPatterns = []struct {
+	sentinel string
+	name     string
+	pattern  string
+}{
+	{yearSentinel, "year", `[0-9]{4}(?:[ \t]*[-,][ \t]*[0-9]{4})*`},
+	{holderSentinel, "holder", `[^\r\n]+?`},
+	{spdxSentinel, "spdx", `[^\r\n]+?`},
+}
+
+var yearRE = regexp.MustCompile(`[0-9]{4}`)
+
+// headerRegexp compiles a regular expression matching the rendered license
+// header lic at the start of a file. Differences in whitespace are
+// tolerated, and any sentinel values in lic match arbitrary content that is
+// captured in the "year", "holder" and "spdx" groups. The match includes
+// the blank line following the header, if present.
+func headerRegexp(lic []byte) (*regexp.Regexp, error) {
+	fields := strings.Fields(string(lic))
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("empty license header")
+	}
+	for i, f := range fields {
+		fields[i] = regexp.QuoteMeta(f)
+	}
+	expr := strings.Join(fields, `\s+`)
+	for _, s := range sentinelPatterns {
+		named := false
+		expr = replaceEach(expr, s.sentinel, func() string {
+			// only the first occurrence may be a named group
+			if named {
+				return "(?:" + s.pattern + ")"
+			}
+			named = true
+			return "(?P<" + s.name + ">" + s.pattern + ")"
+		})
+	}
+	return regexp.Compile(`\A\s*` + expr + `[ \t]*(?:\r?\n|\z)(?:[ \t]*\r?\n)?`)
+}
+
+var headerRegexpCache sync.Map // map[string]*regexp.Regexp
+
+// cachedHeaderRegexp is like headerRegexp, but reuses the expressions
+// compiled for identical headers.
+func cachedHeaderRegexp(lic []byte) (*regexp.Regexp, error) {
+	if re, ok := headerRegexpCache.Load(string(lic)); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := headerRegexp(lic)
+	if err != nil {
+		return nil, err
+	}
+	headerRegexpCache.Store(string(lic), re)
+	return re, nil
+}
+
+// replaceEach replaces each occurrence of old in s with the result of
+// calling repl.
+func replaceEach(s, old string, repl func() string) string {
+	parts := strings.Split(s, old)
+	var b strings.Builder
+	for i, p := range parts {
+		if i > 0 {
+			b.WriteString(repl())
+		}
+		b.WriteString(p)
+	}
+	return b.String()
+}
+
+// lastYear returns the latest year mentioned in s, such as 2018 for
+// "2005-2008,2018".
+func lastYear(s string) (int, bool) {
+	last, ok := 0, false
+	for _, y := range yearRE.FindAllString(s, -1) {
+		n, _ := strconv.Atoi(y)
+		if n > last {
+			last, ok = n, true
+		}
+	}
+	return last, ok
+}
+
+// firstYear returns the earliest year mentioned in s.
+func firstYear(s string) (int, bool) {
+	first, ok := 0, false
+	for _, y := range yearRE.FindAllString(s, -1) {
+		n, _ := strconv.Atoi(y)
+		if !ok || n < first {
+			first, ok = n, true
+		}
+	}
+	return first, ok
+}
@@ -0,0 +1,125 @@
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package header
+
+import (
+	"testing"
+)
+
+func TestHeaderRegexp(t *testing.T) {
+	tests := []struct {
+		header     string // rendered header passed to headerRegexp
+		content    string // file content to match
+		wantMatch  string // expected full match, if any
+		wantYear   string // expected "year" group
+		wantHolder string // expected "holder" group
+	}{
+		{
+			"// Copyright " + yearSentinel + " H\n\n",
+			"// Copyright 2018 H\n\ncontent",
+			"// Copyright 2018 H\n\n",
+			"2018",
+			"",
+		},
+		{
+			"// Copyright " + yearSentinel + " H\n\n",
+			"// Copyright 2005-2008, 2018 H\ncontent",
+			"// Copyright 2005-2008, 2018 H\n",
+			"2005-2008, 2018",
+			"",
+		},
+		// whitespace differences are tolerated
+		{
+			"/*\n * Copyright " + yearSentinel + " H\n *\n * Text\n */\n\n",
+			"\n/*\n *   Copyright 2018 H\n *\n *   Text\n */\n\n\ncontent",
+			"\n/*\n *   Copyright 2018 H\n *\n *   Text\n */\n\n",
+			"2018",
+			"",
+		},
+		{
+			"# Copyright " + yearSentinel + " " + holderSentinel + ". All rights reserved.\n\n",
+			"# Copyright 2018 Bhojpur Consulting, India. All rights reserved.\n",
+			"# Copyright 2018 Bhojpur Consulting, India. All rights reserved.\n",
+			"2018",
+			"Bhojpur Consulting, India",
+		},
+
+		// headers must be at the start of the file
+		{
+			"// Copyright " + yearSentinel + " H\n\n",
+			"content\n// Copyright 2018 H\n\n",
+			"",
+			"",
+			"",
+		},
+		{
+			"// Copyright " + yearSentinel + " H\n\n",
+			"// Copyright 2018 X\n\n",
+			"",
+			"",
+			"",
+		},
+	}
+
+	for _, tt := range tests {
+		re, err := headerRegexp([]byte(tt.header))
+		if err != nil {
+			t.Fatalf("headerRegexp(%q) returned error: %v", tt.header, err)
+		}
+		m := re.FindStringSubmatch(tt.content)
+		var got, year, holder string
+		if m != nil {
+			got = m[0]
+			if i := re.SubexpIndex("year"); i >= 0 {
+				year = m[i]
+			}
+			if i := re.SubexpIndex("holder"); i >= 0 {
+				holder = m[i]
+			}
+		}
+		if got != tt.wantMatch || year != tt.wantYear || holder != tt.wantHolder {
+			t.Errorf("headerRegexp(%q) on %q matched (%q, %q, %q), want (%q, %q, %q)",
+				tt.header, tt.content, got, year, holder, tt.wantMatch, tt.wantYear, tt.wantHolder)
+		}
+	}
+}
+
+func TestLastYear(t *testing.T) {
+	tests := []struct {
+		years  string
+		want   int
+		wantOK bool
+	}{
+		{"", 0, false},
+		{"unknown", 0, false},
+		{"2018", 2018, true},
+		{"2018-2022", 2022, true},
+		{"2005-2008,2018", 2018, true},
+		{"2022, 2019", 2022, true},
+	}
+
+	for _, tt := range tests {
+		got, ok := lastYear(tt.years)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("lastYear(%q) returned (%d, %t), want (%d, %t)", tt.years, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
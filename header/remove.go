@@ -0,0 +1,63 @@
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package header
+
+import (
+	"text/template"
+)
+
+// removeLicense removes the license header rendered from tmpl with data from
+// the start of the contents b of the file at path, together with its
+// trailing blank line. The header may have any copyright year and may use
+// the comment decoration of any of the comment styles of the registry; a hashbang
+// or other head line preceding it is kept.
+//
+// It returns the new contents, and false if no such header was found.
+func removeLicense(styles *StyleRegistry, path string, b []byte, tmpl *template.Template, data LicenseData) ([]byte, bool, error) {
+	candidates := styles.Styles()
+	if s, ok := styles.Lookup(path); ok {
+		// try the style of the file type first
+		candidates = append([]CommentStyle{s}, candidates...)
+	}
+
+	d := data
+	d.Year = yearSentinel
+	off := len(hashBang(b))
+	for _, s := range candidates {
+		lic, err := ExecuteTemplate(tmpl, d, s.Top, s.Mid, s.Bot)
+		if err != nil {
+			return nil, false, err
+		}
+		re, err := cachedHeaderRegexp(lic)
+		if err != nil {
+			return nil, false, err
+		}
+		m := re.FindIndex(b[off:])
+		if m == nil {
+			continue
+		}
+		out := make([]byte, 0, len(b)-(m[1]-m[0]))
+		out = append(out, b[:off+m[0]]...)
+		out = append(out, b[off+m[1]:]...)
+		return out, true, nil
+	}
+	return nil, false, nil
+}
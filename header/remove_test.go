@@ -0,0 +1,65 @@
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package header
+
+import (
+	"testing"
+	"text/template"
+)
+
+func TestRemoveLicense(t *testing.T) {
+	tmpl := template.Must(template.New("").Parse("Copyright {{.Year}} {{.Holder}}\n\nText"))
+	data := LicenseData{Holder: "H", Year: "2022"}
+
+	tests := []struct {
+		path        string
+		contents    string
+		wantContent string
+		wantRemoved bool
+	}{
+		{"file.go", "// Copyright 2019 H\n//\n// Text\n\ncontent", "content", true},
+		{"file.go", "// Copyright 2018-2021 H\n//\n// Text\ncontent", "content", true},
+		{"file.c", "/*\n * Copyright 2018 H\n *\n * Text\n */\n\ncontent", "content", true},
+		{"file.sh", "#!/bin/bash\n# Copyright 2019 H\n#\n# Text\n\ncontent", "#!/bin/bash\ncontent", true},
+		{"file.sh", "\n# Copyright 2019 H\n#\n# Text\n\n\ncontent", "\ncontent", true},
+
+		// decoration of another comment style
+		{"file.go", "/*\n * Copyright 2019 H\n *\n * Text\n */\n\ncontent", "content", true},
+
+		// headers not matching the template or holder are kept
+		{"file.go", "// Copyright 2019 Other\n//\n// Text\n\ncontent", "", false},
+		{"file.go", "// Copyright 2019 H\n//\n// Other\n\ncontent", "", false},
+		{"file.go", "content\n// Copyright 2019 H\n//\n// Text\n", "", false},
+	}
+
+	for _, tt := range tests {
+		got, removed, err := removeLicense(defaultStyles, tt.path, []byte(tt.contents), tmpl, data)
+		if err != nil {
+			t.Errorf("removeLicense(%q, %q) returned error: %v", tt.path, tt.contents, err)
+		}
+		if removed != tt.wantRemoved {
+			t.Errorf("removeLicense(%q, %q) returned removed: %t, want %t", tt.path, tt.contents, removed, tt.wantRemoved)
+		}
+		if string(got) != tt.wantContent {
+			t.Errorf("removeLicense(%q, %q) returned contents: %q, want %q", tt.path, tt.contents, got, tt.wantContent)
+		}
+	}
+}
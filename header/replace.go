@@ -0,0 +1,169 @@
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package header
+
+import (
+	"regexp"
+	"sort"
+	"text/template"
+)
+
+// knownHeader is a regular expression matching the header rendered from a
+// built-in license template in one of the comment styles.
+type knownHeader struct {
+	license string // key of the licenseTemplate entry, or "" for SPDX only headers
+	re      *regexp.Regexp
+}
+
+// knownHeaders returns the expressions matching all the built-in license
+// templates, with and without SPDX identifiers, in all the comment styles of
+// the registry. Templates with the SPDX suffix come first, so that the
+// identifier is matched as part of the header.
+func (r *StyleRegistry) knownHeaders() []knownHeader {
+	r.mu.RLock()
+	known := r.known
+	r.mu.RUnlock()
+	if known != nil {
+		return known
+	}
+
+	var names []string
+	for name := range licenseTemplate {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	type variant struct {
+		license string
+		tmpl    string
+		data    LicenseData
+	}
+	wild := LicenseData{Year: yearSentinel, Holder: holderSentinel, SPDXID: spdxSentinel}
+	var variants []variant
+	for _, name := range names {
+		variants = append(variants, variant{name, licenseTemplate[name] + spdxSuffix, wild})
+	}
+	for _, name := range names {
+		variants = append(variants, variant{name, licenseTemplate[name], wild})
+	}
+	variants = append(variants,
+		variant{"", tmplSPDX, wild},
+		variant{"", tmplSPDX, LicenseData{SPDXID: spdxSentinel}},
+		variant{"", tmplReuse, wild},
+	)
+
+	for _, v := range variants {
+		tmpl := template.Must(template.New("").Parse(v.tmpl))
+		for _, s := range r.Styles() {
+			lic, err := ExecuteTemplate(tmpl, v.data, s.Top, s.Mid, s.Bot)
+			if err != nil {
+				panic(err)
+			}
+			re, err := headerRegexp(lic)
+			if err != nil {
+				panic(err)
+			}
+			known = append(known, knownHeader{v.license, re})
+		}
+	}
+	r.mu.Lock()
+	r.known = known
+	r.mu.Unlock()
+	return known
+}
+
+// foundHeader describes a license header found at the start of a file.
+type foundHeader struct {
+	license    string // SPDX identifier or licenseTemplate key of the header
+	year       string // copyright year(s), if any
+	holder     string // copyright holder, if any
+	start, end int    // byte range of the header including its trailing blank line
+}
+
+// findHeader looks for a header rendered from one of the built-in license
+// templates, in one of the comment styles of the registry, at the start of b
+// after any hashbang line. It returns nil if no such header is found.
+func findHeader(styles *StyleRegistry, b []byte) *foundHeader {
+	off := len(hashBang(b))
+	for _, k := range styles.knownHeaders() {
+		m := k.re.FindSubmatchIndex(b[off:])
+		if m == nil {
+			continue
+		}
+		group := func(name string) string {
+			i := k.re.SubexpIndex(name)
+			if i < 0 || m[2*i] < 0 {
+				return ""
+			}
+			return string(b[off+m[2*i] : off+m[2*i+1]])
+		}
+		h := &foundHeader{
+			license: k.license,
+			year:    group("year"),
+			holder:  group("holder"),
+			start:   off + m[0],
+			end:     off + m[1],
+		}
+		if spdx := group("spdx"); spdx != "" {
+			h.license = spdx
+		}
+		return h
+	}
+	return nil
+}
+
+// replaceLicense replaces a header rendered from one of the built-in license
+// templates at the start of the contents b of the file at path with the
+// header rendered from tmpl. Headers already matching tmpl are left intact.
+//
+// It returns the new contents and the license of the replaced header, or an
+// empty string if the header was not replaced.
+func replaceLicense(styles *StyleRegistry, path string, b []byte, tmpl *template.Template, data LicenseData) ([]byte, string, error) {
+	h := findHeader(styles, b)
+	if h == nil {
+		return nil, "", nil
+	}
+
+	// leave headers for the selected license alone, whatever their year
+	// or holder
+	d := LicenseData{Year: yearSentinel, Holder: holderSentinel, SPDXID: data.SPDXID}
+	cur, err := styles.Render(path, tmpl, d)
+	if err != nil || cur == nil {
+		return nil, "", err
+	}
+	re, err := cachedHeaderRegexp(cur)
+	if err != nil {
+		return nil, "", err
+	}
+	if re.Match(b[h.start:h.end]) {
+		return nil, "", nil
+	}
+
+	lic, err := styles.Render(path, tmpl, data)
+	if err != nil {
+		return nil, "", err
+	}
+	out := make([]byte, 0, len(b)-(h.end-h.start)+len(lic))
+	out = append(out, b[:h.start]...)
+	out = append(out, lic...)
+	out = append(out, b[h.end:]...)
+	return out, h.license, nil
+}
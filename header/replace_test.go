@@ -0,0 +1,142 @@
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package header
+
+import (
+	"testing"
+	"text/template"
+)
+
+func TestFindHeader(t *testing.T) {
+	apache := template.Must(template.New("").Parse(tmplApache))
+	mitSPDX := template.Must(template.New("").Parse(tmplMIT + spdxSuffix))
+	spdxOnly := template.Must(template.New("").Parse(tmplSPDX))
+	data := LicenseData{Year: "2018", Holder: "Bhojpur Consulting", SPDXID: "MIT"}
+
+	render := func(tmpl *template.Template, s CommentStyle) string {
+		b, err := ExecuteTemplate(tmpl, data, s.Top, s.Mid, s.Bot)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+
+	tests := []struct {
+		description string
+		content     string
+		want        *foundHeader
+	}{
+		{"no header", "content", nil},
+		{"unknown header", "// Copyright 2018 Bhojpur Consulting\n\ncontent", nil},
+		{
+			"apache in its own style",
+			render(apache, styleBlock) + "content",
+			&foundHeader{license: "Apache-2.0", year: "2018", holder: "Bhojpur Consulting", end: len(render(apache, styleBlock))},
+		},
+		{
+			"apache in another style",
+			render(apache, styleHash) + "content",
+			&foundHeader{license: "Apache-2.0", year: "2018", holder: "Bhojpur Consulting", end: len(render(apache, styleHash))},
+		},
+		{
+			"mit with SPDX identifier",
+			render(mitSPDX, styleSlashes) + "content",
+			&foundHeader{license: "MIT", year: "2018", holder: "Bhojpur Consulting", end: len(render(mitSPDX, styleSlashes))},
+		},
+		{
+			"SPDX only",
+			"#!/bin/sh\n" + render(spdxOnly, styleHash) + "content",
+			&foundHeader{license: "MIT", year: "2018", holder: "Bhojpur Consulting", start: 10, end: 10 + len(render(spdxOnly, styleHash))},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			got := findHeader(defaultStyles, []byte(tt.content))
+			if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+				t.Errorf("findHeader(%q) returned %+v, want %+v", tt.content, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReplaceLicense(t *testing.T) {
+	apache := template.Must(template.New("").Parse(tmplApache))
+	mit := template.Must(template.New("").Parse(tmplMIT))
+	bsd := template.Must(template.New("").Parse(tmplBSD))
+	data := LicenseData{Year: "2022", Holder: "H", SPDXID: "MIT"}
+	old := LicenseData{Year: "2018", Holder: "Old", SPDXID: "Apache-2.0"}
+
+	render := func(tmpl *template.Template, d LicenseData) string {
+		b, err := Render("file.go", tmpl, d)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+
+	tests := []struct {
+		description  string
+		content      string
+		wantContent  string
+		wantReplaced string
+	}{
+		{
+			"replace apache header",
+			render(apache, old) + "content",
+			render(mit, data) + "content",
+			"Apache-2.0",
+		},
+		{
+			"replace bsd header after hashbang",
+			"#!/usr/bin/env foo\n" + render(bsd, old) + "content",
+			"#!/usr/bin/env foo\n" + render(mit, data) + "content",
+			"bsd",
+		},
+		{
+			"keep header of the selected license",
+			render(mit, old) + "content",
+			"",
+			"",
+		},
+		{
+			"keep unknown header",
+			"// Copyright 2018 Old\n\ncontent",
+			"",
+			"",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			got, replaced, err := replaceLicense(defaultStyles, "file.go", []byte(tt.content), mit, data)
+			if err != nil {
+				t.Fatalf("replaceLicense returned error: %v", err)
+			}
+			if replaced != tt.wantReplaced {
+				t.Errorf("replaceLicense(%q) replaced %q, want %q", tt.content, replaced, tt.wantReplaced)
+			}
+			if string(got) != tt.wantContent {
+				t.Errorf("replaceLicense(%q) returned %q, want %q", tt.content, got, tt.wantContent)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package header
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// SidecarSuffix is the suffix of sidecar files, which hold the license
+// header of the file they are named after when that file cannot hold one
+// itself, as defined by the REUSE specification.
+const SidecarSuffix = ".license"
+
+var (
+	// sidecarTemplate renders the contents of sidecar files.
+	sidecarTemplate = template.Must(template.New("").Parse(tmplReuse))
+
+	// sidecarStyles holds the plain style of the contents of sidecar files.
+	sidecarStyles = &StyleRegistry{rules: []StyleRule{{Extensions: []string{SidecarSuffix}}}}
+)
+
+// IsSidecar reports whether name is the name of a sidecar file.
+func IsSidecar(name string) bool {
+	return strings.HasSuffix(strings.ToLower(name), SidecarSuffix)
+}
+
+// NeedsSidecar reports whether the named file, with contents b, gets its
+// license header in a sidecar file: no rule skips it, and it is a binary file
+// or its file type has no known comment style. Sidecar files and empty files
+// need none.
+func (p *Processor) NeedsSidecar(name string, b []byte) bool {
+	if IsSidecar(name) || len(b) == 0 || p.LicenseFor(name) == nil {
+		return false
+	}
+	_, ok := p.styles().Lookup(name)
+	return !ok || isBinary(b)
+}
+
+// FixSidecar is like Fix for the sidecar file of the named file, holding the
+// SPDX-FileCopyrightText and SPDX-License-Identifier tags of its license.
+// The current contents of the sidecar file are sidecar, nil if it does not
+// exist. A removal leaving the sidecar file empty has a nil Content, meaning
+// that the sidecar file must be deleted.
+func (p *Processor) FixSidecar(name string, sidecar []byte) (*Change, error) {
+	l, data, err := p.fileLicense(name)
+	if err != nil || l == nil {
+		return nil, err
+	}
+	path := name + SidecarSuffix
+
+	if p.Remove {
+		nb, removed, err := removeLicense(sidecarStyles, path, sidecar, sidecarTemplate, data)
+		if err != nil || !removed {
+			return nil, err
+		}
+		if len(bytes.TrimSpace(nb)) == 0 {
+			nb = nil
+		}
+		return &Change{Action: Removed, Content: nb}, nil
+	}
+	if p.Replace {
+		nb, old, err := replaceLicense(sidecarStyles, path, sidecar, sidecarTemplate, data)
+		if err != nil {
+			return nil, err
+		}
+		if old != "" {
+			nb = append(bytes.TrimRight(nb, "\n"), '\n')
+			return &Change{Action: Replaced, From: old, To: data.SPDXID, Content: nb}, nil
+		}
+	}
+	if !HasLicense(sidecar) {
+		lic, err := ExecuteTemplate(sidecarTemplate, data, "", "", "")
+		if err != nil {
+			return nil, err
+		}
+		if len(bytes.TrimSpace(sidecar)) == 0 {
+			// no blank line after the tags of a new sidecar file
+			return &Change{Action: Added, To: data.SPDXID, Content: lic[:len(lic)-1]}, nil
+		}
+		return &Change{Action: Added, To: data.SPDXID, Content: insertHeader(sidecar, lic)}, nil
+	}
+	if p.Update {
+		nb, updated, err := updateYear(sidecarStyles, path, sidecar, sidecarTemplate, data)
+		if err != nil || !updated {
+			return nil, err
+		}
+		return &Change{Action: Updated, Content: nb}, nil
+	}
+	return nil, nil
+}
+
+// CheckSidecar is like Check for a file whose license header is held by its
+// sidecar file, with contents sidecar, nil if it does not exist. In Strict
+// mode, the sidecar file must match the one rendered by FixSidecar.
+func (p *Processor) CheckSidecar(name string, sidecar []byte) (CheckResult, error) {
+	l, data, err := p.fileLicense(name)
+	if err != nil || l == nil {
+		return CheckResult{Status: StatusOK}, err
+	}
+	if !HasLicense(sidecar) {
+		return CheckResult{Status: StatusMissing, Reason: "missing license sidecar file"}, nil
+	}
+	if p.Strict {
+		return checkHeader(sidecarStyles, name+SidecarSuffix, sidecar, sidecarTemplate, data)
+	}
+	id := IdentifyText(string(sidecar))
+	if !p.Foreign {
+		return CheckResult{Status: StatusOK, License: id.License}, nil
+	}
+	switch {
+	case !id.Identified():
+		return CheckResult{
+			Status: StatusMismatchedLicense,
+			Reason: fmt.Sprintf("found unrecognized license in sidecar file, want %s", data.SPDXID),
+		}, nil
+	case id.License != SPDXLicense(data.SPDXID):
+		return CheckResult{
+			Status:  StatusMismatchedLicense,
+			Reason:  fmt.Sprintf("found %s license in sidecar file, want %s", id.License, data.SPDXID),
+			License: id.License,
+		}, nil
+	}
+	return CheckResult{Status: StatusOK, License: id.License}, nil
+}
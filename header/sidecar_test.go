@@ -0,0 +1,144 @@
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package header
+
+import (
+	"testing"
+	"text/template"
+)
+
+func TestNeedsSidecar(t *testing.T) {
+	mit := &License{Template: template.Must(template.New("").Parse(tmplMIT))}
+	p := &Processor{
+		License: mit,
+		Rules:   []Rule{{Patterns: []string{"third_party/**"}, Skip: true}},
+	}
+	tests := []struct {
+		name    string
+		content string
+		want    bool
+	}{
+		{"main.go", "package main\n", false},
+		{"logo.png", "\x89PNG\x00", true},
+		{"data.json", "{}\n", true},
+		{"font.go", "\x00\x01", true},
+		{"empty.bin", "", false},
+		{"logo.png.license", "SPDX-License-Identifier: MIT\n", false},
+		{"third_party/logo.png", "\x89PNG\x00", false},
+	}
+
+	for _, tt := range tests {
+		if got := p.NeedsSidecar(tt.name, []byte(tt.content)); got != tt.want {
+			t.Errorf("NeedsSidecar(%q) = %t, want %t", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFixSidecar(t *testing.T) {
+	l, err := NewLicense("MIT", "", SPDXOff, LicenseData{Year: "2022", Holder: "H"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sidecar := "SPDX-FileCopyrightText: 2020 H\n\nSPDX-License-Identifier: MIT\n"
+	tests := []struct {
+		description string
+		p           Processor
+		sidecar     []byte
+		wantAction  Action
+		want        string
+		wantNil     bool // want a nil Content
+	}{
+		{"new sidecar", Processor{}, nil, Added, "SPDX-FileCopyrightText: 2022 H\n\nSPDX-License-Identifier: MIT\n", false},
+		{"sidecar without tags", Processor{}, []byte("Logo of the project.\n"), Added, "SPDX-FileCopyrightText: 2022 H\n\nSPDX-License-Identifier: MIT\n\nLogo of the project.\n", false},
+		{"existing sidecar", Processor{}, []byte(sidecar), NoChange, "", false},
+		{"update", Processor{Update: true}, []byte(sidecar), Updated, "SPDX-FileCopyrightText: 2020-2022 H\n\nSPDX-License-Identifier: MIT\n", false},
+		{"replace", Processor{Replace: true}, []byte("SPDX-FileCopyrightText: 2020 H\n\nSPDX-License-Identifier: Apache-2.0\n"), Replaced, "SPDX-FileCopyrightText: 2022 H\n\nSPDX-License-Identifier: MIT\n", false},
+		{"remove", Processor{Remove: true}, []byte(sidecar), Removed, "", true},
+		{"remove missing", Processor{Remove: true}, nil, NoChange, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			p := tt.p
+			p.License = l
+			c, err := p.FixSidecar("logo.png", tt.sidecar)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c == nil {
+				if tt.wantAction != NoChange {
+					t.Fatalf("FixSidecar returned no change, want %v", tt.wantAction)
+				}
+				return
+			}
+			if c.Action != tt.wantAction {
+				t.Errorf("FixSidecar returned action %v, want %v", c.Action, tt.wantAction)
+			}
+			if tt.wantNil {
+				if c.Content != nil {
+					t.Errorf("FixSidecar returned %q, want nil", c.Content)
+				}
+				return
+			}
+			if string(c.Content) != tt.want {
+				t.Errorf("FixSidecar returned %q, want %q", c.Content, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckSidecar(t *testing.T) {
+	l, err := NewLicense("MIT", "", SPDXOff, LicenseData{Year: "2022", Holder: "H"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		description string
+		p           Processor
+		sidecar     string
+		want        CheckStatus
+	}{
+		{"missing", Processor{}, "", StatusMissing},
+		{"present", Processor{}, "SPDX-License-Identifier: Apache-2.0\n", StatusOK},
+		{"strict", Processor{Strict: true}, "SPDX-FileCopyrightText: 2022 H\n\nSPDX-License-Identifier: MIT\n", StatusOK},
+		{"strict holder", Processor{Strict: true}, "SPDX-FileCopyrightText: 2022 Other\n\nSPDX-License-Identifier: MIT\n", StatusMismatchedHolder},
+		{"foreign", Processor{Foreign: true}, "SPDX-FileCopyrightText: 2022 Other\nSPDX-License-Identifier: MIT\n", StatusOK},
+		{"foreign license", Processor{Foreign: true}, "SPDX-License-Identifier: Apache-2.0\n", StatusMismatchedLicense},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			p := tt.p
+			p.License = l
+			var b []byte
+			if tt.sidecar != "" {
+				b = []byte(tt.sidecar)
+			}
+			res, err := p.CheckSidecar("logo.png", b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.Status != tt.want {
+				t.Errorf("CheckSidecar returned %+v, want status %v", res, tt.want)
+			}
+		})
+	}
+}
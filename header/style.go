@@ -0,0 +1,208 @@
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package header
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/template"
+
+	doublestar "github.com/bmatcuk/doublestar/v4"
+)
+
+// CommentStyle defines the prefixes used to turn a license into a comment:
+// an optional top line, a prefix for each line of the license and an
+// optional bottom line.
+type CommentStyle struct {
+	Top, Mid, Bot string
+}
+
+var (
+	styleBlock     = CommentStyle{"/*", " * ", " */"}
+	styleJSDoc     = CommentStyle{"/**", " * ", " */"}
+	styleSlashes   = CommentStyle{"", "// ", ""}
+	styleHash      = CommentStyle{"", "# ", ""}
+	styleSemicolon = CommentStyle{"", ";; ", ""}
+	stylePercent   = CommentStyle{"", "% ", ""}
+	styleDashes    = CommentStyle{"", "-- ", ""}
+	styleXML       = CommentStyle{"<!--", " ", "-->"}
+	styleOCaml     = CommentStyle{"(**", "   ", "*)"}
+)
+
+// StyleRule associates a comment style with the files it applies to. Names
+// are matched case insensitively.
+type StyleRule struct {
+	Style      CommentStyle
+	Extensions []string // file extensions, such as ".go"
+	Filenames  []string // exact file names, such as "Dockerfile"
+	Patterns   []string // doublestar patterns, matched against the file name unless they contain a slash
+}
+
+// matches reports whether the rule applies to the file at the lower case
+// path with base name base. The names of the rule must be lower case.
+func (r *StyleRule) matches(path, base string) bool {
+	for _, n := range r.Filenames {
+		if base == n {
+			return true
+		}
+	}
+	for _, p := range r.Patterns {
+		name := base
+		if strings.Contains(p, "/") {
+			name = filepath.ToSlash(path)
+		}
+		// ignore error, since patterns are validated when registered
+		if match, _ := doublestar.Match(p, name); match {
+			return true
+		}
+	}
+	ext := filepath.Ext(base)
+	for _, e := range r.Extensions {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
+
+// StyleRegistry maps files to the comment style of their type. Rules
+// registered last take precedence. The zero value is an empty registry;
+// DefaultStyles returns one holding the built-in styles. It is safe for
+// concurrent use.
+type StyleRegistry struct {
+	mu    sync.RWMutex
+	rules []StyleRule
+	known []knownHeader // headers of the built-in templates in all styles, built on first use
+}
+
+// Register adds a rule to the registry, overriding the existing rules for
+// the same files.
+func (r *StyleRegistry) Register(rule StyleRule) error {
+	if len(rule.Extensions)+len(rule.Filenames)+len(rule.Patterns) == 0 {
+		return fmt.Errorf("comment style %+v does not apply to any file", rule.Style)
+	}
+	if rule.Style.Top == "" && strings.TrimSpace(rule.Style.Mid) == "" {
+		return fmt.Errorf("comment style %+v has neither a top nor a line prefix", rule.Style)
+	}
+	rule.Extensions = lowerAll(rule.Extensions)
+	for i, e := range rule.Extensions {
+		if !strings.HasPrefix(e, ".") {
+			rule.Extensions[i] = "." + e
+		}
+	}
+	rule.Filenames = lowerAll(rule.Filenames)
+	rule.Patterns = lowerAll(rule.Patterns)
+	for _, p := range rule.Patterns {
+		if !doublestar.ValidatePattern(p) {
+			return fmt.Errorf("comment style pattern %q is not valid", p)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rules = append(r.rules, rule)
+	r.known = nil
+	return nil
+}
+
+// Lookup returns the comment style for the file type specified by path, or
+// false if the file type is unknown.
+func (r *StyleRegistry) Lookup(path string) (CommentStyle, bool) {
+	path = strings.ToLower(path)
+	base := filepath.Base(path)
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for i := len(r.rules) - 1; i >= 0; i-- {
+		if r.rules[i].matches(path, base) {
+			return r.rules[i].Style, true
+		}
+	}
+	return CommentStyle{}, false
+}
+
+// Styles returns the distinct comment styles of the registry.
+func (r *StyleRegistry) Styles() []CommentStyle {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var styles []CommentStyle
+	seen := make(map[CommentStyle]bool)
+	for i := len(r.rules) - 1; i >= 0; i-- {
+		if s := r.rules[i].Style; !seen[s] {
+			seen[s] = true
+			styles = append(styles, s)
+		}
+	}
+	return styles
+}
+
+// Render populates the provided license template with data, and returns it
+// with the proper prefix for the file type specified by path. The file does
+// not need to actually exist, only its name is used to determine the prefix.
+// It returns nil if the file type is unknown.
+func (r *StyleRegistry) Render(path string, tmpl *template.Template, data LicenseData) ([]byte, error) {
+	s, ok := r.Lookup(path)
+	if !ok {
+		return nil, nil
+	}
+	return ExecuteTemplate(tmpl, data, s.Top, s.Mid, s.Bot)
+}
+
+func lowerAll(s []string) []string {
+	out := make([]string, len(s))
+	for i, v := range s {
+		out[i] = strings.ToLower(v)
+	}
+	return out
+}
+
+// builtinStyles are the comment styles of the file types supported out of
+// the box.
+var builtinStyles = []StyleRule{
+	{Style: styleHash, Filenames: []string{"cmakelists.txt"}, Patterns: []string{"*.cmake.in"}, Extensions: []string{".cmake"}},
+	{Style: styleOCaml, Extensions: []string{".ml", ".mli", ".mll", ".mly"}},
+	{Style: styleSlashes, Extensions: []string{".php"}},
+	{Style: styleXML, Extensions: []string{".html", ".xml", ".vue", ".wxi", ".wxl", ".wxs"}},
+	{Style: styleDashes, Extensions: []string{".hs", ".sql", ".sdl"}},
+	{Style: stylePercent, Extensions: []string{".erl"}},
+	{Style: styleSemicolon, Extensions: []string{".el", ".lisp"}},
+	{Style: styleHash, Extensions: []string{".py", ".sh", ".yaml", ".yml", ".dockerfile", ".rb", ".tcl", ".bzl", ".pl"}, Filenames: []string{"dockerfile", "gemfile"}},
+	{Style: styleSlashes, Extensions: []string{".cc", ".cpp", ".cs", ".go", ".hcl", ".hh", ".hpp", ".m", ".mm", ".proto", ".rs", ".swift", ".dart", ".groovy", ".v", ".sv"}},
+	{Style: styleJSDoc, Extensions: []string{".js", ".mjs", ".cjs", ".jsx", ".tsx", ".css", ".scss", ".sass", ".tf", ".ts"}},
+	{Style: styleBlock, Extensions: []string{".c", ".h", ".gv", ".java", ".scala", ".kt", ".kts"}},
+}
+
+// defaultStyles is the registry used when no other is configured. It must
+// not be modified.
+var defaultStyles = DefaultStyles()
+
+// DefaultStyles returns a new registry holding the built-in comment styles,
+// which can be extended or overridden by registering more rules.
+func DefaultStyles() *StyleRegistry {
+	return &StyleRegistry{rules: append([]StyleRule(nil), builtinStyles...)}
+}
+
+// Render is like StyleRegistry.Render with the built-in comment styles.
+func Render(path string, tmpl *template.Template, data LicenseData) ([]byte, error) {
+	return defaultStyles.Render(path, tmpl, data)
+}
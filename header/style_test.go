@@ -0,0 +1,103 @@
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package header
+
+import (
+	"testing"
+)
+
+func TestStyleRegistry(t *testing.T) {
+	lua := CommentStyle{"", "-- ", ""}
+	ps := CommentStyle{"<#", "", "#>"}
+	r := DefaultStyles()
+	for _, rule := range []StyleRule{
+		{Style: lua, Extensions: []string{"lua", ".JL"}},
+		{Style: styleHash, Filenames: []string{"Makefile"}},
+		{Style: ps, Patterns: []string{"*.ps1", "scripts/**/*.psm1"}},
+		{Style: styleBlock, Extensions: []string{".go"}},
+	} {
+		if err := r.Register(rule); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		path   string
+		want   CommentStyle
+		wantOK bool
+	}{
+		{"f.unknown", CommentStyle{}, false},
+		{"f.c", styleBlock, true},
+		{"f.lua", lua, true},
+		{"F.JL", lua, true},
+		{"a/Makefile", styleHash, true},
+		{"a/makefile.am", CommentStyle{}, false},
+		{"a/f.ps1", ps, true},
+		{"scripts/a/f.psm1", ps, true},
+		{"other/f.psm1", CommentStyle{}, false},
+		{"dockerfile", styleHash, true},
+		{"f.cmake.in", styleHash, true},
+
+		// registered styles override the built-in ones
+		{"f.go", styleBlock, true},
+	}
+
+	for _, tt := range tests {
+		got, ok := r.Lookup(tt.path)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("Lookup(%q) returned (%+v, %t), want (%+v, %t)", tt.path, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestStyleRegistryErrors(t *testing.T) {
+	tests := []struct {
+		description string
+		rule        StyleRule
+	}{
+		{"no files", StyleRule{Style: styleHash}},
+		{"no prefix", StyleRule{Style: CommentStyle{"", " ", ""}, Extensions: []string{".x"}}},
+		{"invalid pattern", StyleRule{Style: styleHash, Patterns: []string{"[x"}}},
+	}
+
+	for _, tt := range tests {
+		var r StyleRegistry
+		if err := r.Register(tt.rule); err == nil {
+			t.Errorf("Register with %s returned no error", tt.description)
+		}
+	}
+}
+
+func TestCommentStyles(t *testing.T) {
+	styles := DefaultStyles().Styles()
+	seen := make(map[CommentStyle]bool)
+	for _, s := range styles {
+		if seen[s] {
+			t.Errorf("Styles() returned %+v more than once", s)
+		}
+		seen[s] = true
+	}
+	for _, s := range []CommentStyle{styleBlock, styleJSDoc, styleSlashes, styleHash, styleSemicolon, stylePercent, styleDashes, styleXML, styleOCaml} {
+		if !seen[s] {
+			t.Errorf("Styles() does not include %+v", s)
+		}
+	}
+}
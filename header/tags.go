@@ -0,0 +1,105 @@
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package header
+
+import (
+	"bufio"
+	"bytes"
+	"regexp"
+	"strings"
+)
+
+// Tags is the copyright and licensing information of a file, in the sense
+// of the REUSE specification, see https://reuse.software/spec/.
+type Tags struct {
+	Copyrights []string // copyright notices, without any SPDX-FileCopyrightText prefix
+	Licenses   []string // SPDX license expressions, as written
+}
+
+var (
+	licenseTagRE   = regexp.MustCompile(`SPDX-License-Identifier:\s*(.*)`)
+	copyrightTagRE = regexp.MustCompile(`(?i)SPDX-(?:File|Snippet)CopyrightText:\s*|copyright\s*(?:\(c\)|©)|copyright\s+[0-9]{4}|©\s*[0-9]{4}`)
+
+	// comment closers and quotes ending tag lines
+	tagSuffixes = []string{"*/", "-->", "*)", "-}", `"""`, "'''", `",`, `"`, "'"}
+)
+
+// FindTags returns the SPDX-License-Identifier tags, SPDX-FileCopyrightText
+// tags and copyright notices found in the contents b of a file, ignoring the
+// lines between REUSE-IgnoreStart and REUSE-IgnoreEnd markers. Binary files
+// have no tags.
+func FindTags(b []byte) Tags {
+	var tags Tags
+	if isBinary(b) {
+		return tags
+	}
+	ignore := false
+	s := bufio.NewScanner(bytes.NewReader(b))
+	s.Buffer(nil, 1<<20)
+	for s.Scan() {
+		line := s.Text()
+		switch {
+		case strings.Contains(line, "REUSE-IgnoreStart"):
+			ignore = true
+			continue
+		case strings.Contains(line, "REUSE-IgnoreEnd"):
+			ignore = false
+			continue
+		case ignore:
+			continue
+		}
+		if m := licenseTagRE.FindStringSubmatch(line); m != nil {
+			if expr := trimTag(m[1]); expr != "" {
+				tags.Licenses = append(tags.Licenses, expr)
+			}
+			continue
+		}
+		if loc := copyrightTagRE.FindStringIndex(line); loc != nil {
+			notice := line[loc[0]:]
+			if strings.HasPrefix(strings.ToUpper(notice), "SPDX-") {
+				notice = line[loc[1]:]
+			}
+			if notice = trimTag(notice); notice != "" {
+				tags.Copyrights = append(tags.Copyrights, notice)
+			}
+		}
+	}
+	return tags
+}
+
+// trimTag returns the value of a tag without surrounding whitespace and
+// comment closers.
+func trimTag(v string) string {
+	v = strings.TrimSpace(v)
+	for _, s := range tagSuffixes {
+		v = strings.TrimSpace(strings.TrimSuffix(v, s))
+	}
+	return v
+}
+
+// isBinary reports whether b looks like the contents of a binary file,
+// containing a NUL byte in its first 8000 bytes as git considers.
+func isBinary(b []byte) bool {
+	if len(b) > 8000 {
+		b = b[:8000]
+	}
+	return bytes.IndexByte(b, 0) >= 0
+}
@@ -0,0 +1,64 @@
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package header
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFindTags(t *testing.T) {
+	tests := []struct {
+		description string
+		content     string
+		want        Tags
+	}{
+		{"no tags", "package main\n", Tags{}},
+		{
+			"spdx tags",
+			"// SPDX-FileCopyrightText: 2022 Alice\n// SPDX-FileCopyrightText: 2023 Bob\n//\n// SPDX-License-Identifier: MIT OR Apache-2.0\n",
+			Tags{Copyrights: []string{"2022 Alice", "2023 Bob"}, Licenses: []string{"MIT OR Apache-2.0"}},
+		},
+		{
+			"copyright notices",
+			"# Copyright (c) 2022 Alice\n# © 2023 Bob\n# Copyright 2021 Carol. All rights reserved.\n",
+			Tags{Copyrights: []string{"Copyright (c) 2022 Alice", "© 2023 Bob", "Copyright 2021 Carol. All rights reserved."}},
+		},
+		{"comment closer", "/* SPDX-License-Identifier: MIT */\n", Tags{Licenses: []string{"MIT"}}},
+		{"html comment", "<!-- SPDX-FileCopyrightText: 2022 Alice -->\n", Tags{Copyrights: []string{"2022 Alice"}}},
+		{"snippet", "// SPDX-SnippetCopyrightText: 2022 Alice\n", Tags{Copyrights: []string{"2022 Alice"}}},
+		{
+			"ignored lines",
+			"// SPDX-License-Identifier: MIT\n// REUSE-IgnoreStart\nconst tag = \"SPDX-License-Identifier: GPL-2.0-only\"\n// REUSE-IgnoreEnd\n",
+			Tags{Licenses: []string{"MIT"}},
+		},
+		{"binary", "SPDX-License-Identifier: MIT\x00", Tags{}},
+		{"copyright sentence", "// The above copyright notice shall be included.\n", Tags{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			if got := FindTags([]byte(tt.content)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FindTags(%q) returned %+v, want %+v", tt.content, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package header
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"path"
+	"strings"
+	"text/template"
+)
+
+// licenseTexts holds the full texts of the licenses, named after their SPDX
+// identifier without any -only or -or-later suffix. Texts holding a copyright
+// notice are templates, rendered with the year and holder of LicenseData.
+//
+//go:embed texts/*.txt
+var licenseTexts embed.FS
+
+// SPDXLicense returns the SPDX identifier of the built-in license, legacy
+// license type or SPDX identifier id, such as "BSD-3-Clause" for the legacy
+// BSD-style notice "bsd". Unknown identifiers are returned unchanged.
+func SPDXLicense(id string) string {
+	if l, ok := LookupLicense(id); ok {
+		id = l
+	} else if canonical, _, ok := LookupSPDXID(id); ok {
+		id = canonical
+	}
+	if id == "bsd" {
+		// the legacy BSD-style notice usually refers to the BSD 3-Clause License
+		id = "BSD-3-Clause"
+	}
+	return id
+}
+
+// textName returns the name of the embedded text of the license id, and
+// false if there is none.
+func textName(id string) (string, bool) {
+	id = strings.TrimSuffix(strings.TrimSuffix(SPDXLicense(id), "-only"), "-or-later")
+	name := "texts/" + id + ".txt"
+	if _, err := licenseTexts.Open(name); err != nil {
+		return "", false
+	}
+	return name, true
+}
+
+// HasLicenseText reports whether the full text of the license id is
+// embedded, see LicenseText.
+func HasLicenseText(id string) bool {
+	_, ok := textName(id)
+	return ok
+}
+
+// LicenseText returns the full text of the license with the SPDX identifier
+// or legacy license type id, as found in a LICENSE file. The copyright notice
+// of the licenses that have one is rendered with the year and holder of
+// data. Licenses that differ only by their -only or -or-later suffix share
+// their text.
+func LicenseText(id string, data LicenseData) ([]byte, error) {
+	name, ok := textName(id)
+	if !ok {
+		return nil, fmt.Errorf("no embedded text for license %q", id)
+	}
+	b, err := licenseTexts.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	tmpl, err := template.New(name).Parse(string(b))
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// SameLicenseText reports whether the licenses a and b share their full
+// text, such as GPL-3.0-only and GPL-3.0-or-later, which can only be told
+// apart by the notices of the files they apply to.
+func SameLicenseText(a, b string) bool {
+	na, ok := textName(a)
+	if !ok {
+		return false
+	}
+	nb, ok := textName(b)
+	return ok && na == nb
+}
+
+// textReferences returns the embedded license texts for identification,
+// keyed by the license they are reported as. Texts shared by several
+// licenses are reported as the -only variant.
+func textReferences() map[string]string {
+	entries, err := licenseTexts.ReadDir("texts")
+	if err != nil {
+		panic(err)
+	}
+	refs := make(map[string]string)
+	for _, e := range entries {
+		id := strings.TrimSuffix(e.Name(), path.Ext(e.Name()))
+		if _, _, ok := LookupSPDXID(id + "-only"); ok {
+			id += "-only"
+		}
+		b, err := LicenseText(id, LicenseData{Year: "2000", Holder: "Holder"})
+		if err != nil {
+			panic(err)
+		}
+		refs[id] = string(b)
+	}
+	return refs
+}
+
+// ReferencesLicenseFile reports whether the header of the built-in license
+// refers to the LICENSE file of the project for the terms of the license,
+// such as the legacy BSD-style notice.
+func ReferencesLicenseFile(license string) bool {
+	id, ok := LookupLicense(license)
+	return ok && strings.Contains(licenseTemplate[id], "LICENSE file")
+}
@@ -0,0 +1,119 @@
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package header
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestLicenseText(t *testing.T) {
+	data := LicenseData{Year: "2022", Holder: "Jane Doe"}
+	tests := []struct {
+		id       string
+		wantLine string // a line of the text
+		wantErr  bool
+	}{
+		{"MIT", "Copyright (c) 2022 Jane Doe", false},
+		{"mit", "MIT License", false},
+		{"Apache-2.0", "                                 Apache License", false},
+		{"apache", "                           Version 2.0, January 2004", false},
+		{"bsd", "BSD 3-Clause License", false},
+		{"BSD-2-Clause", "Copyright (c) 2022, Jane Doe", false},
+		{"GPL-3.0-or-later", "                    GNU GENERAL PUBLIC LICENSE", false},
+		{"gpl-2.0-only", "                       Version 2, June 1991", false},
+		{"Zlib", "Copyright (c) 2022 Jane Doe", false},
+		{"CC0-1.0", "CC0 1.0 Universal", false},
+		{"AGPL-3.0-only", "                    GNU AFFERO GENERAL PUBLIC LICENSE", false},
+		{"EPL-2.0", "Eclipse Public License - v 2.0", false},
+		{"CC-BY-4.0", "Attribution 4.0 International", false},
+		{"CC-BY-SA-4.0", "", true},
+		{"unknown", "", true},
+	}
+
+	for _, tt := range tests {
+		b, err := LicenseText(tt.id, data)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("LicenseText(%q) returned no error", tt.id)
+			}
+			if HasLicenseText(tt.id) {
+				t.Errorf("HasLicenseText(%q) = true", tt.id)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("LicenseText(%q) returned error: %v", tt.id, err)
+			continue
+		}
+		if !strings.Contains(string(b), "\n"+tt.wantLine+"\n") && !strings.HasPrefix(string(b), tt.wantLine+"\n") {
+			t.Errorf("LicenseText(%q) does not contain line %q:\n%s", tt.id, tt.wantLine, b)
+		}
+		if !bytes.HasSuffix(b, []byte("\n")) {
+			t.Errorf("LicenseText(%q) does not end with a newline", tt.id)
+		}
+	}
+}
+
+func TestLicensesHaveText(t *testing.T) {
+	for _, l := range Licenses() {
+		if !HasLicenseText(l.ID) {
+			t.Errorf("license %s has no embedded text", l.ID)
+		}
+	}
+}
+
+func TestSameLicenseText(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"GPL-3.0-only", "GPL-3.0-or-later", true},
+		{"LGPL-2.1-or-later", "lgpl-2.1-only", true},
+		{"GPL-2.0-only", "GPL-3.0-only", false},
+		{"MIT", "mit", true},
+		{"AGPL-3.0-only", "GPL-3.0-only", false},
+		{"CC-BY-SA-4.0", "CC-BY-SA-4.0", false},
+	}
+
+	for _, tt := range tests {
+		if got := SameLicenseText(tt.a, tt.b); got != tt.want {
+			t.Errorf("SameLicenseText(%q, %q) = %t, want %t", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestIdentifyLicenseTexts(t *testing.T) {
+	for id, text := range textReferences() {
+		// another holder and year than the reference
+		b, err := LicenseText(id, LicenseData{Year: "2019-2022", Holder: "Someone Else"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := IdentifyText(string(b)); got.License != id || !got.Identified() {
+			t.Errorf("IdentifyText of the %s text returned %+v", id, got)
+		}
+		if len(text) == 0 {
+			t.Errorf("empty %s text", id)
+		}
+	}
+}
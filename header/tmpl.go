@@ -0,0 +1,277 @@
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package header
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+var (
+	licenseTemplate = map[string]string{
+		"0BSD":              tmpl0BSD,
+		"AGPL-3.0-only":     tmplAGPL3,
+		"AGPL-3.0-or-later": tmplAGPL3OrLater,
+		"Apache-2.0":        tmplApache,
+		"BSD-2-Clause":      tmplBSD2,
+		"BSD-3-Clause":      tmplBSD3,
+		"BSL-1.0":           tmplBSL,
+		"CC-BY-4.0":         tmplCCBY4,
+		"EPL-2.0":           tmplEPL2,
+		"GPL-2.0-only":      tmplGPL2,
+		"GPL-2.0-or-later":  tmplGPL2OrLater,
+		"GPL-3.0-only":      tmplGPL3,
+		"GPL-3.0-or-later":  tmplGPL3OrLater,
+		"ISC":               tmplISC,
+		"LGPL-2.1-only":     tmplLGPL21,
+		"LGPL-2.1-or-later": tmplLGPL21OrLater,
+		"LGPL-3.0-only":     tmplLGPL3,
+		"LGPL-3.0-or-later": tmplLGPL3OrLater,
+		"MIT":               tmplMIT,
+		"MPL-2.0":           tmplMPL,
+		"Unlicense":         tmplUnlicense,
+		"bsd":               tmplBSD, // short BSD-style notice, kept for backwards compatibility
+	}
+	// maintain backwards compatibility by mapping legacy Bhojpur License types to their
+	// SPDX equivalents.
+	legacyLicenseTypes = map[string]string{
+		"apache": "Apache-2.0",
+		"mit":    "MIT",
+		"mpl":    "MPL-2.0",
+	}
+)
+
+// LicenseData specifies the data used to fill out a Bhojpur License template.
+type LicenseData struct {
+	Year   string // Copyright year(s).
+	Holder string // Name of the copyright holder.
+	SPDXID string // SPDX Identifier
+}
+
+// SPDXMode selects whether SPDX identifiers are included in license headers.
+type SPDXMode string
+
+const (
+	SPDXOff   SPDXMode = ""      // license text only
+	SPDXOn    SPDXMode = "true"  // license text followed by an SPDX identifier
+	SPDXOnly  SPDXMode = "only"  // SPDX identifier only
+	SPDXReuse SPDXMode = "reuse" // REUSE SPDX-FileCopyrightText and SPDX-License-Identifier tags only
+)
+
+// tagsOnly reports whether the mode renders SPDX tags instead of the
+// template of the license.
+func (m SPDXMode) tagsOnly() bool {
+	return m == SPDXOnly || m == SPDXReuse
+}
+
+// tagsTemplate returns the template of the SPDX tags rendered in mode m.
+func (m SPDXMode) tagsTemplate() string {
+	if m == SPDXReuse {
+		return tmplReuse
+	}
+	return tmplSPDX
+}
+
+// Template returns the license template for the specified license, with the
+// SPDX identifier added according to spdx. License identifiers are matched
+// case insensitively. Other licenses must be valid SPDX license expressions:
+// single licenses without a built-in template are only supported with SPDX
+// identifiers, while other expressions, such as "Apache-2.0 OR MIT", get a
+// combined header describing the expression.
+func Template(license string, spdx SPDXMode) (string, error) {
+	_, t, err := licenseTemplateFor(license, spdx)
+	return t, err
+}
+
+// licenseTemplateFor returns the canonical identifier or expression of license along
+// with its template. Deprecated identifiers, such as GPL-3.0, are replaced
+// by their current form.
+func licenseTemplateFor(license string, spdx SPDXMode) (string, string, error) {
+	if id, ok := LookupLicense(license); ok {
+		switch {
+		case spdx.tagsOnly():
+			return id, spdx.tagsTemplate(), nil
+		case spdx == SPDXOn:
+			// append spdx headers to recognized license
+			return id, licenseTemplate[id] + spdxSuffix, nil
+		}
+		return id, licenseTemplate[id], nil
+	}
+	e, err := ParseExpression(license)
+	if err != nil {
+		return "", "", err
+	}
+	e.replaceDeprecated()
+	license = e.String()
+	if _, ok := licenseTemplate[license]; ok {
+		return licenseTemplateFor(license, spdx)
+	}
+	switch {
+	case spdx.tagsOnly():
+		return license, spdx.tagsTemplate(), nil
+	case e.simple() && spdx == SPDXOn:
+		// license without a template, but SPDX headers requested
+		return license, tmplSPDX, nil
+	case e.simple():
+		return "", "", fmt.Errorf("no built-in template for license %q. Include the '-s' flag to request SPDX style headers using this license", license)
+	}
+	return license, expressionTemplate(e), nil
+}
+
+// expressionTemplate returns the template of a combined header for the
+// license expression e, describing the expression in words and followed by
+// its SPDX identifier.
+func expressionTemplate(e *Expression) string {
+	desc := "This file is licensed under " + describe(e, true) + "."
+	var b strings.Builder
+	b.WriteString("Copyright {{.Year}} {{.Holder}}. All rights reserved.\n\n")
+	line := 0
+	for _, w := range strings.Fields(desc) {
+		if line > 0 && line+1+len(w) > 72 {
+			b.WriteString("\n")
+			line = 0
+		} else if line > 0 {
+			b.WriteString(" ")
+			line++
+		}
+		b.WriteString(w)
+		line += len(w)
+	}
+	b.WriteString(spdxSuffix)
+	return b.String()
+}
+
+// describe returns the license expression e in words, using the names of
+// the built-in licenses.
+func describe(e *Expression, top bool) string {
+	if e.Op == "" {
+		s := e.License
+		if name := licenseNames[e.License]; name != "" && e.License != "bsd" {
+			s = "the " + strings.TrimPrefix(name, "The ")
+		}
+		if e.OrLater {
+			s += " or any later version"
+		}
+		if e.Exception != "" {
+			s += " with " + e.Exception
+		}
+		return s
+	}
+	parts := make([]string, len(e.Operands))
+	for i, o := range e.Operands {
+		parts[i] = describe(o, false)
+	}
+	list := strings.Join(parts[:len(parts)-1], ", ")
+	if e.Op == "OR" {
+		s := "either " + list + " or " + parts[len(parts)-1]
+		if top {
+			s += ", at your option"
+		} else {
+			s = "(" + s + ")"
+		}
+		return s
+	}
+	if len(parts) == 2 {
+		return "both " + list + " and " + parts[1]
+	}
+	return "all of " + list + " and " + parts[len(parts)-1]
+}
+
+// ExecuteTemplate will execute a Bhojpur License template t with data d
+// and prefix the result with top, middle and bottom. The SPDX identifier of
+// legacy license types is written in its SPDX form, such as BSD-3-Clause for
+// "bsd".
+func ExecuteTemplate(t *template.Template, d LicenseData, top, mid, bot string) ([]byte, error) {
+	d.SPDXID = SPDXLicense(d.SPDXID)
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, d); err != nil {
+		return nil, err
+	}
+	var out bytes.Buffer
+	if top != "" {
+		fmt.Fprintln(&out, top)
+	}
+	s := bufio.NewScanner(&buf)
+	for s.Scan() {
+		fmt.Fprintln(&out, strings.TrimRightFunc(mid+s.Text(), unicode.IsSpace))
+	}
+	if bot != "" {
+		fmt.Fprintln(&out, bot)
+	}
+	fmt.Fprintln(&out)
+	return out.Bytes(), nil
+}
+
+const tmplApache = `Copyright {{.Year}} {{.Holder}}. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.`
+
+const tmplBSD = `Copyright (c) {{.Year}} {{.Holder}} All rights reserved.
+Use of this source code is governed by a BSD-style
+license that can be found in the LICENSE file.`
+
+const tmplMIT = `Copyright (c) {{.Year}} {{.Holder}}. All rights reserved.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+the Software, and to permit persons to whom the Software is furnished to do so,
+subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.`
+
+const tmplMPL = `This Source Code Form is subject to the terms of the Mozilla Public
+License, v. 2.0. If a copy of the MPL was not distributed with this
+file, You can obtain one at https://mozilla.org/MPL/2.0/.`
+
+const tmplSPDX = `{{ if and .Year .Holder }}Copyright {{.Year}} {{.Holder}}. All rights resevred.
+{{ end }}SPDX-License-Identifier: {{.SPDXID}}`
+
+// tmplReuse renders the tags of the REUSE specification, see
+// https://reuse.software/spec/.
+const tmplReuse = `SPDX-FileCopyrightText: {{.Year}} {{.Holder}}
+
+SPDX-License-Identifier: {{.SPDXID}}`
+
+const spdxSuffix = "\n\nSPDX-License-Identifier: {{.SPDXID}}"
@@ -18,11 +18,10 @@
 // OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
 // THE SOFTWARE.
 
-package main
+package header
 
 import (
 	"errors"
-	"os"
 	"testing"
 	"text/template"
 )
@@ -35,111 +34,169 @@ func init() {
 	template.Must(template.New("").Parse(tmplMPL))
 }
 
-func TestFetchTemplate(t *testing.T) {
+func TestTemplate(t *testing.T) {
 	tests := []struct {
 		description  string   // test case description
-		license      string   // license passed to fetchTemplate
-		templateFile string   // templatefile passed to fetchTemplate
-		spdx         spdxFlag // spdx value passed to fetchTemplate
+		license      string   // license passed to Template
+		spdx         SPDXMode // spdx value passed to Template
 		wantTemplate string   // expected returned template
 		wantErr      error    // expected returned error
 	}{
-		// custom template files
 		{
-			"non-existent template file",
-			"",
-			"/does/not/exist",
-			spdxOff,
+			"unknown license",
+			"unknown",
+			SPDXOff,
 			"",
-			os.ErrNotExist,
+			errors.New(`unknown license "unknown"`),
 		},
 		{
-			"custom template file",
+			"misspelled license",
+			"Apache2",
+			SPDXOn,
 			"",
-			"testdata/custom.tpl",
-			spdxOff,
-			"Copyright {{.Year}} {{.Holder}}\n\nCustom License Template\n",
-			nil,
+			errors.New(`unknown license "Apache2", did you mean "Apache-2.0"?`),
 		},
-
 		{
-			"unknown license",
-			"unknown",
-			"",
-			spdxOff,
+			"license without template",
+			"Zlib",
+			SPDXOff,
 			"",
-			errors.New(`unknown license: "unknown". Include the '-s' flag to request SPDX style headers using this license`),
+			errors.New(`no built-in template for license "Zlib". Include the '-s' flag to request SPDX style headers using this license`),
 		},
 
 		// pre-defined license templates, no SPDX
 		{
 			"apache license template",
 			"Apache-2.0",
-			"",
-			spdxOff,
+			SPDXOff,
 			tmplApache,
 			nil,
 		},
 		{
 			"mit license template",
 			"MIT",
-			"",
-			spdxOff,
+			SPDXOff,
 			tmplMIT,
 			nil,
 		},
 		{
 			"bsd license template",
 			"bsd",
-			"",
-			spdxOff,
+			SPDXOff,
 			tmplBSD,
 			nil,
 		},
 		{
 			"mpl license template",
 			"MPL-2.0",
-			"",
-			spdxOff,
+			SPDXOff,
 			tmplMPL,
 			nil,
 		},
+		{
+			"deprecated license identifier",
+			"GPL-3.0",
+			SPDXOff,
+			tmplGPL3,
+			nil,
+		},
+		{
+			"case insensitive license template",
+			"gpl-3.0-or-later",
+			SPDXOff,
+			tmplGPL3OrLater,
+			nil,
+		},
 
 		// SPDX variants
 		{
 			"apache license template with SPDX added",
 			"Apache-2.0",
-			"",
-			spdxOn,
+			SPDXOn,
 			tmplApache + spdxSuffix,
 			nil,
 		},
 		{
 			"apache license template with SPDX only",
 			"Apache-2.0",
-			"",
-			spdxOnly,
+			SPDXOnly,
 			tmplSPDX,
 			nil,
 		},
 		{
 			"unknown license with SPDX only",
 			"unknown",
+			SPDXOnly,
 			"",
-			spdxOnly,
+			errors.New(`unknown license "unknown"`),
+		},
+		{
+			"license without template with SPDX added",
+			"zlib",
+			SPDXOn,
 			tmplSPDX,
 			nil,
 		},
+		{
+			"license reference with SPDX only",
+			"LicenseRef-Proprietary",
+			SPDXOnly,
+			tmplSPDX,
+			nil,
+		},
+		{
+			"apache license template with REUSE tags",
+			"Apache-2.0",
+			SPDXReuse,
+			tmplReuse,
+			nil,
+		},
+		{
+			"license expression with REUSE tags",
+			"MIT OR Apache-2.0",
+			SPDXReuse,
+			tmplReuse,
+			nil,
+		},
+
+		// license expressions
+		{
+			"dual license",
+			"Apache-2.0 OR MIT",
+			SPDXOff,
+			"Copyright {{.Year}} {{.Holder}}. All rights reserved.\n\n" +
+				"This file is licensed under either the Apache License 2.0 or the MIT\n" +
+				"License, at your option.\n\n" +
+				"SPDX-License-Identifier: {{.SPDXID}}",
+			nil,
+		},
+		{
+			"license with exception",
+			"GPL-2.0-only WITH Classpath-exception-2.0",
+			SPDXOn,
+			"Copyright {{.Year}} {{.Holder}}. All rights reserved.\n\n" +
+				"This file is licensed under the GNU General Public License v2.0 only\n" +
+				"with Classpath-exception-2.0.\n\n" +
+				"SPDX-License-Identifier: {{.SPDXID}}",
+			nil,
+		},
+		{
+			"license expression with SPDX only",
+			"mit and zlib",
+			SPDXOnly,
+			"",
+			errors.New(`invalid license expression "mit and zlib": operator "and" must be upper case`),
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.description, func(t *testing.T) {
-			tpl, err := fetchTemplate(tt.license, tt.templateFile, tt.spdx)
+			tpl, err := Template(tt.license, tt.spdx)
 			if tt.wantErr != nil && (err == nil || (!errors.Is(err, tt.wantErr) && err.Error() != tt.wantErr.Error())) {
-				t.Fatalf("fetchTemplate(%q, %q) returned error: %#v, want %#v", tt.license, tt.templateFile, err, tt.wantErr)
+				t.Fatalf("Template(%q, %q) returned error: %#v, want %#v", tt.license, tt.spdx, err, tt.wantErr)
 			}
 			if tpl != tt.wantTemplate {
-				t.Errorf("fetchTemplate(%q, %q) returned template: %q, want %q", tt.license, tt.templateFile, tpl, tt.wantTemplate)
+				t.Errorf("Template(%q, %q) returned template: %q, want %q", tt.license, tt.spdx, tpl, tt.wantTemplate)
 			}
 		})
 	}
@@ -0,0 +1,85 @@
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package header
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"text/template"
+)
+
+// updateYear returns the contents b of the file at path with the copyright
+// year of its license header extended to the latest year of data.Year.
+// The bool result reports whether the contents were changed.
+func updateYear(styles *StyleRegistry, path string, b []byte, tmpl *template.Template, data LicenseData) ([]byte, bool, error) {
+	year, ok := lastYear(data.Year)
+	if !ok {
+		return nil, false, fmt.Errorf("copyright year %q does not contain a year", data.Year)
+	}
+	d := data
+	d.Year = yearSentinel
+	lic, err := styles.Render(path, tmpl, d)
+	if err != nil || lic == nil {
+		return b, false, err
+	}
+	re, err := cachedHeaderRegexp(lic)
+	if err != nil {
+		return b, false, err
+	}
+	i := re.SubexpIndex("year")
+	if i < 0 {
+		// the template does not include a year
+		return b, false, nil
+	}
+
+	off := len(hashBang(b))
+	m := re.FindSubmatchIndex(b[off:])
+	if m == nil {
+		return b, false, nil
+	}
+	start, end := off+m[2*i], off+m[2*i+1]
+	years, ok := extendYears(string(b[start:end]), year)
+	if !ok {
+		return b, false, nil
+	}
+	out := make([]byte, 0, len(b)+len(years))
+	out = append(out, b[:start]...)
+	out = append(out, years...)
+	out = append(out, b[end:]...)
+	return out, true, nil
+}
+
+var trailingRange = regexp.MustCompile(`-[ \t]*[0-9]{4}$`)
+
+// extendYears extends the copyright years in s, such as "2018" or
+// "2018-2020", to end with year. It returns false if s already covers year.
+func extendYears(s string, year int) (string, bool) {
+	last, ok := lastYear(s)
+	if !ok || last >= year {
+		return s, false
+	}
+	y := strconv.Itoa(year)
+	if trailingRange.MatchString(s) {
+		return s[:len(s)-len(y)] + y, true
+	}
+	return s + "-" + y, true
+}
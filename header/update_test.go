@@ -0,0 +1,87 @@
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package header
+
+import (
+	"testing"
+	"text/template"
+)
+
+func TestUpdateYear(t *testing.T) {
+	tmpl := template.Must(template.New("").Parse("Copyright {{.Year}} {{.Holder}}\n\nText"))
+	data := LicenseData{Holder: "H", Year: "2022"}
+
+	tests := []struct {
+		contents     string
+		wantContents string
+		wantUpdated  bool
+	}{
+		{"// Copyright 2019 H\n//\n// Text\n\ncontent", "// Copyright 2019-2022 H\n//\n// Text\n\ncontent", true},
+		{"// Copyright 2018-2021 H\n//\n// Text\n\ncontent", "// Copyright 2018-2022 H\n//\n// Text\n\ncontent", true},
+		{"// Copyright 2005-2008,2018 H\n//\n// Text\n", "// Copyright 2005-2008,2018-2022 H\n//\n// Text\n", true},
+		{"#!/bin/bash\n// Copyright 2019 H\n//\n// Text\n", "#!/bin/bash\n// Copyright 2019-2022 H\n//\n// Text\n", true},
+
+		// already up to date
+		{"// Copyright 2022 H\n//\n// Text\n", "// Copyright 2022 H\n//\n// Text\n", false},
+		{"// Copyright 2019-2022 H\n//\n// Text\n", "// Copyright 2019-2022 H\n//\n// Text\n", false},
+
+		// headers not rendered from the template are left untouched
+		{"// Copyright 2019 Other\n//\n// Text\n", "// Copyright 2019 Other\n//\n// Text\n", false},
+		{"// Copyright 2019 H\n//\n// Other\n", "// Copyright 2019 H\n//\n// Other\n", false},
+		{"content\n// Copyright 2019 H\n//\n// Text\n", "content\n// Copyright 2019 H\n//\n// Text\n", false},
+	}
+
+	for _, tt := range tests {
+		got, updated, err := updateYear(defaultStyles, "file.go", []byte(tt.contents), tmpl, data)
+		if err != nil {
+			t.Errorf("updateYear(%q) returned error: %v", tt.contents, err)
+		}
+		if updated != tt.wantUpdated {
+			t.Errorf("updateYear(%q) returned updated: %t, want %t", tt.contents, updated, tt.wantUpdated)
+		}
+		if string(got) != tt.wantContents {
+			t.Errorf("updateYear(%q) returned contents: %q, want %q", tt.contents, got, tt.wantContents)
+		}
+	}
+}
+
+func TestExtendYears(t *testing.T) {
+	tests := []struct {
+		years   string
+		year    int
+		want    string
+		wantExt bool
+	}{
+		{"2019", 2022, "2019-2022", true},
+		{"2018-2021", 2022, "2018-2022", true},
+		{"2018 - 2021", 2022, "2018 - 2022", true},
+		{"2005-2008,2018", 2022, "2005-2008,2018-2022", true},
+		{"2022", 2022, "2022", false},
+		{"2018-2023", 2022, "2018-2023", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := extendYears(tt.years, tt.year)
+		if got != tt.want || ok != tt.wantExt {
+			t.Errorf("extendYears(%q, %d) returned (%q, %t), want (%q, %t)", tt.years, tt.year, got, ok, tt.want, tt.wantExt)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/bhojpur/license/header"
+)
+
+// identification is the license identified in the header of a file.
+type identification struct {
+	Path       string  `json:"path"`
+	License    string  `json:"license,omitempty"` // empty if not identified
+	Closest    string  `json:"closest,omitempty"` // closest license if not identified
+	Confidence float64 `json:"confidence"`
+	Year       string  `json:"year,omitempty"`
+	Holder     string  `json:"holder,omitempty"`
+}
+
+// runIdentify implements the identify command, which prints the license
+// identified in the header of the given files, and of the files with a known
+// comment style in the given directories.
+func runIdentify(args []string) error {
+	fset := flag.NewFlagSet("identify", flag.ExitOnError)
+	format := fset.String("format", "text", "output format: text or json")
+	fset.Usage = func() {
+		fmt.Fprintf(fset.Output(), "Usage: license identify [flags] file [file ...]\n\nFlags:\n")
+		fset.PrintDefaults()
+	}
+	fset.Parse(args)
+	if fset.NArg() == 0 {
+		fset.Usage()
+		os.Exit(1)
+	}
+	if *format != "text" && *format != "json" {
+		return fmt.Errorf("-format %q is not one of text, json", *format)
+	}
+
+	var ids []identification
+	for _, arg := range fset.Args() {
+		paths, err := identifyPaths(arg)
+		if err != nil {
+			return err
+		}
+		for _, path := range paths {
+			b, err := ioutil.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			id := header.Identify(b)
+			if licenseFileRE.MatchString(filepath.Base(path)) {
+				// license files hold the text of the license itself
+				id = header.IdentifyText(string(b))
+			}
+			ids = append(ids, newIdentification(path, id))
+		}
+	}
+	if *format == "json" {
+		return writeJSON(os.Stdout, struct {
+			Files []identification `json:"files"`
+		}{ids})
+	}
+	return writeIdentifications(os.Stdout, ids)
+}
+
+// identifyPaths returns path if it is a file, or the license files and the
+// files with a known comment style under path if it is a directory, skipping
+// hidden directories.
+func identifyPaths(path string) ([]string, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !fi.IsDir() {
+		return []string{path}, nil
+	}
+	styles := header.DefaultStyles()
+	var paths []string
+	err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if p != path && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if _, ok := styles.Lookup(p); (ok || licenseFileRE.MatchString(d.Name())) && d.Type().IsRegular() {
+			paths = append(paths, p)
+		}
+		return nil
+	})
+	return paths, err
+}
+
+func newIdentification(path string, id header.Identification) identification {
+	res := identification{
+		Path:       filepath.ToSlash(path),
+		Confidence: roundConfidence(id.Confidence),
+		Year:       id.Year,
+		Holder:     id.Holder,
+	}
+	if id.Identified() {
+		res.License = id.License
+	} else {
+		res.Closest = id.License
+	}
+	return res
+}
+
+// writeIdentifications writes a table of the identified licenses to w.
+func writeIdentifications(w io.Writer, ids []identification) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "FILE\tLICENSE\tCONFIDENCE\tYEAR\tHOLDER")
+	for _, id := range ids {
+		license := id.License
+		switch {
+		case license == "" && id.Closest != "":
+			license = fmt.Sprintf("unknown (closest: %s)", id.Closest)
+		case license == "":
+			license = "none"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%.0f%%\t%s\t%s\n", id.Path, license, id.Confidence*100, dash(id.Year), dash(id.Holder))
+	}
+	return tw.Flush()
+}
+
+// roundConfidence rounds the confidence c down to three decimals, for
+// printing as JSON.
+func roundConfidence(c float64) float64 {
+	return float64(int(c*1000)) / 1000
+}
+
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
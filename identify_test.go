@@ -0,0 +1,79 @@
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/bhojpur/license/header"
+)
+
+func TestWriteIdentifications(t *testing.T) {
+	var buf bytes.Buffer
+	err := writeIdentifications(&buf, []identification{
+		newIdentification("a.go", header.Identification{License: "MIT", Confidence: 1, Year: "2022", Holder: "H"}),
+		newIdentification("b.go", header.Identification{License: "ISC", Confidence: 0.4567}),
+		newIdentification("c.go", header.Identification{}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "FILE  LICENSE                 CONFIDENCE  YEAR  HOLDER\n" +
+		"a.go  MIT                     100%        2022  H\n" +
+		"b.go  unknown (closest: ISC)  46%         -     -\n" +
+		"c.go  none                    0%          -     -\n"
+	if got := buf.String(); got != want {
+		t.Errorf("writeIdentifications wrote:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestIdentifyPaths(t *testing.T) {
+	tmp := tempDir(t)
+	defer os.RemoveAll(tmp)
+	for _, name := range []string{"LICENSE", "a.go", "sub/b.c", "image.png", ".git/c.go"} {
+		path := filepath.Join(tmp, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := identifyPaths(tmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{filepath.Join(tmp, "LICENSE"), filepath.Join(tmp, "a.go"), filepath.Join(tmp, "sub", "b.c")}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("identifyPaths returned %q, want %q", got, want)
+	}
+	if got, err := identifyPaths(filepath.Join(tmp, "image.png")); err != nil || len(got) != 1 {
+		t.Errorf("identifyPaths of a file returned %q, %v, want the file", got, err)
+	}
+	if _, err := identifyPaths(filepath.Join(tmp, "missing")); err == nil {
+		t.Error("identifyPaths of a missing file returned no error")
+	}
+}
@@ -0,0 +1,320 @@
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/bhojpur/license/header"
+)
+
+// licenseFileRE matches the names of the license files of a project.
+var licenseFileRE = regexp.MustCompile(`^(?:LICEN[CS]E|COPYING)(?:[-.].*)?$`)
+
+// initFile is a project level file written by the init command.
+type initFile struct {
+	path    string // slash separated path relative to the project directory
+	license string // license of the text, or "" for the NOTICE file
+	content []byte
+}
+
+// runInit implements the init command, which writes the LICENSE file of a
+// project, its NOTICE file and optionally its LICENSES directory, for the
+// license and copyright holder selected by the flags and the configuration.
+func runInit(args []string) error {
+	cfg, err := loadProjectConfig(".")
+	if err != nil {
+		return err
+	}
+	fset := flag.NewFlagSet("init", flag.ExitOnError)
+	l := fset.String("l", *license, "license type: an SPDX identifier or expression")
+	c := fset.String("c", *holder, "copyright holder")
+	y := fset.String("y", *year, "copyright year(s)")
+	name := fset.String("name", "", "project name for the NOTICE file (default: the name of the directory)")
+	notice := fset.Bool("notice", false, "write an Apache-style NOTICE file (default: true for the Apache-2.0 license)")
+	reuse := fset.Bool("reuse", spdx == spdxReuse, "also write the license texts to the LICENSES directory, as required by the REUSE specification")
+	force := fset.Bool("force", false, "overwrite existing files that do not match the license")
+	check := fset.Bool("check", false, "check that the files exist and match the license instead of writing them")
+	fset.Usage = func() {
+		fmt.Fprintf(fset.Output(), "Usage: license init [flags] [directory]\n\nFlags:\n")
+		fset.PrintDefaults()
+	}
+	fset.Parse(args)
+	if fset.NArg() > 1 {
+		fset.Usage()
+		os.Exit(1)
+	}
+	dir := cfg.dir
+	if fset.NArg() == 1 {
+		dir = fset.Arg(0)
+	}
+	if *name == "" {
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return err
+		}
+		*name = filepath.Base(abs)
+	}
+
+	lic, err := header.NewLicense(*l, "", header.SPDXOn, header.LicenseData{Year: *y, Holder: *c})
+	if err != nil {
+		return err
+	}
+	noticeSet := false
+	fset.Visit(func(f *flag.Flag) { noticeSet = noticeSet || f.Name == "notice" })
+	if !noticeSet {
+		*notice = needsNotice(lic.Data.SPDXID)
+	}
+	files, err := initFiles(lic.Data, *name, *notice, *reuse)
+	if err != nil {
+		return err
+	}
+	if *check {
+		return checkInitFiles(os.Stdout, dir, files)
+	}
+	return writeInitFiles(dir, files, *force)
+}
+
+// initFiles returns the files of a project licensed as described by data:
+// the texts of its licenses, in a LICENSE file or in one LICENSE-<id> file
+// per license of an expression, along with a NOTICE file and the LICENSES
+// directory if requested.
+func initFiles(data header.LicenseData, name string, notice, reuse bool) ([]initFile, error) {
+	ids := []string{data.SPDXID}
+	if _, ok := header.LookupLicense(data.SPDXID); !ok {
+		e, err := header.ParseExpression(data.SPDXID)
+		if err != nil {
+			return nil, err
+		}
+		if ex := e.Exceptions(); len(ex) > 0 {
+			return nil, fmt.Errorf("no embedded text for exception %q", ex[0])
+		}
+		ids = e.Licenses()
+	}
+
+	var files, texts []initFile
+	for _, id := range ids {
+		text, err := header.LicenseText(id, data)
+		if err != nil {
+			return nil, err
+		}
+		path := "LICENSE"
+		if len(ids) > 1 {
+			path = "LICENSE-" + id
+		}
+		files = append(files, initFile{path: path, license: id, content: text})
+		if reuse {
+			if _, _, ok := header.LookupSPDXID(id); !ok {
+				return nil, fmt.Errorf("license %q has no SPDX identifier to name its text in LICENSES", id)
+			}
+			texts = append(texts, initFile{path: "LICENSES/" + id + ".txt", license: id, content: text})
+		}
+	}
+	if notice {
+		var refs []string
+		for _, f := range files {
+			refs = append(refs, f.path)
+		}
+		files = append(files, initFile{path: "NOTICE", content: noticeText(name, data, refs)})
+	}
+	return append(files, texts...), nil
+}
+
+// needsNotice reports whether the license expression includes the Apache
+// License 2.0, whose section 4(d) makes a NOTICE file customary.
+func needsNotice(expr string) bool {
+	for _, id := range licenseIDs([]string{expr}) {
+		if id == "Apache-2.0" {
+			return true
+		}
+	}
+	return false
+}
+
+// noticeText returns the contents of an Apache-style NOTICE file for the
+// project name, licensed as described by data under the terms found in the
+// license files refs.
+func noticeText(name string, data header.LicenseData, refs []string) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s\nCopyright %s %s\n\n", name, data.Year, data.Holder)
+	fmt.Fprintf(&buf, "This product includes software developed by %s,\n", data.Holder)
+	fmt.Fprintf(&buf, "licensed under %s.\n", data.SPDXID)
+	if len(refs) == 1 {
+		fmt.Fprintf(&buf, "See the %s file for the terms of the license.\n", refs[0])
+	} else {
+		fmt.Fprintf(&buf, "See the %s and %s files for the terms of the licenses.\n", strings.Join(refs[:len(refs)-1], ", "), refs[len(refs)-1])
+	}
+	return buf.Bytes()
+}
+
+// matches reports whether the contents b of an existing file match the
+// file: license texts must be identified as the same license, while any
+// NOTICE file matches.
+func (f *initFile) matches(b []byte) bool {
+	if f.license == "" {
+		return true
+	}
+	return licenseTextMatches(f.license, b)
+}
+
+// licenseTextMatches reports whether the text b is identified as the text of
+// the license, or of a license sharing its text.
+func licenseTextMatches(license string, b []byte) bool {
+	id := header.IdentifyText(string(b))
+	return id.Identified() && (id.License == license || header.SameLicenseText(id.License, license))
+}
+
+// writeInitFiles writes the files to the project directory dir. Existing
+// files are kept if they match, and only overwritten if force is set.
+func writeInitFiles(dir string, files []initFile, force bool) error {
+	var failed []string
+	for _, f := range files {
+		path := filepath.Join(dir, filepath.FromSlash(f.path))
+		b, err := ioutil.ReadFile(path)
+		switch {
+		case err == nil && f.matches(b):
+			continue
+		case err == nil && !force:
+			log.Printf("%s: exists and does not match the %s license, use -force to overwrite it", path, f.license)
+			failed = append(failed, path)
+			continue
+		case err == nil:
+			err = writeFile(path, f.content)
+		case os.IsNotExist(err):
+			if err = os.MkdirAll(filepath.Dir(path), 0755); err == nil {
+				err = createFile(path, f.content)
+			}
+		}
+		if err != nil {
+			log.Printf("%s: %v", path, err)
+			failed = append(failed, path)
+			continue
+		}
+		if *verbose {
+			log.Printf("%s written", path)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%d of %d files not written", len(failed), len(files))
+	}
+	return nil
+}
+
+// checkInitFiles checks that the files exist in the project directory dir
+// and match, and prints the ones that do not to w.
+func checkInitFiles(w io.Writer, dir string, files []initFile) error {
+	failed := 0
+	for _, f := range files {
+		path := filepath.Join(dir, filepath.FromSlash(f.path))
+		if res := checkInitFile(path, f); res.Status != header.StatusOK {
+			fmt.Fprintf(w, "%s: %s: %s\n", path, res.Status, res.Reason)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return errors.New("the license files of the project are missing or do not match")
+	}
+	return nil
+}
+
+// checkInitFile checks that the file at path exists and matches f.
+func checkInitFile(path string, f initFile) header.CheckResult {
+	b, err := ioutil.ReadFile(path)
+	switch {
+	case os.IsNotExist(err):
+		return header.CheckResult{Status: header.StatusMissing, Reason: "missing file"}
+	case err != nil:
+		return header.CheckResult{Status: header.StatusError, Reason: err.Error()}
+	case !f.matches(b):
+		id := header.IdentifyText(string(b))
+		reason := fmt.Sprintf("found unrecognized license text, want %s", f.license)
+		if id.Identified() {
+			reason = fmt.Sprintf("found %s license text, want %s", id.License, f.license)
+		}
+		return header.CheckResult{Status: header.StatusMismatchedLicense, Reason: reason, License: id.License}
+	}
+	return header.CheckResult{Status: header.StatusOK, License: f.license}
+}
+
+// checkProjectLicense checks the license files of the project containing the
+// directory dir, licensed as described by data: the LICENSE file, or the
+// LICENSE-<id> files of an expression, found in dir or its parents up to the
+// root of the git repository must hold the text of their license. The files
+// are required for licenses whose headers refer to the LICENSE file, and
+// otherwise only checked if they exist with -strict, which verifies that the
+// project is licensed as selected. The results are added to report.
+func checkProjectLicense(dir string, data header.LicenseData, report *checkReport) error {
+	if !*strict && !header.ReferencesLicenseFile(data.SPDXID) {
+		return nil
+	}
+	files, err := initFiles(data, "", false, false)
+	if err != nil {
+		// without an embedded text, there is nothing to compare the files to
+		return nil
+	}
+	var failed error
+	for _, f := range files {
+		path, err := findProjectFile(dir, f.path)
+		if err != nil {
+			return err
+		}
+		if path == "" {
+			if !header.ReferencesLicenseFile(data.SPDXID) {
+				continue
+			}
+			path = filepath.Join(dir, f.path)
+		}
+		res := checkInitFile(path, f)
+		report.add(path, res)
+		if res.Status == header.StatusOK {
+			continue
+		}
+		if *format == "" {
+			fmt.Printf("%s: %s: %s\n", path, res.Status, res.Reason)
+		}
+		failed = errors.New(res.Reason)
+	}
+	return failed
+}
+
+// projectDirs returns the directories of the patterns, without duplicates:
+// the patterns themselves for directories, and the directories holding the
+// others.
+func projectDirs(patterns []string) []string {
+	var dirs []string
+	for _, p := range patterns {
+		if fi, err := os.Stat(p); err != nil || !fi.IsDir() {
+			p = filepath.Dir(p)
+		}
+		dirs = appendUnique(dirs, filepath.Clean(p))
+	}
+	return dirs
+}
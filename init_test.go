@@ -0,0 +1,167 @@
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bhojpur/license/header"
+)
+
+func TestInitFiles(t *testing.T) {
+	tests := []struct {
+		description string
+		license     string
+		notice      bool
+		reuse       bool
+		want        []string // paths of the files
+		wantErr     string
+	}{
+		{"single license", "mit", false, false, []string{"LICENSE"}, ""},
+		{"notice", "Apache-2.0", true, false, []string{"LICENSE", "NOTICE"}, ""},
+		{"expression", "MIT OR Apache-2.0", true, false, []string{"LICENSE-MIT", "LICENSE-Apache-2.0", "NOTICE"}, ""},
+		{"reuse", "GPL-3.0-or-later", false, true, []string{"LICENSE", "LICENSES/GPL-3.0-or-later.txt"}, ""},
+		{"legacy license type", "bsd", false, false, []string{"LICENSE"}, ""},
+		{"legacy license type in LICENSES", "bsd", false, true, nil, `license "bsd" has no SPDX identifier to name its text in LICENSES`},
+		{"exception", "GPL-2.0-only WITH Classpath-exception-2.0", false, false, nil, `no embedded text for exception "Classpath-exception-2.0"`},
+		{"license without text", "CC-BY-SA-4.0", false, false, nil, `no embedded text for license "CC-BY-SA-4.0"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			l, err := header.NewLicense(tt.license, "", header.SPDXOn, header.LicenseData{Year: "2022", Holder: "H"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			files, err := initFiles(l.Data, "project", tt.notice, tt.reuse)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("initFiles returned error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, f := range files {
+				got = append(got, f.path)
+				if f.license != "" && !f.matches(f.content) {
+					t.Errorf("the text of %s is not identified as %s", f.path, f.license)
+				}
+			}
+			if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+				t.Errorf("initFiles returned %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNoticeText(t *testing.T) {
+	data := header.LicenseData{Year: "2022", Holder: "Jane Doe", SPDXID: "MIT OR Apache-2.0"}
+	got := string(noticeText("project", data, []string{"LICENSE-MIT", "LICENSE-Apache-2.0"}))
+	want := `project
+Copyright 2022 Jane Doe
+
+This product includes software developed by Jane Doe,
+licensed under MIT OR Apache-2.0.
+See the LICENSE-MIT and LICENSE-Apache-2.0 files for the terms of the licenses.
+`
+	if got != want {
+		t.Errorf("noticeText returned:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestWriteInitFiles(t *testing.T) {
+	tmp := tempDir(t)
+	l, err := header.NewLicense("MIT", "", header.SPDXOn, header.LicenseData{Year: "2022", Holder: "H"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := initFiles(l.Data, "project", true, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := checkInitFiles(&out, tmp, files); err == nil {
+		t.Error("checkInitFiles of missing files returned no error")
+	}
+	if !strings.Contains(out.String(), filepath.Join(tmp, "LICENSE")+": missing: missing file\n") {
+		t.Errorf("checkInitFiles printed:\n%s", out.String())
+	}
+
+	if err := writeInitFiles(tmp, files, false); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"LICENSE", "NOTICE", "LICENSES/MIT.txt"} {
+		b, err := ioutil.ReadFile(filepath.Join(tmp, filepath.FromSlash(name)))
+		if err != nil || len(b) == 0 {
+			t.Errorf("%s was not written: %v", name, err)
+		}
+	}
+	out.Reset()
+	if err := checkInitFiles(&out, tmp, files); err != nil {
+		t.Errorf("checkInitFiles of written files returned %v:\n%s", err, out.String())
+	}
+
+	// existing files of another license are only overwritten with force
+	apache, err := header.LicenseText("Apache-2.0", header.LicenseData{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFiles(t, tmp, map[string]string{"LICENSE": string(apache)})
+	out.Reset()
+	if err := checkInitFiles(&out, tmp, files); err == nil || !strings.Contains(out.String(), "found Apache-2.0 license text, want MIT") {
+		t.Errorf("checkInitFiles of another license returned %v:\n%s", err, out.String())
+	}
+	if err := writeInitFiles(tmp, files, false); err == nil {
+		t.Error("writeInitFiles overwrote a file of another license without force")
+	}
+	if err := writeInitFiles(tmp, files, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkInitFiles(&out, tmp, files); err != nil {
+		t.Errorf("checkInitFiles of overwritten files returned %v", err)
+	}
+}
+
+func TestNeedsNotice(t *testing.T) {
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{"Apache-2.0", true},
+		{"MIT OR Apache-2.0", true},
+		{"MIT", false},
+		{"bsd", false},
+	}
+
+	for _, tt := range tests {
+		if got := needsNotice(tt.expr); got != tt.want {
+			t.Errorf("needsNotice(%q) = %t, want %t", tt.expr, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// journalEntry records the original contents of a file modified by a run.
+type journalEntry struct {
+	Path    string      `json:"path"`              // absolute path of the file
+	Mode    os.FileMode `json:"mode"`              // original mode
+	ModTime time.Time   `json:"mtime"`             // original modification time
+	Content []byte      `json:"content"`           // original contents
+	Sum     string      `json:"sum"`               // SHA-256 checksum of the new contents
+	Created bool        `json:"created,omitempty"` // the file did not exist
+	Deleted bool        `json:"deleted,omitempty"` // the file was deleted
+}
+
+// journal is an undo journal: a file of JSON entries, one per modified file,
+// appended to by each run. Entries are written once the files they describe
+// have been modified, so that failed writes are not undone. It is safe for
+// concurrent use.
+type journal struct {
+	mu  sync.Mutex
+	f   *os.File
+	enc *json.Encoder
+}
+
+// openJournal opens the journal at path for appending, creating it if needed.
+func openJournal(path string) (*journal, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &journal{f: f, enc: json.NewEncoder(f)}, nil
+}
+
+// newJournalEntry returns the entry of the file at path, about to be changed
+// from old to new. A nil old means that the file is about to be created, and
+// a nil new that it is about to be deleted.
+func newJournalEntry(path string, old, new []byte) (*journalEntry, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	e := &journalEntry{
+		Path:    abs,
+		Content: old,
+		Sum:     checksum(new),
+		Created: old == nil,
+		Deleted: new == nil,
+	}
+	if !e.Created {
+		fi, err := os.Stat(abs)
+		if err != nil {
+			return nil, err
+		}
+		e.Mode, e.ModTime = fi.Mode().Perm(), fi.ModTime()
+	}
+	return e, nil
+}
+
+// record adds the entry e of a file that has been changed, and flushes it to
+// disk.
+func (j *journal) record(e *journalEntry) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if err := j.enc.Encode(e); err != nil {
+		return fmt.Errorf("journal: %w", err)
+	}
+	return j.f.Sync()
+}
+
+func (j *journal) Close() error {
+	return j.f.Close()
+}
+
+func checksum(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// readJournal returns the entries of the journal at path.
+func readJournal(path string) ([]journalEntry, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var entries []journalEntry
+	dec := json.NewDecoder(bytes.NewReader(b))
+	for {
+		var e journalEntry
+		if err := dec.Decode(&e); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// undoJournal restores the files recorded in the journal at path to their
+// original contents, mode and modification time, latest changes first.
+// Created files are deleted, and deleted files created again. Files that were
+// changed again since they were recorded are left alone.
+// The journal is removed once all files are restored.
+func undoJournal(path string) error {
+	entries, err := readJournal(path)
+	if err != nil {
+		return err
+	}
+	failed := 0
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		if e.Deleted {
+			if _, err := os.Lstat(e.Path); !os.IsNotExist(err) {
+				log.Printf("%s: created again since the journal was written, not restored", e.Path)
+				failed++
+				continue
+			}
+		} else {
+			b, err := ioutil.ReadFile(e.Path)
+			if err != nil {
+				log.Printf("%s: %v", e.Path, err)
+				failed++
+				continue
+			}
+			if checksum(b) != e.Sum {
+				log.Printf("%s: modified since the journal was written, not restored", e.Path)
+				failed++
+				continue
+			}
+		}
+		if e.Created {
+			err = os.Remove(e.Path)
+		} else {
+			err = replaceFile(e.Path, e.Content, e.Mode, e.ModTime)
+		}
+		if err != nil {
+			log.Printf("%s: %v", e.Path, err)
+			failed++
+			continue
+		}
+		if *verbose {
+			log.Printf("%s restored", e.Path)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d files not restored, journal %s kept", failed, len(entries), path)
+	}
+	return os.Remove(path)
+}
@@ -0,0 +1,175 @@
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestUndoJournal(t *testing.T) {
+	tmp := tempDir(t)
+	path := filepath.Join(tmp, "undo.journal")
+	a, b := filepath.Join(tmp, "a.go"), filepath.Join(tmp, "b.go")
+	mtime := time.Date(2018, 1, 2, 3, 4, 5, 0, time.UTC)
+	for _, f := range []string{a, b} {
+		if err := ioutil.WriteFile(f, []byte("v1"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(f, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	j, err := openJournal(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a is changed twice, by two runs
+	for _, c := range []struct {
+		path     string
+		old, new string
+	}{
+		{a, "v1", "v2"},
+		{b, "v1", "v2"},
+		{a, "v2", "v3"},
+	} {
+		e, err := newJournalEntry(c.path, []byte(c.old), []byte(c.new))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := writeFile(c.path, []byte(c.new)); err != nil {
+			t.Fatal(err)
+		}
+		if err := j.record(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := j.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := undoJournal(path); err != nil {
+		t.Fatalf("undoJournal returned error: %v", err)
+	}
+	for _, f := range []string{a, b} {
+		got, err := ioutil.ReadFile(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fi, err := os.Stat(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != "v1" || fi.Mode().Perm() != 0600 || !fi.ModTime().Equal(mtime) {
+			t.Errorf("undoJournal restored %s to %q with mode %v and mtime %v", f, got, fi.Mode().Perm(), fi.ModTime())
+		}
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("undoJournal kept the journal: %v", err)
+	}
+}
+
+func TestUndoJournalModified(t *testing.T) {
+	tmp := tempDir(t)
+	path := filepath.Join(tmp, "undo.journal")
+	f := filepath.Join(tmp, "a.go")
+	if err := ioutil.WriteFile(f, []byte("v1"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	j, err := openJournal(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, err := newJournalEntry(f, []byte("v1"), []byte("v2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := j.record(e); err != nil {
+		t.Fatal(err)
+	}
+	j.Close()
+	// the file was edited after the run
+	if err := ioutil.WriteFile(f, []byte("edited"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := undoJournal(path); err == nil {
+		t.Error("undoJournal of a modified file returned no error")
+	}
+	if got, _ := ioutil.ReadFile(f); string(got) != "edited" {
+		t.Errorf("undoJournal changed the modified file to %q", got)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("undoJournal removed the journal: %v", err)
+	}
+}
+
+func TestUndoJournalCreatedDeleted(t *testing.T) {
+	tmp := tempDir(t)
+	path := filepath.Join(tmp, "undo.journal")
+	created, deleted := filepath.Join(tmp, "a.png.license"), filepath.Join(tmp, "b.png.license")
+	if err := ioutil.WriteFile(deleted, []byte("v1"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	j, err := openJournal(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []struct {
+		path     string
+		old, new []byte
+		change   func() error
+	}{
+		{created, nil, []byte("v1"), func() error { return createFile(created, []byte("v1")) }},
+		{deleted, []byte("v1"), nil, func() error { return os.Remove(deleted) }},
+	} {
+		e, err := newJournalEntry(c.path, c.old, c.new)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := c.change(); err != nil {
+			t.Fatal(err)
+		}
+		if err := j.record(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	j.Close()
+
+	if err := undoJournal(path); err != nil {
+		t.Fatalf("undoJournal returned error: %v", err)
+	}
+	if _, err := os.Stat(created); !os.IsNotExist(err) {
+		t.Errorf("undoJournal kept the created file: %v", err)
+	}
+	fi, err := os.Stat(deleted)
+	if err != nil {
+		t.Fatalf("undoJournal did not restore the deleted file: %v", err)
+	}
+	if got, _ := ioutil.ReadFile(deleted); string(got) != "v1" || fi.Mode().Perm() != 0600 {
+		t.Errorf("undoJournal restored the deleted file to %q with mode %v", got, fi.Mode().Perm())
+	}
+}
@@ -0,0 +1,407 @@
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/bhojpur/license/header"
+)
+
+// reuseVersion is the version of the REUSE specification checked by lint.
+const reuseVersion = "3.3"
+
+// reuseMetadataRE matches the names of the REUSE metadata files.
+var reuseMetadataRE = regexp.MustCompile(`^REUSE\.toml$|\.spdx$`)
+
+// needsNoLicense reports whether the file name need not carry licensing
+// information, such as license files. These files are not checked by lint,
+// and get no sidecar files.
+func needsNoLicense(name string) bool {
+	base := filepath.Base(name)
+	return licenseFileRE.MatchString(base) || reuseMetadataRE.MatchString(base)
+}
+
+// lintReport is the REUSE compliance report of a project. Files are slash
+// separated paths relative to the root of the project.
+type lintReport struct {
+	BadLicenses              map[string][]string `json:"bad_licenses"` // invalid expressions or identifiers, with the files using them
+	DeprecatedLicenses       []string            `json:"deprecated_licenses"`
+	LicensesWithoutExtension []string            `json:"licenses_without_extension"`
+	MissingLicenses          map[string][]string `json:"missing_licenses"` // identifiers without license text, with the files using them
+	UnusedLicenses           []string            `json:"unused_licenses"`
+	UsedLicenses             []string            `json:"used_licenses"`
+	ReadErrors               []string            `json:"read_errors"`
+	MissingCopyright         []string            `json:"files_without_copyright"`
+	MissingLicensing         []string            `json:"files_without_license"`
+	Files                    int                 `json:"files"`
+	Compliant                bool                `json:"compliant"`
+}
+
+// runLint implements the lint command, which checks the compliance of a
+// project with the REUSE specification.
+func runLint(args []string) error {
+	fset := flag.NewFlagSet("lint", flag.ExitOnError)
+	format := fset.String("format", "text", "output format: text or json")
+	fset.Usage = func() {
+		fmt.Fprintf(fset.Output(), "Usage: license lint [flags] [directory]\n\nFlags:\n")
+		fset.PrintDefaults()
+	}
+	fset.Parse(args)
+	if fset.NArg() > 1 {
+		fset.Usage()
+		os.Exit(1)
+	}
+	if *format != "text" && *format != "json" {
+		return fmt.Errorf("-format %q is not one of text, json", *format)
+	}
+	root := "."
+	if fset.NArg() == 1 {
+		root = fset.Arg(0)
+	}
+
+	r, err := lintProject(root)
+	if err != nil {
+		return err
+	}
+	if *format == "json" {
+		err = writeJSON(os.Stdout, r)
+	} else {
+		err = r.write(os.Stdout)
+	}
+	if err != nil {
+		return err
+	}
+	if !r.Compliant {
+		return errors.New("the project is not compliant with the REUSE specification")
+	}
+	return nil
+}
+
+// lintProject checks the files of the project at root, including the
+// license texts of its LICENSES directory and the information of its
+// REUSE.toml or .reuse/dep5 file.
+func lintProject(root string) (*lintReport, error) {
+	r := &lintReport{
+		BadLicenses:     make(map[string][]string),
+		MissingLicenses: make(map[string][]string),
+	}
+	texts, err := r.licenseTexts(filepath.Join(root, "LICENSES"))
+	if err != nil {
+		return nil, err
+	}
+
+	used := make(map[string]bool)
+	deprecated := make(map[string]bool)
+	err = scanProject(root, func(path, name string, tags header.Tags, err error) {
+		if err != nil {
+			r.ReadErrors = append(r.ReadErrors, name)
+			return
+		}
+		r.Files++
+		if len(tags.Copyrights) == 0 {
+			r.MissingCopyright = append(r.MissingCopyright, name)
+		}
+		if len(tags.Licenses) == 0 {
+			r.MissingLicensing = append(r.MissingLicensing, name)
+		}
+		for _, l := range tags.Licenses {
+			e, err := header.ParseExpression(l)
+			if err != nil {
+				r.BadLicenses[l] = append(r.BadLicenses[l], name)
+				continue
+			}
+			for _, id := range append(e.Licenses(), e.Exceptions()...) {
+				used[id] = true
+				if _, dep, _ := header.LookupSPDXID(id); dep {
+					deprecated[id] = true
+				}
+				if !texts[id] {
+					r.MissingLicenses[id] = append(r.MissingLicenses[id], name)
+				}
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for id := range texts {
+		if !used[id] {
+			r.UnusedLicenses = append(r.UnusedLicenses, id)
+		}
+		if _, dep, _ := header.LookupSPDXID(id); dep {
+			deprecated[id] = true
+		}
+	}
+	for id := range used {
+		r.UsedLicenses = append(r.UsedLicenses, id)
+	}
+	for id := range deprecated {
+		r.DeprecatedLicenses = append(r.DeprecatedLicenses, id)
+	}
+	sort.Strings(r.UnusedLicenses)
+	sort.Strings(r.UsedLicenses)
+	sort.Strings(r.DeprecatedLicenses)
+	sort.Strings(r.LicensesWithoutExtension)
+	r.Compliant = len(r.BadLicenses) == 0 && len(r.DeprecatedLicenses) == 0 &&
+		len(r.LicensesWithoutExtension) == 0 && len(r.MissingLicenses) == 0 &&
+		len(r.UnusedLicenses) == 0 && len(r.ReadErrors) == 0 &&
+		len(r.MissingCopyright) == 0 && len(r.MissingLicensing) == 0
+	return r, nil
+}
+
+// scanProject calls fn for each file of the project at root that should
+// carry licensing information, in lexical order, with its slash separated
+// name relative to root and its tags combined with the information of the
+// REUSE.toml or .reuse/dep5 file. Ignored files, license texts, sidecar
+// files and empty files are skipped, and files that cannot be read are
+// passed with an error.
+func scanProject(root string, fn func(path, name string, tags header.Tags, err error)) error {
+	annotations, err := loadReuseAnnotations(root)
+	if err != nil {
+		return err
+	}
+	var ign *gitIgnore
+	if abs, err := filepath.Abs(root); err == nil && repoRoot(abs) != "" {
+		if ign, err = newGitIgnore(root); err != nil {
+			return err
+		}
+	}
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		name := filepath.ToSlash(rel)
+		if d.IsDir() {
+			switch {
+			case name == ".":
+				return nil
+			case isVCSDir(d.Name()),
+				name == ".reuse" || name == "LICENSES",
+				ign != nil && ign.ignored(path, true):
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() || needsNoLicense(name) || isTempFile(name) || ign != nil && ign.ignored(path, false) {
+			return nil
+		}
+		if strings.HasSuffix(name, header.SidecarSuffix) {
+			if _, err := os.Stat(strings.TrimSuffix(path, header.SidecarSuffix)); err == nil {
+				return nil
+			}
+		}
+		if fi, err := d.Info(); err == nil && fi.Size() == 0 {
+			return nil
+		}
+
+		tags, err := fileTags(path)
+		if err != nil {
+			fn(path, name, tags, err)
+			return nil
+		}
+		for i := len(annotations) - 1; i >= 0; i-- {
+			if annotations[i].matches(name) {
+				tags = annotations[i].apply(tags)
+				break
+			}
+		}
+		fn(path, name, tags, nil)
+		return nil
+	})
+}
+
+// licenseTexts returns the identifiers of the license texts in dir, named
+// after their identifier with a file extension, and records the invalid
+// ones in the report.
+func (r *lintReport) licenseTexts(dir string) (map[string]bool, error) {
+	texts := make(map[string]bool)
+	entries, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return texts, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, fi := range entries {
+		name := fi.Name()
+		if fi.IsDir() || strings.HasSuffix(name, header.SidecarSuffix) {
+			continue
+		}
+		id := strings.TrimSuffix(name, filepath.Ext(name))
+		if _, _, ok := header.LookupSPDXID(name); ok {
+			id = name
+			r.LicensesWithoutExtension = append(r.LicensesWithoutExtension, name)
+		}
+		if canonical, _, ok := header.LookupSPDXID(id); !ok || canonical != id {
+			path := "LICENSES/" + name
+			r.BadLicenses[id] = append(r.BadLicenses[id], path)
+			continue
+		}
+		texts[id] = true
+	}
+	return texts, nil
+}
+
+// fileTags returns the copyright and licensing information of the file at
+// path, read from its sidecar file if it has one.
+func fileTags(path string) (header.Tags, error) {
+	b, err := ioutil.ReadFile(path + header.SidecarSuffix)
+	if os.IsNotExist(err) {
+		b, err = ioutil.ReadFile(path)
+	}
+	if err != nil {
+		return header.Tags{}, err
+	}
+	return header.FindTags(b), nil
+}
+
+// fileLicenses returns the license expressions of a file with the given tags
+// and contents b: those of its tags, or else the license identified in its
+// header, for headers written with the full license notice instead of an
+// SPDX-License-Identifier tag.
+func fileLicenses(b []byte, tags header.Tags) []string {
+	if len(tags.Licenses) > 0 {
+		return tags.Licenses
+	}
+	if id := header.Identify(b); id.Identified() {
+		return []string{id.License}
+	}
+	return nil
+}
+
+// apply returns the tags of a file combined with the information of the
+// annotation, according to its precedence.
+func (a *reuseAnnotation) apply(tags header.Tags) header.Tags {
+	switch a.precedence {
+	case precedenceOverride:
+		return header.Tags{Copyrights: a.copyrights, Licenses: a.licenses}
+	case precedenceAggregate:
+		return header.Tags{
+			Copyrights: append(append([]string(nil), tags.Copyrights...), a.copyrights...),
+			Licenses:   append(append([]string(nil), tags.Licenses...), a.licenses...),
+		}
+	}
+	if len(tags.Copyrights) == 0 {
+		tags.Copyrights = a.copyrights
+	}
+	if len(tags.Licenses) == 0 {
+		tags.Licenses = a.licenses
+	}
+	return tags
+}
+
+// write writes the report to w in the format of the reuse lint command.
+func (r *lintReport) write(w io.Writer) error {
+	section := func(title string) { fmt.Fprintf(w, "# %s\n\n", title) }
+	usages := func(m map[string][]string) {
+		for _, id := range sortedKeys(m) {
+			fmt.Fprintf(w, "'%s' found in:\n", id)
+			for _, f := range m[id] {
+				fmt.Fprintf(w, "* %s\n", f)
+			}
+			fmt.Fprintln(w)
+		}
+	}
+	list := func(intro string, files []string) {
+		fmt.Fprintln(w, intro)
+		for _, f := range files {
+			fmt.Fprintf(w, "* %s\n", f)
+		}
+		fmt.Fprintln(w)
+	}
+
+	if len(r.BadLicenses) > 0 {
+		section("BAD LICENSES")
+		usages(r.BadLicenses)
+	}
+	if len(r.DeprecatedLicenses) > 0 {
+		section("DEPRECATED LICENSES")
+		list("The following licenses are deprecated by SPDX:", r.DeprecatedLicenses)
+	}
+	if len(r.LicensesWithoutExtension) > 0 {
+		section("LICENSES WITHOUT FILE EXTENSION")
+		list("The following licenses have no file extension:", r.LicensesWithoutExtension)
+	}
+	if len(r.MissingLicenses) > 0 {
+		section("MISSING LICENSES")
+		usages(r.MissingLicenses)
+	}
+	if len(r.UnusedLicenses) > 0 {
+		section("UNUSED LICENSES")
+		list("The following licenses are not used:", r.UnusedLicenses)
+	}
+	if len(r.ReadErrors) > 0 {
+		section("READ ERRORS")
+		list("Could not read:", r.ReadErrors)
+	}
+	if len(r.MissingCopyright) > 0 || len(r.MissingLicensing) > 0 {
+		section("MISSING COPYRIGHT AND LICENSING INFORMATION")
+		if len(r.MissingCopyright) > 0 {
+			list("The following files have no copyright information:", r.MissingCopyright)
+		}
+		if len(r.MissingLicensing) > 0 {
+			list("The following files have no licensing information:", r.MissingLicensing)
+		}
+	}
+
+	section("SUMMARY")
+	fmt.Fprintf(w, "* Bad licenses: %s\n", strings.Join(sortedKeys(r.BadLicenses), ", "))
+	fmt.Fprintf(w, "* Deprecated licenses: %s\n", strings.Join(r.DeprecatedLicenses, ", "))
+	fmt.Fprintf(w, "* Licenses without file extension: %s\n", strings.Join(r.LicensesWithoutExtension, ", "))
+	fmt.Fprintf(w, "* Missing licenses: %s\n", strings.Join(sortedKeys(r.MissingLicenses), ", "))
+	fmt.Fprintf(w, "* Unused licenses: %s\n", strings.Join(r.UnusedLicenses, ", "))
+	fmt.Fprintf(w, "* Used licenses: %s\n", strings.Join(r.UsedLicenses, ", "))
+	fmt.Fprintf(w, "* Read errors: %d\n", len(r.ReadErrors))
+	fmt.Fprintf(w, "* Files with copyright information: %d / %d\n", r.Files-len(r.MissingCopyright), r.Files)
+	fmt.Fprintf(w, "* Files with license information: %d / %d\n", r.Files-len(r.MissingLicensing), r.Files)
+	fmt.Fprintln(w)
+	if r.Compliant {
+		_, err := fmt.Fprintf(w, "Congratulations! Your project is compliant with version %s of the REUSE Specification :-)\n", reuseVersion)
+		return err
+	}
+	_, err := fmt.Fprintf(w, "Unfortunately, your project is not compliant with version %s of the REUSE Specification :-(\n", reuseVersion)
+	return err
+}
+
+func sortedKeys(m map[string][]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,182 @@
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestLintProject(t *testing.T) {
+	tests := []struct {
+		description string
+		files       map[string]string
+		want        lintReport
+	}{
+		{
+			"compliant",
+			map[string]string{
+				"LICENSES/MIT.txt":        "MIT License",
+				"LICENSES/Apache-2.0.txt": "Apache License",
+				"LICENSE":                 "MIT License",
+				"main.go":                 "// SPDX-FileCopyrightText: 2022 Alice\n// SPDX-License-Identifier: MIT\npackage main\n",
+				"logo.png":                "\x89PNG\x00",
+				"logo.png.license":        "SPDX-FileCopyrightText: 2022 Alice\nSPDX-License-Identifier: Apache-2.0\n",
+				"empty.txt":               "",
+				".git/config":             "[core]",
+			},
+			lintReport{
+				UsedLicenses: []string{"Apache-2.0", "MIT"},
+				Files:        2,
+				Compliant:    true,
+			},
+		},
+		{
+			"not compliant",
+			map[string]string{
+				"LICENSES/MIT.txt":    "MIT License",
+				"LICENSES/BSD":        "BSD License",
+				"LICENSES/GPL-2.0":    "GNU GPL",
+				"LICENSES/Foo.txt":    "Foo License",
+				"main.go":             "// Copyright 2022 Alice\n// SPDX-License-Identifier: Apache2\npackage main\n",
+				"util.go":             "// SPDX-License-Identifier: MIT OR Apache-2.0\npackage main\n",
+				"docs/README.md":      "# Docs\n",
+				"docs/orphan.license": "SPDX-License-Identifier: MIT\n",
+			},
+			lintReport{
+				BadLicenses: map[string][]string{
+					"Apache2": {"main.go"},
+					"BSD":     {"LICENSES/BSD"},
+					"Foo":     {"LICENSES/Foo.txt"},
+				},
+				DeprecatedLicenses:       []string{"GPL-2.0"},
+				LicensesWithoutExtension: []string{"GPL-2.0"},
+				MissingLicenses:          map[string][]string{"Apache-2.0": {"util.go"}},
+				UnusedLicenses:           []string{"GPL-2.0"},
+				UsedLicenses:             []string{"Apache-2.0", "MIT"},
+				MissingCopyright:         []string{"docs/README.md", "docs/orphan.license", "util.go"},
+				MissingLicensing:         []string{"docs/README.md"},
+				Files:                    4,
+			},
+		},
+		{
+			"deprecated license with a text",
+			map[string]string{
+				"LICENSES/GPL-3.0.txt": "GNU GPL",
+				"main.go":              "// SPDX-FileCopyrightText: 2022 Alice\n// SPDX-License-Identifier: GPL-3.0\npackage main\n",
+			},
+			lintReport{
+				DeprecatedLicenses: []string{"GPL-3.0"},
+				UsedLicenses:       []string{"GPL-3.0"},
+				Files:              1,
+			},
+		},
+		{
+			"REUSE.toml",
+			map[string]string{
+				"LICENSES/MIT.txt":     "MIT License",
+				"LICENSES/CC0-1.0.txt": "CC0",
+				"REUSE.toml": `version = 1
+[[annotations]]
+path = "docs/**"
+SPDX-FileCopyrightText = "2022 Alice"
+SPDX-License-Identifier = "CC0-1.0"
+
+[[annotations]]
+path = "*.go"
+precedence = "override"
+SPDX-FileCopyrightText = "2022 Bob"
+SPDX-License-Identifier = "MIT"
+`,
+				"main.go":        "// SPDX-License-Identifier: GPL-3.0-only\npackage main\n",
+				"docs/README.md": "# Docs\n",
+				"docs/a.md":      "<!-- SPDX-License-Identifier: MIT -->\n",
+			},
+			lintReport{
+				UsedLicenses: []string{"CC0-1.0", "MIT"},
+				Files:        3,
+				Compliant:    true,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			dir := tempDir(t)
+			writeFiles(t, dir, tt.files)
+			got, err := lintProject(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.want.BadLicenses == nil {
+				tt.want.BadLicenses = map[string][]string{}
+			}
+			if tt.want.MissingLicenses == nil {
+				tt.want.MissingLicenses = map[string][]string{}
+			}
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("lintProject returned\n%+v\nwant\n%+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLintReportWrite(t *testing.T) {
+	r := &lintReport{
+		MissingLicenses:  map[string][]string{"MIT": {"a.go", "b.go"}},
+		UsedLicenses:     []string{"MIT"},
+		MissingCopyright: []string{"b.go"},
+		Files:            2,
+	}
+	var buf bytes.Buffer
+	if err := r.write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	want := `# MISSING LICENSES
+
+'MIT' found in:
+* a.go
+* b.go
+
+# MISSING COPYRIGHT AND LICENSING INFORMATION
+
+The following files have no copyright information:
+* b.go
+
+# SUMMARY
+
+* Bad licenses: 
+* Deprecated licenses: 
+* Licenses without file extension: 
+* Missing licenses: MIT
+* Unused licenses: 
+* Used licenses: MIT
+* Read errors: 0
+* Files with copyright information: 1 / 2
+* Files with license information: 2 / 2
+
+Unfortunately, your project is not compliant with version 3.3 of the REUSE Specification :-(
+`
+	if got := buf.String(); got != want {
+		t.Errorf("write printed\n%s\nwant\n%s", got, want)
+	}
+}
@@ -21,7 +21,7 @@
 package main
 
 import (
-	"bytes"
+	"context"
 	"errors"
 	"flag"
 	"fmt"
@@ -29,25 +29,60 @@ import (
 	"log"
 	"os"
 	"path/filepath"
-	"regexp"
+	"runtime"
 	"strings"
-	"text/template"
+	"sync/atomic"
 	"time"
 
+	"github.com/bhojpur/license/header"
 	doublestar "github.com/bmatcuk/doublestar/v4"
 	"golang.org/x/sync/errgroup"
 )
 
 const helpText = `Usage: license [flags] pattern [pattern ...]
+       license identify [flags] file [file ...]
+       license lint [flags] [directory]
+       license init [flags] [directory]
+       license deps [flags] [directory]
+       license sbom [flags] [directory]
+       license policy check [flags] [directory]
+       license keygen [flags] private-key-file
+       license sign -key private-key-file file [file ...]
+       license verify -key public-key-file [flags] file [file ...]
 
 The program ensures source code files have copyright license headers by scanning
 directory patterns recursively.
 
 It modifies all source files in place and avoids adding a license header to any
-file that already has one.
+file that already has one. With -update, the copyright year of existing license
+headers is extended to the -y value instead, and with -replace, headers of other
+known licenses are replaced by the selected one. With -remove, headers matching
+the selected license are removed.
 
 The pattern argument can be provided multiple times, and may also refer to single
-files.
+files. With -undo, the files modified by runs recorded with -journal are restored
+instead, and no pattern is needed. -list-licenses prints the licenses that can be
+selected with -l.
+
+The identify command reports the license, copyright years and holder found in
+the header of each file, along with a confidence score. The lint command checks
+the compliance of a project with the REUSE specification, see -s=reuse. The
+init command writes the LICENSE file of a project, and the NOTICE file of
+Apache-2.0 projects, for the selected license and copyright holder. The deps
+command lists the licenses of the Go module dependencies of a project, found in
+the module cache or vendor directory. The sbom command writes a software bill of materials of the files and module
+dependencies of a project, in the SPDX or CycloneDX format. The policy check
+command verifies these licenses against the allowed, denied and incompatible
+licenses of a .license-policy.yaml file. The keygen, sign and verify commands
+generate Ed25519 key pairs, write detached signatures of program binaries and
+verify them offline.
+
+A pattern naming an existing file or directory is always processed as such, even
+if it has the name of a command: run commands from another directory, giving the
+directory as argument, when the working directory holds a file of that name.
+
+Defaults for the flags can be set in a .license.yaml file in the working
+directory or one of its parents, up to the root of the repository.
 
 Flags:
 `
@@ -58,21 +93,44 @@ var (
 	spdx               spdxFlag
 
 	holder    = flag.String("c", "Bhojpur Consulting Private Limited, India", "copyright holder")
-	license   = flag.String("l", "apache", "license type: Apache, BSD, MIT, MPL")
+	license   = flag.String("l", "apache", "license type: an SPDX identifier such as Apache-2.0, MIT or GPL-3.0-or-later, see -list-licenses")
 	licensef  = flag.String("f", "", "A standard Bhojpur License file")
 	year      = flag.String("y", fmt.Sprint(time.Now().Year()), "copyright year(s)")
 	verbose   = flag.Bool("v", false, "verbose mode: print the name of the files that are modified")
 	checkonly = flag.Bool("check", false, "check only mode: verify presence of Bhojpur License headers and exit with non-zero code if missing")
+	strict    = flag.Bool("strict", false, "with -check, verify that headers match the selected license, holder and year instead of only looking for a copyright notice")
+	foreign   = flag.Bool("foreign", false, "with -check, identify the license of existing headers and report headers of other or unrecognized licenses")
+	sidecars  = flag.Bool("sidecar", false, "write the SPDX header of binary files and of files without a known comment style to <file>"+header.SidecarSuffix+" sidecar files")
+	update    = flag.Bool("update", false, "update mode: extend the copyright year of existing Bhojpur License headers to the -y value")
+	replace   = flag.Bool("replace", false, "replace mode: replace license headers rendered from other built-in templates with the selected license")
+	gitYear   = flag.Bool("git-year", false, "derive the copyright years of each file from its git history, falling back to -y for files without commits")
+	remove    = flag.Bool("remove", false, "remove mode: remove Bhojpur License headers matching the selected license and holder")
+	dryRun    = flag.Bool("dry-run", false, "dry-run mode: print the changes that would be made as a unified diff without modifying any file")
+
+	gitignore  = flag.Bool("gitignore", false, "skip files ignored by .gitignore and .git/info/exclude files")
+	gitTracked = flag.Bool("git-tracked", false, "only process files tracked by the local git index")
+	format     = flag.String("format", "", "with -check, print a report of all checked files in the given format: json, sarif or junit")
+	patchFile  = flag.String("patch", "", "dry-run mode writing the changes to the given patch file, to be applied with git apply")
+	since      = flag.String("since", "", "only process files added or modified since the given git revision, such as origin/main")
+	jobs       = flag.Int("j", runtime.NumCPU(), "number of files processed in parallel")
+	failFast   = flag.Bool("fail-fast", false, "stop at the first file failing the check or that cannot be processed, instead of processing all files")
+	configPath = flag.String("config", "", "project configuration file (default: "+configFile+" in the working directory or its parents)")
+
+	backup         = flag.Bool("backup", false, "keep the original contents of modified files next to them, with an "+backupSuffix+" suffix")
+	journalPath    = flag.String("journal", "", "record the original contents of modified files in the given undo journal")
+	undo           = flag.String("undo", "", "restore the files recorded in the given undo journal to their original contents, and exit")
+	followSymlinks = flag.Bool("follow-symlinks", false, "process the targets of symbolic links pointing outside of the walked directories")
+	listLicenses   = flag.Bool("list-licenses", false, "print the licenses with a built-in template, and exit")
 )
 
 func init() {
 	flag.Usage = func() {
-		fmt.Fprintln(os.Stderr, helpText)
+		fmt.Fprint(os.Stderr, helpText)
 		flag.PrintDefaults()
 	}
 	flag.Var(&skipExtensionFlags, "skip", "[deprecated: see -ignore] file extensions to skip, For example: -skip rb -skip go")
 	flag.Var(&ignorePatterns, "ignore", "file patterns to ignore, for example: -ignore **/*.go -ignore vendor/**")
-	flag.Var(&spdx, "s", "Include SPDX identifier in Bhojpur License header. Set -s=only to only include SPDX identifier.")
+	flag.Var(&spdx, "s", "Include SPDX identifier in Bhojpur License header. Set -s=only to only include SPDX identifier, or -s=reuse for the SPDX-FileCopyrightText and SPDX-License-Identifier tags of the REUSE specification.")
 }
 
 // stringSlice stores the results of a repeated command line flag as a string slice.
@@ -91,9 +149,10 @@ func (i *stringSlice) Set(value string) error {
 type spdxFlag string
 
 const (
-	spdxOff  spdxFlag = ""
-	spdxOn   spdxFlag = "true" // value set by flag package on bool flag
-	spdxOnly spdxFlag = "only"
+	spdxOff   spdxFlag = ""
+	spdxOn    spdxFlag = "true" // value set by flag package on bool flag
+	spdxOnly  spdxFlag = "only"
+	spdxReuse spdxFlag = "reuse"
 )
 
 // IsBoolFlag causes a bare '-s' flag to be set as the string 'true'.  This
@@ -103,20 +162,85 @@ func (i *spdxFlag) String() string   { return string(*i) }
 
 func (i *spdxFlag) Set(value string) error {
 	v := spdxFlag(value)
-	if v != spdxOn && v != spdxOnly {
-		return fmt.Errorf("error: flag 's' expects '%v' or '%v'", spdxOn, spdxOnly)
+	if v != spdxOn && v != spdxOnly && v != spdxReuse {
+		return fmt.Errorf("error: flag 's' expects '%v', '%v' or '%v'", spdxOn, spdxOnly, spdxReuse)
 	}
 	*i = v
 	return nil
 }
 
+// commands are run with the remaining arguments when named by the first
+// argument, instead of processing files, unless a file or directory of that
+// name exists. See command.
+var commands = map[string]func(args []string) error{
+	"identify": runIdentify,
+	"lint":     runLint,
+	"init":     runInit,
+	"sbom":     runSBOM,
+	"deps":     runDeps,
+	"policy":   runPolicy,
+	"keygen":   runKeygen,
+	"sign":     runSign,
+	"verify":   runVerify,
+}
+
+// command returns the command named by the first argument. An existing
+// path of the same name is processed as a pattern instead, so that adding a
+// command never changes the meaning of license <dir>.
+func command(args []string) (func(args []string) error, bool) {
+	if len(args) == 0 {
+		return nil, false
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		return nil, false
+	}
+	if _, err := os.Lstat(args[0]); err == nil {
+		return nil, false
+	}
+	return cmd, true
+}
+
 func main() {
 	flag.Parse()
+	if cmd, ok := command(flag.Args()); ok {
+		if err := cmd(flag.Args()[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if *undo != "" {
+		if err := undoJournal(*undo); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if *listLicenses {
+		printLicenses(os.Stdout)
+		return
+	}
 	if flag.NArg() == 0 {
 		flag.Usage()
 		os.Exit(1)
 	}
 
+	cfg, err := loadProjectConfig(".")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *remove && (*update || *replace || *checkonly) {
+		log.Fatal("-remove cannot be combined with -update, -replace or -check")
+	}
+	if *jobs < 1 {
+		log.Fatalf("-j must be at least 1, got %d", *jobs)
+	}
+	if *format != "" && !*checkonly {
+		log.Fatal("-format requires -check")
+	}
+	if *format != "" && !validFormat(*format) {
+		log.Fatalf("-format %q is not one of %s", *format, strings.Join(reportFormats, ", "))
+	}
+
 	// convert -skip flags to -ignore equivalents
 	for _, s := range skipExtensionFlags {
 		ignorePatterns = append(ignorePatterns, fmt.Sprintf("**/*.%s", s))
@@ -128,253 +252,505 @@ func main() {
 		}
 	}
 
-	// Map the legacy Bhojpur License values
-	var ltype = legacyLicenseTypes[*license]
-	if ltype == "" {
-		*license = ltype
-	}
-
-	data := LicenseData{
+	def, err := newLicense(*license, *licensef, spdx, header.LicenseData{
 		Year:   *year,
 		Holder: *holder,
-		SPDXID: *license,
-	}
-
-	tpl, ferr := fetchTemplate(*license, *licensef, spdx)
-	if ferr != nil {
-		log.Fatal(ferr)
-	}
-	t, perr := template.New("").Parse(tpl)
-	if perr != nil {
-		log.Fatal(perr)
-	}
-
-	// process at most 1000 files in parallel
-	ch := make(chan *file, 1000)
-	done := make(chan struct{})
-	go func() {
-		var wg errgroup.Group
-		for f := range ch {
-			f := f
-			wg.Go(func() error {
-				if *checkonly {
-					// Check if file extension is known
-					lic, err := licenseHeader(f.path, t, data)
-					if err != nil {
-						log.Printf("%s: %v", f.path, err)
-						return err
-					}
-					if lic == nil { // Unknown fileExtension
-						return nil
-					}
-					// Check if file has a Bhojpur License
-					hasLicense, err := fileHasLicense(f.path)
-					if err != nil {
-						log.Printf("%s: %v", f.path, err)
-						return err
-					}
-					if !hasLicense {
-						fmt.Printf("%s\n", f.path)
-						return errors.New("missing Bhojpur License header")
-					}
-				} else {
-					modified, err := BhojpurLicense(f.path, f.mode, t, data)
-					if err != nil {
-						log.Printf("%s: %v", f.path, err)
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	rules, err := newRules(cfg.Rules, def, *license, *licensef, spdx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(cfg.Ignore) > 0 {
+		// like the paths of rules, the ignore patterns of the configuration
+		// are relative to its directory, and take precedence over the rules
+		rules = append([]header.Rule{{Patterns: cfg.Ignore, Skip: true}}, rules...)
+	}
+	styles, err := cfg.styles()
+	if err != nil {
+		log.Fatal(err)
+	}
+	proc = &header.Processor{
+		License: def,
+		Rules:   rules,
+		Styles:  styles,
+		Ignore:  ignorePatterns,
+		Update:  *update,
+		Replace: *replace,
+		Remove:  *remove,
+		Strict:  *strict,
+		Foreign: *foreign,
+	}
+	if *gitYear {
+		proc.FileData = func(name string, data header.LicenseData) (header.LicenseData, error) {
+			return fileData(filePath(cfg.dir, name), data)
+		}
+	}
+
+	report := &checkReport{}
+	if *patchFile != "" {
+		f, err := os.Create(*patchFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer f.Close()
+		diffs.w = f
+		*dryRun = true
+	}
+	if *journalPath != "" && !*dryRun {
+		undoLog, err = openJournal(*journalPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer undoLog.Close()
+	}
+
+	// The walk feeds the files to a fixed number of workers. With -fail-fast,
+	// the first failure cancels the context, which stops the walk and the
+	// workers; otherwise failures are only recorded and all files processed.
+	g, ctx := errgroup.WithContext(context.Background())
+	ch := make(chan *file, *jobs)
+	g.Go(func() error {
+		defer close(ch)
+		for _, d := range flag.Args() {
+			var err error
+			if *since != "" {
+				err = walkChanged(ctx, ch, d, *since)
+			} else {
+				err = walk(ctx, ch, d)
+			}
+			if err != nil {
+				if ctx.Err() == nil {
+					log.Print(err)
+				}
+				return err
+			}
+		}
+		return nil
+	})
+	var failed int32
+	for i := 0; i < *jobs; i++ {
+		g.Go(func() error {
+			for f := range ch {
+				if ctx.Err() != nil {
+					return nil
+				}
+				if err := processFile(f, cfg.dir, report); err != nil {
+					atomic.StoreInt32(&failed, 1)
+					if *failFast {
 						return err
 					}
-					if *verbose && modified {
-						log.Printf("%s modified", f.path)
-					}
 				}
-				return nil
-			})
+			}
+			return nil
+		})
+	}
+	err = g.Wait()
+	if *checkonly && err == nil {
+		for _, dir := range projectDirs(flag.Args()) {
+			if checkProjectLicense(dir, def.Data, report) != nil {
+				atomic.StoreInt32(&failed, 1)
+			}
 		}
-		err := wg.Wait()
-		close(done)
-		if err != nil {
-			os.Exit(1)
+	}
+	if *format != "" {
+		if werr := report.write(os.Stdout, *format); werr != nil {
+			log.Print(werr)
+			err = werr
 		}
-	}()
+	}
+	if err != nil || atomic.LoadInt32(&failed) != 0 {
+		os.Exit(1)
+	}
+}
 
-	for _, d := range flag.Args() {
-		if err := walk(ch, d); err != nil {
-			log.Fatal(err)
+// processFile checks or fixes the license header of f according to the
+// selected mode, with rules relative to the directory base, and adds the
+// result of checks to report. It returns an error if the file fails the
+// check or cannot be processed, after logging it.
+func processFile(f *file, base string, report *checkReport) error {
+	name := fileName(base, f.path)
+	sidecar, err := useSidecar(f.path, name)
+	if err != nil {
+		log.Printf("%s: %v", f.path, err)
+		report.add(f.path, header.CheckResult{Status: header.StatusError, Reason: err.Error()})
+		return err
+	}
+	if !sidecar && !proc.Handles(name) {
+		return nil
+	}
+	if *checkonly {
+		res, err := checkLicense(f.path, name, sidecar)
+		if err != nil {
+			log.Printf("%s: %v", f.path, err)
+			report.add(f.path, header.CheckResult{Status: header.StatusError, Reason: err.Error()})
+			return err
+		}
+		report.add(f.path, res)
+		if res.Status != header.StatusOK {
+			if *format == "" && (*strict || *foreign) {
+				fmt.Printf("%s: %s: %s\n", f.path, res.Status, res.Reason)
+			} else if *format == "" {
+				fmt.Printf("%s\n", f.path)
+			}
+			return errors.New(res.Reason)
 		}
+		return nil
+	}
+	c, err := fixLicense(f.path, name, sidecar)
+	if err != nil {
+		log.Printf("%s: %v", f.path, err)
+		return err
+	}
+	if c == nil {
+		return nil
+	}
+	written := f.path
+	if sidecar {
+		written += header.SidecarSuffix
 	}
-	close(ch)
-	<-done
+	if *dryRun {
+		log.Printf("%s: %s", written, c)
+	} else if *verbose {
+		log.Printf("%s modified", written)
+	}
+	return nil
 }
 
 type file struct {
 	path string
-	mode os.FileMode
 }
 
-func walk(ch chan<- *file, start string) error {
+// walk sends the files under start to ch, until ctx is cancelled.
+func walk(ctx context.Context, ch chan<- *file, start string) error {
+	ff, err := newFileFilter(start)
+	if err != nil {
+		return err
+	}
 	return filepath.Walk(start, func(path string, fi os.FileInfo, err error) error {
+		if isSidecarOfFile(path) {
+			return nil
+		}
 		if err != nil {
 			log.Printf("%s error: %v", path, err)
 			return nil
 		}
 		if fi.IsDir() {
+			if path != start && ff.skipDir(path) {
+				return filepath.SkipDir
+			}
 			return nil
 		}
-		if fileMatches(path, ignorePatterns) {
-			log.Printf("skipping: %s", path)
+		if ff.skipFile(path) {
 			return nil
 		}
-		ch <- &file{path, fi.Mode()}
-		return nil
+		if fi.Mode()&os.ModeSymlink != 0 {
+			target, ok := ff.followLink(path)
+			if !ok {
+				return nil
+			}
+			path = target
+		}
+		return send(ctx, ch, &file{path})
 	})
 }
 
-// fileMatches determines if path matches one of the provided file patterns.
-// Patterns are assumed to be valid.
-func fileMatches(path string, patterns []string) bool {
-	for _, p := range patterns {
-		// ignore error, since we assume patterns are valid
-		if match, _ := doublestar.Match(p, path); match {
-			return true
+// walkChanged is like walk, but only sends the files that were added or
+// modified since the revision rev.
+func walkChanged(ctx context.Context, ch chan<- *file, start, rev string) error {
+	ff, err := newFileFilter(start)
+	if err != nil {
+		return err
+	}
+	files, err := changedFiles(start, rev)
+	if err != nil {
+		return err
+	}
+	for _, path := range files {
+		if isSidecarOfFile(path) {
+			continue
+		}
+		fi, err := os.Lstat(path)
+		if err != nil {
+			log.Printf("%s error: %v", path, err)
+			continue
+		}
+		if fi.IsDir() || ff.skipFile(path) {
+			continue
+		}
+		if fi.Mode()&os.ModeSymlink != 0 {
+			target, ok := ff.followLink(path)
+			if !ok {
+				continue
+			}
+			path = target
+		}
+		if err := send(ctx, ch, &file{path}); err != nil {
+			return err
 		}
 	}
-	return false
+	return nil
 }
 
-// BhojpurLicense add a copyright license content to the file if missing.
-//
-// It returns true if the file was updated.
-func BhojpurLicense(path string, fmode os.FileMode, tmpl *template.Template, data LicenseData) (bool, error) {
-	var lic []byte
-	var err error
-	lic, err = licenseHeader(path, tmpl, data)
-	if err != nil || lic == nil {
-		return false, err
+// isSidecarOfFile reports whether path is the sidecar file of an existing
+// file. Such sidecar files are processed along with their file, which may
+// remove them while the walk is in progress.
+func isSidecarOfFile(path string) bool {
+	if !header.IsSidecar(path) {
+		return false
 	}
+	_, err := os.Stat(path[:len(path)-len(header.SidecarSuffix)])
+	return err == nil
+}
 
-	b, err := ioutil.ReadFile(path)
-	if err != nil {
-		return false, err
-	}
-	if hasLicense(b) || isGenerated(b) {
-		return false, err
+// send sends f to ch, unless ctx is cancelled first.
+func send(ctx context.Context, ch chan<- *file, f *file) error {
+	select {
+	case ch <- f:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
 	}
+}
+
+// fileFilter selects the files to process under a start path according to
+// the -ignore, -gitignore, -git-tracked and -follow-symlinks flags.
+type fileFilter struct {
+	root    string // absolute path of the directory walked, with symbolic links resolved
+	ign     *gitIgnore
+	tracked map[string]bool
+	outputs map[string]bool // absolute paths of the patch file and undo journal
+}
 
-	line := hashBang(b)
-	if len(line) > 0 {
-		b = b[len(line):]
-		if line[len(line)-1] != '\n' {
-			line = append(line, '\n')
+func newFileFilter(start string) (*fileFilter, error) {
+	var ff fileFilter
+	var err error
+	if ff.root, err = filepath.Abs(start); err != nil {
+		return nil, err
+	}
+	if root, err := filepath.EvalSymlinks(ff.root); err == nil {
+		ff.root = root
+	}
+	if fi, err := os.Stat(ff.root); err == nil && !fi.IsDir() {
+		ff.root = filepath.Dir(ff.root)
+	}
+	if *gitignore {
+		if ff.ign, err = newGitIgnore(start); err != nil {
+			return nil, err
 		}
-		lic = append(line, lic...)
 	}
-	b = append(lic, b...)
-	return true, ioutil.WriteFile(path, b, fmode)
+	if *gitTracked {
+		if ff.tracked, err = trackedFiles(start); err != nil {
+			return nil, err
+		}
+	}
+	ff.outputs = make(map[string]bool)
+	for _, path := range []string{*patchFile, *journalPath} {
+		if path == "" {
+			continue
+		}
+		if abs, err := filepath.Abs(path); err == nil {
+			ff.outputs[abs] = true
+		}
+	}
+	return &ff, nil
 }
 
-// fileHasLicense reports whether the file at path contains a license header.
-func fileHasLicense(path string) (bool, error) {
-	b, err := ioutil.ReadFile(path)
+// followLink returns the path of the file to process for the symbolic link
+// at path, or false if the link must be skipped. Links to files inside the
+// walked directory are skipped, as their targets are processed on their own,
+// and links to files outside of it are only followed with -follow-symlinks.
+func (ff *fileFilter) followLink(path string) (string, bool) {
+	target, err := filepath.EvalSymlinks(path)
 	if err != nil {
-		return false, err
+		log.Printf("%s error: %v", path, err)
+		return "", false
+	}
+	if fi, err := os.Stat(target); err != nil || !fi.Mode().IsRegular() {
+		return "", false
+	}
+	abs, err := filepath.Abs(target)
+	if err != nil {
+		return "", false
+	}
+	if rel, err := filepath.Rel(ff.root, abs); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	if !*followSymlinks {
+		log.Printf("skipping symbolic link out of the tree: %s", path)
+		return "", false
 	}
-	// If generated, we count it as if it has a license.
-	return hasLicense(b) || isGenerated(b), nil
+	return target, true
 }
 
-// licenseHeader populates the provided license template with data, and returns
-// it with the proper prefix for the file type specified by path. The file does
-// not need to actually exist, only its name is used to determine the prefix.
-func licenseHeader(path string, tmpl *template.Template, data LicenseData) ([]byte, error) {
-	var lic []byte
-	var err error
-	base := strings.ToLower(filepath.Base(path))
-
-	switch fileExtension(base) {
-	case ".c", ".h", ".gv", ".java", ".scala", ".kt", ".kts":
-		lic, err = ExecuteTemplate(tmpl, data, "/*", " * ", " */")
-	case ".js", ".mjs", ".cjs", ".jsx", ".tsx", ".css", ".scss", ".sass", ".tf", ".ts":
-		lic, err = ExecuteTemplate(tmpl, data, "/**", " * ", " */")
-	case ".cc", ".cpp", ".cs", ".go", ".hcl", ".hh", ".hpp", ".m", ".mm", ".proto", ".rs", ".swift", ".dart", ".groovy", ".v", ".sv":
-		lic, err = ExecuteTemplate(tmpl, data, "", "// ", "")
-	case ".py", ".sh", ".yaml", ".yml", ".dockerfile", "dockerfile", ".rb", "gemfile", ".tcl", ".bzl", ".pl":
-		lic, err = ExecuteTemplate(tmpl, data, "", "# ", "")
-	case ".el", ".lisp":
-		lic, err = ExecuteTemplate(tmpl, data, "", ";; ", "")
-	case ".erl":
-		lic, err = ExecuteTemplate(tmpl, data, "", "% ", "")
-	case ".hs", ".sql", ".sdl":
-		lic, err = ExecuteTemplate(tmpl, data, "", "-- ", "")
-	case ".html", ".xml", ".vue", ".wxi", ".wxl", ".wxs":
-		lic, err = ExecuteTemplate(tmpl, data, "<!--", " ", "-->")
-	case ".php":
-		lic, err = ExecuteTemplate(tmpl, data, "", "// ", "")
-	case ".ml", ".mli", ".mll", ".mly":
-		lic, err = ExecuteTemplate(tmpl, data, "(**", "   ", "*)")
-	default:
-		// handle various cmake files
-		if base == "cmakelists.txt" || strings.HasSuffix(base, ".cmake.in") || strings.HasSuffix(base, ".cmake") {
-			lic, err = ExecuteTemplate(tmpl, data, "", "# ", "")
+// skipDir reports whether the directory at path must not be descended into.
+func (ff *fileFilter) skipDir(path string) bool {
+	return isVCSDir(filepath.Base(path)) || ff.ign != nil && ff.ign.ignored(path, true)
+}
+
+// skipFile reports whether the file at path must not be processed.
+func (ff *fileFilter) skipFile(path string) bool {
+	if isTempFile(path) || ff.isOutput(path) {
+		return true
+	}
+	if proc.Ignored(path) {
+		log.Printf("skipping: %s", path)
+		return true
+	}
+	if ff.ign != nil && ff.ign.ignored(path, false) {
+		return true
+	}
+	if ff.tracked != nil {
+		if abs, err := filepath.Abs(path); err != nil || !ff.tracked[abs] {
+			return true
 		}
 	}
-	return lic, err
+	return false
 }
 
-// fileExtension returns the file extension of name, or the full name if there
-// is no extension.
-func fileExtension(name string) string {
-	if v := filepath.Ext(name); v != "" {
-		return v
+// isOutput reports whether the file at path was written by the tool itself:
+// the patch file, the undo journal or the backup of another file.
+func (ff *fileFilter) isOutput(path string) bool {
+	if abs, err := filepath.Abs(path); err == nil && ff.outputs[abs] {
+		return true
 	}
-	return name
+	if strings.HasSuffix(path, backupSuffix) {
+		if _, err := os.Stat(strings.TrimSuffix(path, backupSuffix)); err == nil {
+			return true
+		}
+	}
+	return false
 }
 
-var head = []string{
-	"#!",                       // shell script
-	"<?xml",                    // XML declaratioon
-	"<!doctype",                // HTML doctype
-	"# encoding:",              // Ruby encoding
-	"# frozen_string_literal:", // Ruby interpreter instruction
-	"<?php",                    // PHP opening tag
-	"# escape",                 // Dockerfile directive
-	"# syntax",                 // Dockerfile directive
+// isVCSDir reports whether name is the name of the metadata directory of a
+// version control system, whose files are never processed.
+func isVCSDir(name string) bool {
+	return name == ".git" || name == ".hg" || name == ".sl"
 }
 
-func hashBang(b []byte) []byte {
-	var line []byte
-	for _, c := range b {
-		line = append(line, c)
-		if c == '\n' {
-			break
-		}
+// proc is the engine fixing and checking license headers, configured by main
+// according to the flags and the project configuration.
+var proc = &header.Processor{}
+
+// undoLog records the original contents of modified files with -journal.
+var undoLog *journal
+
+// diffs receives the changes made in dry-run mode.
+var diffs = &diffWriter{w: os.Stdout}
+
+// fileData returns the license data for the file at path, with the copyright
+// years derived from the git history of the file for -git-year.
+func fileData(path string, data header.LicenseData) (header.LicenseData, error) {
+	years, err := gitYears(path)
+	if err != nil {
+		return data, err
 	}
-	first := strings.ToLower(string(line))
-	for _, h := range head {
-		if strings.HasPrefix(first, h) {
-			return line
-		}
+	if years != "" {
+		data.Year = years
 	}
-	return nil
+	return data, nil
 }
 
-// go generate: ^// Code generated by Bhojpur License engine .* DO NOT EDIT\.$
-var goGenerated = regexp.MustCompile(`(?m)^.{1,2} Code generated .* DO NOT EDIT\.$`)
+// useSidecar reports whether the license header of the file at path, named
+// name for the rules of the configuration, is held by its sidecar file: the
+// sidecar file exists, or the file needs one with -sidecar. Sidecar files
+// themselves and license files have none.
+func useSidecar(path, name string) (bool, error) {
+	if header.IsSidecar(name) || needsNoLicense(path) || proc.LicenseFor(name) == nil {
+		return false, nil
+	}
+	if _, err := os.Stat(path + header.SidecarSuffix); err == nil {
+		return true, nil
+	} else if !os.IsNotExist(err) {
+		return false, err
+	}
+	if !*sidecars {
+		return false, nil
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+	return proc.NeedsSidecar(name, b), nil
+}
 
-// cargo raze: ^DO NOT EDIT! Replaced on runs of cargo-raze$
-var cargoRazeGenerated = regexp.MustCompile(`(?m)^DO NOT EDIT! Replaced on runs of cargo-raze$`)
+// readSidecar returns the contents of the sidecar file of the file at path,
+// or nil if it does not exist.
+func readSidecar(path string) ([]byte, error) {
+	b, err := ioutil.ReadFile(path + header.SidecarSuffix)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return b, err
+}
 
-// isGenerated returns true if it contains a string that implies the file was
-// generated.
-func isGenerated(b []byte) bool {
-	return goGenerated.Match(b) || cargoRazeGenerated.Match(b)
+// checkLicense checks the license header of the file at path, named name
+// for the rules of the configuration, or the one of its sidecar file.
+func checkLicense(path, name string, sidecar bool) (header.CheckResult, error) {
+	if sidecar {
+		b, err := readSidecar(path)
+		if err != nil {
+			return header.CheckResult{}, err
+		}
+		return proc.CheckSidecar(name, b)
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return header.CheckResult{}, err
+	}
+	return proc.Check(name, b)
 }
 
-func hasLicense(b []byte) bool {
-	n := 1000
-	if len(b) < 1000 {
-		n = len(b)
+// fixLicense adds, updates, replaces or removes the license header of the
+// file at path, named name for the rules of the configuration, or the one of
+// its sidecar file, according to the selected mode, and writes the result
+// back. In dry-run mode, the changes are written as a unified diff to diffs
+// instead. It returns the change, or nil if the file was left unchanged.
+func fixLicense(path, name string, sidecar bool) (*header.Change, error) {
+	var b []byte
+	var c *header.Change
+	var err error
+	if sidecar {
+		b, err = readSidecar(path)
+		if err == nil {
+			c, err = proc.FixSidecar(name, b)
+		}
+		path += header.SidecarSuffix
+	} else {
+		b, err = ioutil.ReadFile(path)
+		if err == nil {
+			c, err = proc.Fix(name, b)
+		}
+	}
+	if err != nil || c == nil {
+		return nil, err
+	}
+	if *dryRun {
+		return c, diffs.write(path, b, c.Content)
+	}
+	if *backup && b != nil {
+		if err := backupFile(path, b); err != nil {
+			return nil, err
+		}
+	}
+	var entry *journalEntry
+	if undoLog != nil {
+		if entry, err = newJournalEntry(path, b, c.Content); err != nil {
+			return nil, err
+		}
+	}
+	switch {
+	case b == nil:
+		err = createFile(path, c.Content)
+	case c.Content == nil:
+		err = os.Remove(path)
+	default:
+		err = writeFile(path, c.Content)
+	}
+	if err == nil && entry != nil {
+		err = undoLog.record(entry)
 	}
-	return bytes.Contains(bytes.ToLower(b[:n]), []byte("copyright")) ||
-		bytes.Contains(bytes.ToLower(b[:n]), []byte("mozilla public")) ||
-		bytes.Contains(bytes.ToLower(b[:n]), []byte("spdx-license-identifier"))
+	return c, err
 }
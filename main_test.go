@@ -21,13 +21,17 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
 	"io/ioutil"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
-	"text/template"
+
+	"github.com/bhojpur/license/header"
 )
 
 func run(t *testing.T, name string, args ...string) {
@@ -95,21 +99,377 @@ func TestMultiyear(t *testing.T) {
 	run(t, "diff", samplefile, sampleLicensed)
 }
 
+func TestUpdate(t *testing.T) {
+	if os.Getenv("RUNME") != "" {
+		main()
+		return
+	}
+
+	tmp := tempDir(t)
+	t.Logf("tmp dir: %s", tmp)
+	samplefile := filepath.Join(tmp, "file.c")
+	const sampleUpdated = "testdata/update_file.c"
+
+	run(t, "cp", "testdata/expected/file.c", samplefile)
+	// run at least 2 times to ensure the update is idempotent
+	for i := 0; i < 2; i++ {
+		cmd := exec.Command(os.Args[0],
+			"-test.run=TestUpdate",
+			"-l", "apache", "-c", "Bhojpur Consulting Private Limited, India",
+			"-y", "2022", "-update", samplefile,
+		)
+		cmd.Env = []string{"RUNME=1"}
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("%v\n%s", err, out)
+		}
+		run(t, "diff", samplefile, sampleUpdated)
+	}
+}
+
+func TestReplace(t *testing.T) {
+	if os.Getenv("RUNME") != "" {
+		main()
+		return
+	}
+
+	tmp := tempDir(t)
+	t.Logf("tmp dir: %s", tmp)
+	samplefile := filepath.Join(tmp, "file.c")
+	const sampleReplaced = "testdata/replace_file.c"
+
+	run(t, "cp", "testdata/expected/file.c", samplefile)
+	args := []string{
+		"-test.run=TestReplace",
+		"-l", "mit", "-c", "Bhojpur Consulting Private Limited, India",
+		"-y", "2022", "-replace",
+	}
+
+	// dry-run reports the change without modifying the file
+	cmd := exec.Command(os.Args[0], append(args, "-dry-run", samplefile)...)
+	cmd.Env = []string{"RUNME=1"}
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("%v\n%s", err, out)
+	}
+	if want := samplefile + ": replace Apache-2.0 header with MIT"; !strings.Contains(string(out), want) {
+		t.Errorf("dry-run output %q does not contain %q", out, want)
+	}
+	run(t, "diff", samplefile, "testdata/expected/file.c")
+
+	// run at least 2 times to ensure the replacement is idempotent
+	for i := 0; i < 2; i++ {
+		cmd := exec.Command(os.Args[0], append(args, samplefile)...)
+		cmd.Env = []string{"RUNME=1"}
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("%v\n%s", err, out)
+		}
+		run(t, "diff", samplefile, sampleReplaced)
+	}
+}
+
+func TestRemove(t *testing.T) {
+	if os.Getenv("RUNME") != "" {
+		main()
+		return
+	}
+
+	tmp := tempDir(t)
+	t.Logf("tmp dir: %s", tmp)
+	run(t, "cp", "-r", "testdata/expected", tmp)
+
+	// run at least 2 times to ensure the program is idempotent
+	for i := 0; i < 2; i++ {
+		t.Logf("run #%d", i)
+		targs := []string{"-test.run=TestRemove"}
+		cargs := []string{"-l", "apache", "-c", "Bhojpur Consulting Private Limited, India", "-y", "2022", "-remove", tmp}
+		c := exec.Command(os.Args[0], append(targs, cargs...)...)
+		c.Env = []string{"RUNME=1"}
+		if out, err := c.CombinedOutput(); err != nil {
+			t.Fatalf("%v\n%s", err, out)
+		}
+
+		// file2.sh gained a trailing newline after its hashbang line when
+		// the header was added
+		run(t, "diff", "-r", "-x", "file2.sh", filepath.Join(tmp, "expected"), "testdata/initial")
+	}
+}
+
+func TestConfig(t *testing.T) {
+	if os.Getenv("RUNME") != "" {
+		main()
+		return
+	}
+
+	tmp := tempDir(t)
+	t.Logf("tmp dir: %s", tmp)
+	samplefile := filepath.Join(tmp, "file.c")
+	ignoredfile := filepath.Join(tmp, "vendor", "file.c")
+	config := filepath.Join(tmp, configFile)
+
+	run(t, "mkdir", filepath.Join(tmp, "vendor"))
+	run(t, "cp", "testdata/initial/file.c", samplefile)
+	run(t, "cp", "testdata/initial/file.c", ignoredfile)
+	const settings = "license: bsd\nholder: Other\nignore:\n  - '**/vendor/**'\n"
+	if err := ioutil.WriteFile(config, []byte(settings), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// command line flags take precedence over the configuration file
+	cmd := exec.Command(os.Args[0],
+		"-test.run=TestConfig",
+		"-config", config, "-c", "Bhojpur Consulting Private Limited, India.",
+		"-y", "2005-2008,2018",
+		tmp,
+	)
+	cmd.Env = []string{"RUNME=1"}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("%v\n%s", err, out)
+	}
+	run(t, "diff", samplefile, "testdata/multiyear_file.c")
+	run(t, "diff", ignoredfile, "testdata/initial/file.c")
+
+	// ignore patterns are relative to the configuration file, like the paths
+	// of rules, also when running from a subdirectory
+	proj := tempDir(t)
+	writeFiles(t, proj, map[string]string{configFile: "license: mit\nholder: Alice\nignore: [sub/vendor/**]\n"})
+	run(t, "mkdir", "-p", filepath.Join(proj, "sub", "vendor"))
+	run(t, "cp", "testdata/initial/file.c", filepath.Join(proj, "sub", "file.c"))
+	run(t, "cp", "testdata/initial/file.c", filepath.Join(proj, "sub", "vendor", "file.c"))
+	cmd = exec.Command(os.Args[0], "-test.run=TestConfig", ".")
+	cmd.Dir = filepath.Join(proj, "sub")
+	cmd.Env = []string{"RUNME=1"}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("%v\n%s", err, out)
+	}
+	if b, _ := ioutil.ReadFile(filepath.Join(proj, "sub", "file.c")); !bytes.Contains(b, []byte("Alice")) {
+		t.Errorf("sub/file.c was not licensed:\n%s", b)
+	}
+	run(t, "diff", filepath.Join(proj, "sub", "vendor", "file.c"), "testdata/initial/file.c")
+}
+
+func TestRules(t *testing.T) {
+	if os.Getenv("RUNME") != "" {
+		main()
+		return
+	}
+
+	tmp := tempDir(t)
+	t.Logf("tmp dir: %s", tmp)
+	config := filepath.Join(tmp, configFile)
+	const settings = `holder: Bhojpur Consulting Private Limited, India
+rules:
+  - paths: [sdk/**]
+    license: apache
+  - paths: [examples/**]
+    license: mit
+  - paths: [third_party/**]
+    skip: true
+`
+	if err := ioutil.WriteFile(config, []byte(settings), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, dir := range []string{"sdk", "examples", "third_party"} {
+		run(t, "mkdir", filepath.Join(tmp, dir))
+		run(t, "cp", "testdata/initial/file.go", filepath.Join(tmp, dir))
+	}
+	// the BSD-style headers refer to the LICENSE file, checked with -check
+	bsd, err := header.LicenseText("bsd", header.LicenseData{Year: "2018", Holder: "Bhojpur Consulting Private Limited, India"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFiles(t, tmp, map[string]string{"LICENSE": string(bsd)})
+
+	for _, args := range [][]string{
+		{"-l", "bsd"},
+		{"-l", "bsd", "-check", "-strict"},
+	} {
+		args = append([]string{"-test.run=TestRules", "-config", config, "-y", "2018"}, args...)
+		cmd := exec.Command(os.Args[0], append(args, tmp)...)
+		cmd.Env = []string{"RUNME=1"}
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("%v: %v\n%s", args, err, out)
+		}
+	}
+	run(t, "diff", filepath.Join(tmp, "sdk", "file.go"), "testdata/expected/file.go")
+	run(t, "diff", filepath.Join(tmp, "third_party", "file.go"), "testdata/initial/file.go")
+	b, err := ioutil.ReadFile(filepath.Join(tmp, "examples", "file.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), "Permission is hereby granted") {
+		t.Errorf("examples/file.go does not have an MIT license header:\n%s", b)
+	}
+}
+
+func TestDryRunPatch(t *testing.T) {
+	if os.Getenv("RUNME") != "" {
+		main()
+		return
+	}
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found")
+	}
+
+	tmp := tempDir(t)
+	t.Logf("tmp dir: %s", tmp)
+	run(t, "cp", "-r", "testdata/initial", tmp)
+
+	cmd := exec.Command(os.Args[0],
+		"-test.run=TestDryRunPatch",
+		"-l", "apache", "-c", "Bhojpur Consulting Private Limited, India", "-y", "2018",
+		"-patch", "license.patch", "initial",
+	)
+	cmd.Dir = tmp
+	cmd.Env = []string{"RUNME=1"}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("%v\n%s", err, out)
+	}
+	run(t, "diff", "-r", filepath.Join(tmp, "initial"), "testdata/initial")
+
+	apply := exec.Command("git", "apply", "license.patch")
+	apply.Dir = tmp
+	if out, err := apply.CombinedOutput(); err != nil {
+		t.Fatalf("git apply: %v\n%s", err, out)
+	}
+	run(t, "diff", "-r", filepath.Join(tmp, "initial"), "testdata/expected")
+
+	// sidecar files are created by the patch
+	writeFiles(t, tmp, map[string]string{"bin/logo.png": "\x89PNG\x00"})
+	cmd = exec.Command(os.Args[0],
+		"-test.run=TestDryRunPatch",
+		"-l", "MIT", "-c", "Jane Doe", "-y", "2022", "-sidecar",
+		"-patch", "sidecar.patch", "bin",
+	)
+	cmd.Dir = tmp
+	cmd.Env = []string{"RUNME=1"}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("%v\n%s", err, out)
+	}
+	if _, err := os.Stat(filepath.Join(tmp, "bin", "logo.png.license")); !os.IsNotExist(err) {
+		t.Fatalf("dry run created the sidecar file (%v)", err)
+	}
+	apply = exec.Command("git", "apply", "sidecar.patch")
+	apply.Dir = tmp
+	if out, err := apply.CombinedOutput(); err != nil {
+		t.Fatalf("git apply: %v\n%s", err, out)
+	}
+	b, err := ioutil.ReadFile(filepath.Join(tmp, "bin", "logo.png.license"))
+	if err != nil || !strings.Contains(string(b), "SPDX-License-Identifier: MIT") {
+		t.Errorf("patch created sidecar file %q (%v)", b, err)
+	}
+}
+
+func TestJournal(t *testing.T) {
+	if os.Getenv("RUNME") != "" {
+		main()
+		return
+	}
+
+	tmp := tempDir(t)
+	t.Logf("tmp dir: %s", tmp)
+	run(t, "cp", "-r", "testdata/initial", tmp)
+	dir := filepath.Join(tmp, "initial")
+	journal := filepath.Join(tmp, "license.journal")
+
+	cmd := exec.Command(os.Args[0],
+		"-test.run=TestJournal",
+		"-l", "apache", "-c", "Bhojpur Consulting Private Limited, India", "-y", "2018",
+		"-journal", journal, "-backup", dir,
+	)
+	cmd.Env = []string{"RUNME=1"}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("%v\n%s", err, out)
+	}
+	run(t, "diff", "-r", "-x", "*.orig", dir, "testdata/expected")
+	run(t, "diff", filepath.Join(dir, "file.c.orig"), "testdata/initial/file.c")
+
+	cmd = exec.Command(os.Args[0], "-test.run=TestJournal", "-undo", journal)
+	cmd.Env = []string{"RUNME=1"}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("%v\n%s", err, out)
+	}
+	run(t, "diff", "-r", "-x", "*.orig", dir, "testdata/initial")
+}
+
+func TestSymlinks(t *testing.T) {
+	if os.Getenv("RUNME") != "" {
+		main()
+		return
+	}
+
+	tmp := tempDir(t)
+	t.Logf("tmp dir: %s", tmp)
+	dir := filepath.Join(tmp, "tree")
+	writeFiles(t, tmp, map[string]string{
+		"tree/file.go": "package main\n",
+		"outside.go":   "package main\n",
+	})
+	if err := os.Symlink(filepath.Join(dir, "file.go"), filepath.Join(dir, "inside.go")); err != nil {
+		t.Skip(err)
+	}
+	if err := os.Symlink(filepath.Join(tmp, "outside.go"), filepath.Join(dir, "link.go")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		args        []string
+		wantOutside bool // whether the file outside of the tree gets a header
+	}{
+		{nil, false},
+		{[]string{"-follow-symlinks"}, true},
+	}
+
+	for _, tt := range tests {
+		args := append([]string{"-test.run=TestSymlinks", "-l", "mit", "-c", "H", "-y", "2022"}, tt.args...)
+		cmd := exec.Command(os.Args[0], append(args, dir)...)
+		cmd.Env = []string{"RUNME=1"}
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("%v\n%s", err, out)
+		}
+		b, err := ioutil.ReadFile(filepath.Join(tmp, "outside.go"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.HasPrefix(string(b), "// Copyright"); got != tt.wantOutside {
+			t.Errorf("run with %q added a header outside of the tree: %t, want %t", tt.args, got, tt.wantOutside)
+		}
+		// links stay links, and files inside the tree get a single header
+		for _, name := range []string{"inside.go", "link.go"} {
+			if fi, err := os.Lstat(filepath.Join(dir, name)); err != nil || fi.Mode()&os.ModeSymlink == 0 {
+				t.Errorf("run with %q replaced the symbolic link %s", tt.args, name)
+			}
+		}
+		b, err = ioutil.ReadFile(filepath.Join(dir, "file.go"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n := strings.Count(string(b), "Copyright"); n != 1 {
+			t.Errorf("run with %q added %d headers to file.go, want 1", tt.args, n)
+		}
+	}
+}
+
 func TestWriteErrors(t *testing.T) {
 	if os.Getenv("RUNME") != "" {
 		main()
 		return
 	}
+	if os.Geteuid() == 0 {
+		t.Skip("file permissions are not enforced for root")
+	}
 
 	tmp := tempDir(t)
 	t.Logf("tmp dir: %s", tmp)
 	samplefile := filepath.Join(tmp, "file.c")
 
+	journal := filepath.Join(tmp, "undo.journal")
+
 	run(t, "cp", "testdata/initial/file.c", samplefile)
 	run(t, "chmod", "0444", samplefile)
 	cmd := exec.Command(os.Args[0],
 		"-test.run=TestWriteErrors",
 		"-l", "apache", "-c", "Bhojpur Consulting Private Limited, India.", "-y", "2018",
+		"-journal", journal,
 		samplefile,
 	)
 	cmd.Env = []string{"RUNME=1"}
@@ -119,6 +479,10 @@ func TestWriteErrors(t *testing.T) {
 		t.Fatalf("TestWriteErrors exited with a zero exit code.\n%s", out)
 	}
 	run(t, "chmod", "0644", samplefile)
+	// the file that could not be written is not recorded in the journal
+	if b, err := ioutil.ReadFile(journal); err != nil || len(b) != 0 {
+		t.Errorf("journal holds %q (%v), want no entries", b, err)
+	}
 }
 
 func TestReadErrors(t *testing.T) {
@@ -126,6 +490,9 @@ func TestReadErrors(t *testing.T) {
 		main()
 		return
 	}
+	if os.Geteuid() == 0 {
+		t.Skip("file permissions are not enforced for root")
+	}
 
 	tmp := tempDir(t)
 	t.Logf("tmp dir: %s", tmp)
@@ -192,6 +559,118 @@ func TestCheckFail(t *testing.T) {
 	}
 }
 
+func TestFailFast(t *testing.T) {
+	if os.Getenv("RUNME") != "" {
+		main()
+		return
+	}
+
+	tmp := tempDir(t)
+	t.Logf("tmp dir: %s", tmp)
+	for _, name := range []string{"a.c", "b.c", "c.c", "d.c"} {
+		run(t, "cp", "testdata/initial/file.c", filepath.Join(tmp, name))
+	}
+
+	tests := []struct {
+		args      []string
+		wantFiles int // number of files reported as failing
+	}{
+		{[]string{"-j", "1"}, 4},
+		{[]string{"-j", "1", "-fail-fast"}, 1},
+		{[]string{"-j", "3"}, 4},
+	}
+
+	for _, tt := range tests {
+		args := append([]string{"-test.run=TestFailFast", "-check"}, tt.args...)
+		cmd := exec.Command(os.Args[0], append(args, tmp)...)
+		cmd.Env = []string{"RUNME=1"}
+		out, err := cmd.Output()
+		if err == nil {
+			t.Fatalf("check with %q exited with a zero exit code.\n%s", tt.args, out)
+		}
+		if got := len(strings.Fields(string(out))); got != tt.wantFiles {
+			t.Errorf("check with %q reported %d files, want %d:\n%s", tt.args, got, tt.wantFiles, out)
+		}
+	}
+
+	cmd := exec.Command(os.Args[0], "-test.run=TestFailFast", "-check", "-j", "0", tmp)
+	cmd.Env = []string{"RUNME=1"}
+	if out, err := cmd.CombinedOutput(); err == nil || !strings.Contains(string(out), "-j must be at least 1") {
+		t.Errorf("check with -j 0 returned %v:\n%s", err, out)
+	}
+}
+
+func TestCheckStrict(t *testing.T) {
+	if os.Getenv("RUNME") != "" {
+		main()
+		return
+	}
+
+	tmp := tempDir(t)
+	t.Logf("tmp dir: %s", tmp)
+	samplefile := filepath.Join(tmp, "file.c")
+	run(t, "cp", "testdata/expected/file.c", samplefile)
+
+	tests := []struct {
+		args    []string
+		wantErr string
+	}{
+		{[]string{"-l", "apache", "-c", "Bhojpur Consulting Private Limited, India.", "-y", "2018"}, ""},
+		{[]string{"-l", "mit", "-c", "Bhojpur Consulting Private Limited, India.", "-y", "2018"}, "mismatched-license"},
+		{[]string{"-l", "apache", "-c", "Other", "-y", "2018"}, "mismatched-holder"},
+		{[]string{"-l", "apache", "-c", "Bhojpur Consulting Private Limited, India.", "-y", "2022"}, "mismatched-year"},
+	}
+
+	for _, tt := range tests {
+		args := append([]string{"-test.run=TestCheckStrict"}, tt.args...)
+		cmd := exec.Command(os.Args[0], append(args, "-check", "-strict", samplefile)...)
+		cmd.Env = []string{"RUNME=1"}
+		out, err := cmd.CombinedOutput()
+		if tt.wantErr == "" && err != nil {
+			t.Errorf("%v: %v\n%s", tt.args, err, out)
+		}
+		if tt.wantErr != "" && (err == nil || !strings.Contains(string(out), tt.wantErr)) {
+			t.Errorf("%v: got %v, want failure %q\n%s", tt.args, err, tt.wantErr, out)
+		}
+	}
+}
+
+func TestCheckFormat(t *testing.T) {
+	if os.Getenv("RUNME") != "" {
+		main()
+		return
+	}
+
+	tmp := tempDir(t)
+	t.Logf("tmp dir: %s", tmp)
+	run(t, "cp", "testdata/initial/file.c", filepath.Join(tmp, "missing.c"))
+	run(t, "cp", "testdata/expected/file.c", filepath.Join(tmp, "ok.c"))
+	run(t, "cp", "testdata/initial/file.txt", filepath.Join(tmp, "unknown.txt"))
+
+	cmd := exec.Command(os.Args[0],
+		"-test.run=TestCheckFormat",
+		"-check", "-format", "json", tmp,
+	)
+	cmd.Env = []string{"RUNME=1"}
+	out, err := cmd.Output()
+	if err == nil {
+		t.Fatalf("TestCheckFormat exited with a zero exit code.\n%s", out)
+	}
+	var report struct {
+		Results []fileResult `json:"results"`
+	}
+	if err := json.Unmarshal(out, &report); err != nil {
+		t.Fatalf("invalid JSON report: %v\n%s", err, out)
+	}
+	want := []fileResult{
+		{Path: filepath.ToSlash(filepath.Join(tmp, "missing.c")), Status: "missing", Reason: "missing license header"},
+		{Path: filepath.ToSlash(filepath.Join(tmp, "ok.c")), Status: "ok", License: "Apache-2.0"},
+	}
+	if !reflect.DeepEqual(report.Results, want) {
+		t.Errorf("JSON report returned %+v, want %+v", report.Results, want)
+	}
+}
+
 func TestMPL(t *testing.T) {
 	if os.Getenv("RUNME") != "" {
 		main()
@@ -214,262 +693,493 @@ func TestMPL(t *testing.T) {
 	}
 }
 
-func createTempFile(contents string, pattern string) (*os.File, error) {
-	f, err := ioutil.TempFile("", pattern)
+func TestIdentify(t *testing.T) {
+	if os.Getenv("RUNME") != "" {
+		main()
+		return
+	}
+
+	cmd := exec.Command(os.Args[0], "-test.run=TestIdentify", "identify", "-format", "json", "testdata/expected/file.c", "testdata/initial/file.c")
+	cmd.Env = []string{"RUNME=1"}
+	out, err := cmd.Output()
 	if err != nil {
-		return nil, err
+		t.Fatalf("identify returned %v:\n%s", err, out)
+	}
+	var got struct {
+		Files []identification `json:"files"`
 	}
+	// the test binary prints PASS after the report
+	if err := json.NewDecoder(bytes.NewReader(out)).Decode(&got); err != nil {
+		t.Fatalf("identify printed invalid JSON: %v\n%s", err, out)
+	}
+	want := []identification{
+		{Path: "testdata/expected/file.c", License: "Apache-2.0", Confidence: 1, Year: "2018", Holder: "Bhojpur Consulting Private Limited, India"},
+		{Path: "testdata/initial/file.c"},
+	}
+	if !reflect.DeepEqual(got.Files, want) {
+		t.Errorf("identify returned %+v, want %+v", got.Files, want)
+	}
+}
 
-	if err := ioutil.WriteFile(f.Name(), []byte(contents), 0644); err != nil {
-		return nil, err
+func TestCheckForeign(t *testing.T) {
+	if os.Getenv("RUNME") != "" {
+		main()
+		return
 	}
 
-	return f, nil
+	tmp := tempDir(t)
+	run(t, "cp", "testdata/expected/file.c", filepath.Join(tmp, "apache.c"))
+	run(t, "cp", "testdata/initial/file.c", filepath.Join(tmp, "missing.c"))
+	cmd := exec.Command(os.Args[0], "-test.run=TestCheckForeign", "-l", "mit", "-foreign", "-check", tmp)
+	cmd.Env = []string{"RUNME=1"}
+	out, err := cmd.Output()
+	if err == nil {
+		t.Fatalf("check of Apache files with -l mit exited with a zero exit code:\n%s", out)
+	}
+	for _, want := range []string{
+		filepath.Join(tmp, "apache.c") + ": mismatched-license: found Apache-2.0 license header, want MIT",
+		filepath.Join(tmp, "missing.c") + ": missing: missing license header",
+	} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("check output does not contain %q:\n%s", want, out)
+		}
+	}
+
+	cmd = exec.Command(os.Args[0], "-test.run=TestCheckForeign", "-foreign", "-check", filepath.Join(tmp, "apache.c"))
+	cmd.Env = []string{"RUNME=1"}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Errorf("check of an Apache file returned %v:\n%s", err, out)
+	}
 }
 
-func TestLicense(t *testing.T) {
-	tmpl := template.Must(template.New("").Parse("{{.Holder}}{{.Year}}{{.SPDXID}}"))
-	data := LicenseData{Holder: "H", Year: "Y", SPDXID: "S"}
+func TestReuseLint(t *testing.T) {
+	if os.Getenv("RUNME") != "" {
+		main()
+		return
+	}
 
-	tests := []struct {
-		contents     string
-		wantContents string
-		wantUpdated  bool
-	}{
-		{"", "// HYS\n\n", true},
-		{"content", "// HYS\n\ncontent", true},
-
-		// various headers that should be left intact. Many don't make
-		// sense for our temp file extension, but that doesn't matter.
-		{"#!/bin/bash\ncontent", "#!/bin/bash\n// HYS\n\ncontent", true},
-		{"<?xml version='1.0'?>\ncontent", "<?xml version='1.0'?>\n// HYS\n\ncontent", true},
-		{"<!doctype html>\ncontent", "<!doctype html>\n// HYS\n\ncontent", true},
-		{"<!DOCTYPE HTML>\ncontent", "<!DOCTYPE HTML>\n// HYS\n\ncontent", true},
-		{"# encoding: UTF-8\ncontent", "# encoding: UTF-8\n// HYS\n\ncontent", true},
-		{"# frozen_string_literal: true\ncontent", "# frozen_string_literal: true\n// HYS\n\ncontent", true},
-		{"<?php\ncontent", "<?php\n// HYS\n\ncontent", true},
-		{"# escape: `\ncontent", "# escape: `\n// HYS\n\ncontent", true},
-		{"# syntax: docker/dockerfile:1.3\ncontent", "# syntax: docker/dockerfile:1.3\n// HYS\n\ncontent", true},
-
-		// ensure files with existing license or generated files are
-		// skipped. No need to test all permutations of these, since
-		// there are specific tests below.
-		{"// Copyright 2018 Bhojpur Consulting\ncontent", "// Copyright 2018 Bhojpur Consulting\ncontent", false},
-		{"// Code generated by Bhojpur License; DO NOT EDIT.\ncontent", "// Code generated by Bhojpur License; DO NOT EDIT.\ncontent", false},
+	tmp := tempDir(t)
+	run(t, "cp", "testdata/initial/file.c", filepath.Join(tmp, "file.c"))
+	cmd := exec.Command(os.Args[0], "-test.run=TestReuseLint", "lint", tmp)
+	cmd.Env = []string{"RUNME=1"}
+	out, err := cmd.Output()
+	if err == nil || !strings.Contains(string(out), "* Files with license information: 0 / 1\n") {
+		t.Fatalf("lint of a file without header returned %v:\n%s", err, out)
 	}
 
-	for _, tt := range tests {
-		// create temp file with contents
-		f, err := createTempFile(tt.contents, "*.go")
-		if err != nil {
-			t.Error(err)
+	cmd = exec.Command(os.Args[0], "-test.run=TestReuseLint", "-s=reuse", "-l", "MIT", "-c", "Alice", "-y", "2022", tmp)
+	cmd.Env = []string{"RUNME=1"}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("adding REUSE headers returned %v:\n%s", err, out)
+	}
+	b, err := ioutil.ReadFile(filepath.Join(tmp, "file.c"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "/*\n * SPDX-FileCopyrightText: 2022 Alice\n *\n * SPDX-License-Identifier: MIT\n */\n\n"
+	if !strings.HasPrefix(string(b), want) {
+		t.Errorf("file.c begins with:\n%s\nwant:\n%s", b, want)
+	}
+
+	writeFiles(t, tmp, map[string]string{"LICENSES/MIT.txt": "MIT License"})
+	cmd = exec.Command(os.Args[0], "-test.run=TestReuseLint", "lint", tmp)
+	cmd.Env = []string{"RUNME=1"}
+	if out, err := cmd.CombinedOutput(); err != nil || !strings.Contains(string(out), "Congratulations!") {
+		t.Errorf("lint of a compliant project returned %v:\n%s", err, out)
+	}
+}
+
+func TestSidecar(t *testing.T) {
+	if os.Getenv("RUNME") != "" {
+		main()
+		return
+	}
+
+	tmp := tempDir(t)
+	mit, err := header.LicenseText("MIT", header.LicenseData{Year: "2022", Holder: "Alice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFiles(t, tmp, map[string]string{
+		"logo.png":  "\x89PNG\r\n\x1a\n\x00",
+		"data.json": "{}\n",
+		"LICENSE":   string(mit),
+	})
+	run(t, "cp", "testdata/initial/file.c", filepath.Join(tmp, "file.c"))
+
+	// without -sidecar, files without a comment style are not checked
+	cmd := exec.Command(os.Args[0], "-test.run=TestSidecar", "-check", filepath.Join(tmp, "logo.png"))
+	cmd.Env = []string{"RUNME=1"}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("check of a binary file returned %v:\n%s", err, out)
+	}
+	cmd = exec.Command(os.Args[0], "-test.run=TestSidecar", "-sidecar", "-check", tmp)
+	cmd.Env = []string{"RUNME=1"}
+	if out, err := cmd.Output(); err == nil || !strings.Contains(string(out), filepath.Join(tmp, "logo.png")) {
+		t.Fatalf("check of a file without sidecar returned %v:\n%s", err, out)
+	}
+
+	cmd = exec.Command(os.Args[0], "-test.run=TestSidecar", "-sidecar", "-l", "MIT", "-c", "Alice", "-y", "2022", tmp)
+	cmd.Env = []string{"RUNME=1"}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("adding sidecar files returned %v:\n%s", err, out)
+	}
+	want := "SPDX-FileCopyrightText: 2022 Alice\n\nSPDX-License-Identifier: MIT\n"
+	for _, name := range []string{"logo.png", "data.json"} {
+		b, err := ioutil.ReadFile(filepath.Join(tmp, name+".license"))
+		if err != nil || string(b) != want {
+			t.Errorf("sidecar of %s holds %q (%v), want %q", name, b, err, want)
 		}
-		fi, err := f.Stat()
-		if err != nil {
-			t.Error(err)
+	}
+	for _, name := range []string{"LICENSE.license", "file.c.license", "logo.png.license.license"} {
+		if _, err := os.Stat(filepath.Join(tmp, name)); !os.IsNotExist(err) {
+			t.Errorf("%s was created: %v", name, err)
 		}
+	}
+	if b, _ := ioutil.ReadFile(filepath.Join(tmp, "logo.png")); string(b) != "\x89PNG\r\n\x1a\n\x00" {
+		t.Errorf("logo.png was modified: %q", b)
+	}
 
-		// run Bhojpur License
-		updated, err := BhojpurLicense(f.Name(), fi.Mode(), tmpl, data)
-		if err != nil {
-			t.Error(err)
+	// sidecar files are accepted as proof of licensing, with or without -sidecar
+	cmd = exec.Command(os.Args[0], "-test.run=TestSidecar", "-check", "-strict", "-l", "MIT", "-c", "Alice", "-y", "2022", tmp)
+	cmd.Env = []string{"RUNME=1"}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Errorf("check of files with sidecars returned %v:\n%s", err, out)
+	}
+
+	// removing the headers removes the sidecar files, and reports them
+	cmd = exec.Command(os.Args[0], "-test.run=TestSidecar", "-sidecar", "-remove", "-v", "-l", "MIT", "-c", "Alice", tmp)
+	cmd.Env = []string{"RUNME=1"}
+	if out, err := cmd.CombinedOutput(); err != nil || !strings.Contains(string(out), filepath.Join(tmp, "logo.png.license")+" modified") {
+		t.Errorf("removing sidecar files returned %v:\n%s", err, out)
+	}
+	for _, name := range []string{"logo.png.license", "data.json.license"} {
+		if _, err := os.Stat(filepath.Join(tmp, name)); !os.IsNotExist(err) {
+			t.Errorf("%s was not removed: %v", name, err)
 		}
+	}
 
-		// check results
-		if updated != tt.wantUpdated {
-			t.Errorf("Bhojpur License with contents %q returned updated: %t, want %t", tt.contents, updated, tt.wantUpdated)
+	// the files of the repository and of the tool itself get no sidecar
+	repo := tempDir(t)
+	run(t, "git", "init", "-q", repo)
+	writeFiles(t, repo, map[string]string{"logo.png": "\x89PNG\r\n\x1a\n\x00"})
+	run(t, "cp", "testdata/initial/file.c", filepath.Join(repo, "file.c"))
+	for i := 0; i < 2; i++ {
+		cmd = exec.Command(os.Args[0], "-test.run=TestSidecar", "-sidecar", "-backup",
+			"-journal", filepath.Join(repo, "undo.journal"), "-l", "MIT", "-c", "Alice", "-y", "2022", repo)
+		cmd.Env = []string{"RUNME=1"}
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("adding sidecar files to a repository returned %v:\n%s", err, out)
 		}
-		gotContents, err := ioutil.ReadFile(f.Name())
-		if err != nil {
-			t.Error(err)
+	}
+	if _, err := os.Stat(filepath.Join(repo, "logo.png.license")); err != nil {
+		t.Errorf("sidecar of logo.png was not created: %v", err)
+	}
+	for _, name := range []string{"undo.journal.license", "file.c.orig.license"} {
+		if _, err := os.Stat(filepath.Join(repo, name)); !os.IsNotExist(err) {
+			t.Errorf("%s was created: %v", name, err)
 		}
-		if got := string(gotContents); got != tt.wantContents {
-			t.Errorf("Bhojpur License with contents %q returned contents: %q, want %q", tt.contents, got, tt.wantContents)
+	}
+	filepath.Walk(filepath.Join(repo, ".git"), func(path string, fi os.FileInfo, err error) error {
+		if err == nil && strings.HasSuffix(path, header.SidecarSuffix) {
+			t.Errorf("%s was created", path)
 		}
+		return nil
+	})
 
-		// if all tests passed, cleanup temp file
-		if !t.Failed() {
-			_ = os.Remove(f.Name())
-		}
+	cmd = exec.Command(os.Args[0], "-test.run=TestSidecar", "-sidecar", "-patch", filepath.Join(repo, "sidecar.patch"),
+		"-l", "Apache-2.0", "-c", "Bob", "-y", "2023", repo)
+	cmd.Env = []string{"RUNME=1"}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("dry run in a repository returned %v:\n%s", err, out)
+	}
+	if b, err := ioutil.ReadFile(filepath.Join(repo, "sidecar.patch")); err != nil || strings.Contains(string(b), "sidecar.patch.license") {
+		t.Errorf("patch adds a sidecar to itself (%v):\n%s", err, b)
 	}
 }
 
-// Test that Bhojpur License headers are added using the appropriate prefix for
-// different filenames and extensions.
-func TestLicenseHeader(t *testing.T) {
-	tpl := template.Must(template.New("").Parse("{{.Holder}}{{.Year}}{{.SPDXID}}"))
-	data := LicenseData{Holder: "H", Year: "Y", SPDXID: "S"}
+func TestInit(t *testing.T) {
+	if os.Getenv("RUNME") != "" {
+		main()
+		return
+	}
 
-	tests := []struct {
-		paths []string // paths passed to licenseHeader
-		want  string   // expected result of executing template
-	}{
-		{
-			[]string{"f.unknown"},
-			"",
-		},
-		{
-			[]string{"f.c", "f.h", "f.gv", "f.java", "f.scala", "f.kt", "f.kts"},
-			"/*\n * HYS\n */\n\n",
-		},
-		{
-			[]string{"f.js", "f.mjs", "f.cjs", "f.jsx", "f.tsx", "f.css", "f.scss", "f.sass", "f.tf", "f.ts"},
-			"/**\n * HYS\n */\n\n",
-		},
-		{
-			[]string{"f.cc", "f.cpp", "f.cs", "f.go", "f.hcl", "f.hh", "f.hpp", "f.m", "f.mm", "f.proto",
-				"f.rs", "f.swift", "f.dart", "f.groovy", "f.v", "f.sv", "f.php"},
-			"// HYS\n\n",
-		},
-		{
-			[]string{"f.py", "f.sh", "f.yaml", "f.yml", "f.dockerfile", "dockerfile", "f.rb", "gemfile", "f.tcl", "f.bzl", "f.pl"},
-			"# HYS\n\n",
-		},
-		{
-			[]string{"f.el", "f.lisp"},
-			";; HYS\n\n",
-		},
-		{
-			[]string{"f.erl"},
-			"% HYS\n\n",
-		},
-		{
-			[]string{"f.hs", "f.sql", "f.sdl"},
-			"-- HYS\n\n",
-		},
-		{
-			[]string{"f.html", "f.xml", "f.vue", "f.wxi", "f.wxl", "f.wxs"},
-			"<!--\n HYS\n-->\n\n",
-		},
-		{
-			[]string{"f.ml", "f.mli", "f.mll", "f.mly"},
-			"(**\n   HYS\n*)\n\n",
-		},
-		{
-			[]string{"cmakelists.txt", "f.cmake", "f.cmake.in"},
-			"# HYS\n\n",
-		},
-
-		// ensure matches are case insenstive
-		{
-			[]string{"F.PY", "DoCkErFiLe"},
-			"# HYS\n\n",
-		},
+	tmp := tempDir(t)
+	cmd := exec.Command(os.Args[0], "-test.run=TestInit", "init", "-l", "bsd", "-c", "Alice", "-y", "2022", "-name", "example", tmp)
+	cmd.Env = []string{"RUNME=1"}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("init returned %v:\n%s", err, out)
+	}
+	if _, err := os.Stat(filepath.Join(tmp, "NOTICE")); !os.IsNotExist(err) {
+		t.Errorf("init wrote a NOTICE file for the bsd license: %v", err)
+	}
+	cmd = exec.Command(os.Args[0], "-test.run=TestInit", "init", "-notice", "-l", "bsd", "-c", "Alice", "-y", "2022", "-name", "example", tmp)
+	cmd.Env = []string{"RUNME=1"}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("init -notice returned %v:\n%s", err, out)
+	}
+	b, err := ioutil.ReadFile(filepath.Join(tmp, "NOTICE"))
+	if err != nil || !strings.HasPrefix(string(b), "example\nCopyright 2022 Alice\n") {
+		t.Errorf("init wrote NOTICE %q (%v)", b, err)
 	}
 
-	for _, tt := range tests {
-		for _, path := range tt.paths {
-			header, _ := licenseHeader(path, tpl, data)
-			if got := string(header); got != tt.want {
-				t.Errorf("licenseHeader(%q) returned: %q, want: %q", path, got, tt.want)
-			}
+	// the BSD-style headers refer to the LICENSE file, checked with -check
+	run(t, "cp", "testdata/initial/file.c", filepath.Join(tmp, "file.c"))
+	cmd = exec.Command(os.Args[0], "-test.run=TestInit", "-l", "bsd", "-c", "Alice", "-y", "2022", ".")
+	cmd.Dir = tmp
+	cmd.Env = []string{"RUNME=1"}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("adding headers returned %v:\n%s", err, out)
+	}
+	cmd = exec.Command(os.Args[0], "-test.run=TestInit", "-l", "bsd", "-check", ".")
+	cmd.Dir = tmp
+	cmd.Env = []string{"RUNME=1"}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Errorf("check with a LICENSE file returned %v:\n%s", err, out)
+	}
+
+	if err := os.Remove(filepath.Join(tmp, "LICENSE")); err != nil {
+		t.Fatal(err)
+	}
+	cmd = exec.Command(os.Args[0], "-test.run=TestInit", "-l", "bsd", "-check", ".")
+	cmd.Dir = tmp
+	cmd.Env = []string{"RUNME=1"}
+	out, err := cmd.Output()
+	if err == nil || !strings.Contains(string(out), "LICENSE: missing: missing file") {
+		t.Errorf("check without a LICENSE file returned %v:\n%s", err, out)
+	}
+	cmd = exec.Command(os.Args[0], "-test.run=TestInit", "init", "-l", "bsd", "-check", tmp)
+	cmd.Env = []string{"RUNME=1"}
+	if out, err := cmd.CombinedOutput(); err == nil {
+		t.Errorf("init -check without a LICENSE file returned no error:\n%s", out)
+	}
+
+	// with -strict, the LICENSE file of the checked directory must hold the
+	// selected license, whatever the working directory
+	proj := tempDir(t)
+	run(t, "cp", "testdata/initial/file.c", filepath.Join(proj, "file.c"))
+	apache, err := header.LicenseText("Apache-2.0", header.LicenseData{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFiles(t, proj, map[string]string{"LICENSE": string(apache)})
+	for _, args := range [][]string{
+		{"-l", "MIT", "-c", "Alice", "-y", "2022", proj},
+		{"-check", "-l", "MIT", "-c", "Alice", "-y", "2022", proj},
+	} {
+		cmd = exec.Command(os.Args[0], append([]string{"-test.run=TestInit"}, args...)...)
+		cmd.Dir = tmp
+		cmd.Env = []string{"RUNME=1"}
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("%v returned %v:\n%s", args, err, out)
 		}
 	}
+	cmd = exec.Command(os.Args[0], "-test.run=TestInit", "-check", "-strict", "-l", "MIT", "-c", "Alice", "-y", "2022", proj)
+	cmd.Dir = tmp
+	cmd.Env = []string{"RUNME=1"}
+	out, err = cmd.Output()
+	if err == nil || !strings.Contains(string(out), filepath.Join(proj, "LICENSE")+": mismatched-license: found Apache-2.0 license text, want MIT") {
+		t.Errorf("strict check with another LICENSE returned %v:\n%s", err, out)
+	}
 }
 
-// Test that generated files are properly recognized.
-func TestIsGenerated(t *testing.T) {
-	tests := []struct {
-		content string
-		want    bool
-	}{
-		{"", false},
-		{"Generated", false},
-		{"// Code generated by Bhojpur License; DO NOT EDIT.", true},
-		{"/*\n* Code generated by Bhojpur License; DO NOT EDIT.\n*/\n", true},
-		{"DO NOT EDIT! Replaced on runs of cargo-raze", true},
+func TestDeps(t *testing.T) {
+	if os.Getenv("RUNME") != "" {
+		main()
+		return
 	}
 
-	for _, tt := range tests {
-		b := []byte(tt.content)
-		if got := isGenerated(b); got != tt.want {
-			t.Errorf("isGenerated(%q) returned %v, want %v", tt.content, got, tt.want)
+	mit, err := header.LicenseText("MIT", header.LicenseData{Year: "2020", Holder: "A"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmp := tempDir(t)
+	writeFiles(t, tmp, map[string]string{
+		"main/go.mod":                          "module example.com/main\n\nrequire (\n\texample.com/mit v1.0.0\n\texample.com/missing v1.0.0 // indirect\n)\n",
+		"cache/example.com/mit@v1.0.0/LICENSE": string(mit),
+	})
+	env := []string{"RUNME=1", "GOMODCACHE=" + filepath.Join(tmp, "cache")}
+
+	cmd := exec.Command(os.Args[0], "-test.run=TestDeps", "deps", "-format", "json", "-indirect=false", filepath.Join(tmp, "main"))
+	cmd.Env = env
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("deps returned %v:\n%s", err, out)
+	}
+	var got struct {
+		Modules []dependency `json:"modules"`
+	}
+	if err := json.NewDecoder(bytes.NewReader(out)).Decode(&got); err != nil {
+		t.Fatalf("deps output is not JSON: %v\n%s", err, out)
+	}
+	if len(got.Modules) != 1 || got.Modules[0].Module != "example.com/mit" || got.Modules[0].License != "MIT" {
+		t.Errorf("deps returned %+v", got.Modules)
+	}
+
+	cmd = exec.Command(os.Args[0], "-test.run=TestDeps", "deps", filepath.Join(tmp, "main"))
+	cmd.Env = env
+	out, err = cmd.CombinedOutput()
+	if err == nil || !strings.Contains(string(out), "1 of 2 modules could not be inspected") {
+		t.Errorf("deps with a missing module returned %v:\n%s", err, out)
+	}
+}
+
+func TestSBOM(t *testing.T) {
+	if os.Getenv("RUNME") != "" {
+		main()
+		return
+	}
+
+	tmp := tempDir(t)
+	writeFiles(t, tmp, map[string]string{
+		"go.mod":  "module example.com/main\n",
+		"main.go": "// SPDX-License-Identifier: MIT\npackage main\n",
+	})
+	out := filepath.Join(tmp, "bom.cdx.json")
+	for i := 0; i < 2; i++ {
+		cmd := exec.Command(os.Args[0], "-test.run=TestSBOM", "sbom", "-format", "cyclonedx", "-o", out, tmp)
+		cmd.Env = []string{"RUNME=1", "SOURCE_DATE_EPOCH=1641092645"}
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("sbom returned %v:\n%s", err, out)
 		}
 	}
+	b, err := ioutil.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc cdxDocument
+	if err := json.Unmarshal(b, &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.Metadata.Timestamp != "2022-01-02T03:04:05Z" || len(doc.Components) != 2 || doc.Components[1].Name != "main.go" {
+		t.Errorf("sbom wrote %s", b)
+	}
+
+	cmd := exec.Command(os.Args[0], "-test.run=TestSBOM", "sbom", "-format", "xml", tmp)
+	cmd.Env = []string{"RUNME=1"}
+	if out, err := cmd.CombinedOutput(); err == nil || !strings.Contains(string(out), `-format "xml" is not one of`) {
+		t.Errorf("sbom with an unknown format returned %v:\n%s", err, out)
+	}
 }
 
-// Test that existing license headers are identified.
-func TestHasLicense(t *testing.T) {
-	tests := []struct {
-		content string
-		want    bool
-	}{
-		{"", false},
-		{"This is my license", false},
-		{"This code is released into the public domain.", false},
-		{"SPDX: MIT", false},
+func TestPolicy(t *testing.T) {
+	if os.Getenv("RUNME") != "" {
+		main()
+		return
+	}
+
+	tmp := tempDir(t)
+	writeFiles(t, tmp, map[string]string{
+		".license-policy.yaml": "license: Apache-2.0\nreview: [MPL-2.0]\nincompatible:\n  Apache-2.0: [GPL-3.0-*]\n",
+		"a.go":                 "// SPDX-License-Identifier: Apache-2.0\npackage a\n",
+		"b.go":                 "// SPDX-License-Identifier: MPL-2.0\npackage a\n",
+	})
+	cmd := exec.Command(os.Args[0], "-test.run=TestPolicy", "policy", "check", tmp)
+	cmd.Env = []string{"RUNME=1"}
+	out, err := cmd.Output()
+	if err != nil || !strings.HasPrefix(string(out), "b.go: review: MPL-2.0 requires a review\n") {
+		t.Errorf("policy check returned %v:\n%s", err, out)
+	}
+	cmd = exec.Command(os.Args[0], "-test.run=TestPolicy", "policy", "check", "-strict", "-format", "json", tmp)
+	cmd.Env = []string{"RUNME=1"}
+	out, err = cmd.Output()
+	var report struct {
+		Results []fileResult `json:"results"`
+	}
+	if err == nil || json.NewDecoder(bytes.NewReader(out)).Decode(&report) != nil || len(report.Results) != 2 {
+		t.Errorf("policy check -strict returned %v:\n%s", err, out)
+	}
 
-		{"Copyright 2018", true},
-		{"CoPyRiGhT 2018", true},
-		{"Subject to the terms of the Mozilla Public License", true},
-		{"SPDX-License-Identifier: MIT", true},
-		{"spdx-license-identifier: MIT", true},
+	writeFiles(t, tmp, map[string]string{"c.go": "// SPDX-License-Identifier: GPL-3.0-or-later\npackage a\n"})
+	cmd = exec.Command(os.Args[0], "-test.run=TestPolicy", "policy", "check", tmp)
+	cmd.Env = []string{"RUNME=1"}
+	out, err = cmd.Output()
+	if err == nil || !strings.Contains(string(out), "c.go: incompatible: GPL-3.0-or-later is incompatible with the Apache-2.0 license of the project\n") {
+		t.Errorf("policy check with an incompatible license returned %v:\n%s", err, out)
 	}
 
-	for _, tt := range tests {
-		b := []byte(tt.content)
-		if got := hasLicense(b); got != tt.want {
-			t.Errorf("hasLicense(%q) returned %v, want %v", tt.content, got, tt.want)
-		}
+	// without license in the policy, the project license is read from the
+	// configuration file of the checked directory, or else given with -l
+	proj := tempDir(t)
+	writeFiles(t, proj, map[string]string{
+		".license-policy.yaml": "incompatible:\n  MIT: [GPL-3.0-*]\n",
+		"c.go":                 "// SPDX-License-Identifier: GPL-3.0-or-later\npackage a\n",
+	})
+	cmd = exec.Command(os.Args[0], "-test.run=TestPolicy", "-l", "MIT", "policy", "check", proj)
+	cmd.Env = []string{"RUNME=1"}
+	out, err = cmd.Output()
+	if err == nil || !strings.Contains(string(out), "c.go: incompatible: GPL-3.0-or-later is incompatible with the MIT license of the project\n") {
+		t.Errorf("policy check with -l returned %v:\n%s", err, out)
+	}
+	writeFiles(t, proj, map[string]string{".license-policy.yaml": "incompatible:\n  BSD-3-Clause: [GPL-3.0-*]\n"})
+	cmd = exec.Command(os.Args[0], "-test.run=TestPolicy", "-l", "bsd", "policy", "check", proj)
+	cmd.Env = []string{"RUNME=1"}
+	out, err = cmd.Output()
+	if err == nil || !strings.Contains(string(out), "c.go: incompatible: GPL-3.0-or-later is incompatible with the BSD-3-Clause license of the project\n") {
+		t.Errorf("policy check with -l bsd returned %v:\n%s", err, out)
+	}
+	writeFiles(t, proj, map[string]string{".license-policy.yaml": "incompatible:\n  MIT: [GPL-3.0-*]\n"})
+	writeFiles(t, proj, map[string]string{".license.yaml": "license: MIT\n"})
+	cmd = exec.Command(os.Args[0], "-test.run=TestPolicy", "policy", "check", proj)
+	cmd.Dir = tmp
+	cmd.Env = []string{"RUNME=1"}
+	out, err = cmd.Output()
+	if err == nil || !strings.Contains(string(out), "c.go: incompatible: GPL-3.0-or-later is incompatible with the MIT license of the project\n") {
+		t.Errorf("policy check with a configuration file returned %v:\n%s", err, out)
 	}
 }
 
-func TestFileMatches(t *testing.T) {
-	tests := []struct {
-		pattern   string
-		path      string
-		wantMatch bool
-	}{
-		// basic single directory patterns
-		{"", "file.c", false},
-		{"*.c", "file.h", false},
-		{"*.c", "file.c", true},
-
-		// subdirectory patterns
-		{"*.c", "vendor/file.c", false},
-		{"**/*.c", "vendor/file.c", true},
-		{"vendor/**", "vendor/file.c", true},
-		{"vendor/**/*.c", "vendor/file.c", true},
-		{"vendor/**/*.c", "vendor/a/b/file.c", true},
-
-		// single character "?" match
-		{"*.?", "file.c", true},
-		{"*.?", "file.go", false},
-		{"*.??", "file.c", false},
-		{"*.??", "file.go", true},
-
-		// character classes - sets and ranges
-		{"*.[ch]", "file.c", true},
-		{"*.[ch]", "file.h", true},
-		{"*.[ch]", "file.ch", false},
-		{"*.[a-z]", "file.c", true},
-		{"*.[a-z]", "file.h", true},
-		{"*.[a-z]", "file.go", false},
-		{"*.[a-z]", "file.R", false},
-
-		// character classes - negations
-		{"*.[^ch]", "file.c", false},
-		{"*.[^ch]", "file.h", false},
-		{"*.[^ch]", "file.R", true},
-		{"*.[!ch]", "file.c", false},
-		{"*.[!ch]", "file.h", false},
-		{"*.[!ch]", "file.R", true},
-
-		// comma-separated alternative matches
-		{"*.{c,go}", "file.c", true},
-		{"*.{c,go}", "file.go", true},
-		{"*.{c,go}", "file.h", false},
-
-		// negating alternative matches
-		{"*.[^{c,go}]", "file.c", false},
-		{"*.[^{c,go}]", "file.go", false},
-		{"*.[^{c,go}]", "file.h", true},
+func TestSignVerify(t *testing.T) {
+	if os.Getenv("RUNME") != "" {
+		main()
+		return
 	}
 
-	for _, tt := range tests {
-		patterns := []string{tt.pattern}
-		if got := fileMatches(tt.path, patterns); got != tt.wantMatch {
-			t.Errorf("fileMatches(%q, %q) returned %v, want %v", tt.path, patterns, got, tt.wantMatch)
+	tmp := tempDir(t)
+	key := filepath.Join(tmp, "signing.key")
+	writeFiles(t, tmp, map[string]string{"program": "program binary"})
+	program := filepath.Join(tmp, "program")
+	for _, args := range [][]string{
+		{"keygen", key},
+		{"sign", "-key", key, program},
+		{"verify", "-key", key + ".pub", program},
+	} {
+		cmd := exec.Command(os.Args[0], append([]string{"-test.run=TestSignVerify"}, args...)...)
+		cmd.Env = []string{"RUNME=1"}
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("%s returned %v:\n%s", args[0], err, out)
 		}
 	}
+
+	writeFiles(t, tmp, map[string]string{"program": "patched binary"})
+	cmd := exec.Command(os.Args[0], "-test.run=TestSignVerify", "verify", "-key", key+".pub", program)
+	cmd.Env = []string{"RUNME=1"}
+	out, err := cmd.CombinedOutput()
+	if err == nil || !strings.Contains(string(out), "program: file does not match the signed hash") {
+		t.Errorf("verify of a modified file returned %v:\n%s", err, out)
+	}
+}
+
+func TestCommandPath(t *testing.T) {
+	if os.Getenv("RUNME") != "" {
+		main()
+		return
+	}
+
+	// a directory named like a command is processed as files
+	tmp := tempDir(t)
+	writeFiles(t, tmp, map[string]string{"init/main.go": "package main\n"})
+	cmd := exec.Command(os.Args[0], "-test.run=TestCommandPath", "-l", "MIT", "-c", "Jane Doe", "-y", "2022", "init")
+	cmd.Dir = tmp
+	cmd.Env = []string{"RUNME=1"}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("%v\n%s", err, out)
+	}
+	b, err := ioutil.ReadFile(filepath.Join(tmp, "init", "main.go"))
+	if err != nil || !strings.Contains(string(b), "Copyright (c) 2022 Jane Doe") {
+		t.Errorf("init/main.go is %q (%v), want a license header", b, err)
+	}
+	if _, err := os.Stat(filepath.Join(tmp, "LICENSE")); !os.IsNotExist(err) {
+		t.Errorf("the init command was run (%v)", err)
+	}
 }
@@ -0,0 +1,399 @@
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/bhojpur/license/header"
+	"gopkg.in/yaml.v3"
+)
+
+// policyFile is the name of the license policy file, looked up in the
+// project directory and its parents up to the root of the repository.
+const policyFile = ".license-policy.yaml"
+
+// policy lists the licenses that the files and dependencies of a project
+// may use. Its entries are SPDX license identifiers such as MIT, simple
+// expressions with an exception, or patterns such as GPL-* where * matches
+// any characters.
+type policy struct {
+	License      string              `yaml:"license"`      // license of the project, defaults to the one of the configuration
+	Allow        []string            `yaml:"allow"`        // allowed licenses, all but the denied ones if empty
+	Deny         []string            `yaml:"deny"`         // denied licenses
+	Review       []string            `yaml:"review"`       // licenses requiring a review before use
+	Incompatible map[string][]string `yaml:"incompatible"` // licenses denied in projects of the license of the key
+
+	project *header.Expression // parsed License
+}
+
+// Outcomes of evaluating a license against a policy, from best to worst.
+const (
+	policyAllowed      = "ok"
+	policyReview       = "review"
+	policyNotAllowed   = "not-allowed"
+	policyIncompatible = "incompatible"
+	policyDenied       = "denied"
+	policyInvalid      = "invalid"
+	policyError        = "error"
+)
+
+var policyRank = map[string]int{
+	policyAllowed:      0,
+	policyReview:       1,
+	policyNotAllowed:   2,
+	policyIncompatible: 3,
+	policyDenied:       4,
+	policyInvalid:      5,
+	policyError:        6,
+}
+
+// loadPolicy reads and validates the policy file at path. Identifiers are
+// replaced by their canonical form.
+func loadPolicy(path string) (*policy, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var p policy
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	dec.KnownFields(true)
+	if err := dec.Decode(&p); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if p.License != "" {
+		if p.project, err = header.ParseExpression(p.License); err != nil {
+			return nil, fmt.Errorf("%s: license: %w", path, err)
+		}
+	}
+	lists := []struct {
+		name    string
+		entries []string
+	}{{"allow", p.Allow}, {"deny", p.Deny}, {"review", p.Review}}
+	for _, l := range lists {
+		if err := canonicalPolicyEntries(l.entries); err != nil {
+			return nil, fmt.Errorf("%s: %s: %w", path, l.name, err)
+		}
+	}
+	incompatible := make(map[string][]string)
+	for id, entries := range p.Incompatible {
+		canonical, _, ok := header.LookupSPDXID(id)
+		if !ok || strings.Contains(id, "*") {
+			return nil, fmt.Errorf("%s: incompatible: %q is not an SPDX license identifier", path, id)
+		}
+		if err := canonicalPolicyEntries(entries); err != nil {
+			return nil, fmt.Errorf("%s: incompatible: %s: %w", path, id, err)
+		}
+		incompatible[canonical] = append(incompatible[canonical], entries...)
+	}
+	p.Incompatible = incompatible
+	return &p, nil
+}
+
+// canonicalPolicyEntries validates the policy entries and replaces them by
+// their canonical form.
+func canonicalPolicyEntries(entries []string) error {
+	for i, s := range entries {
+		if strings.Contains(s, "*") {
+			if _, err := path.Match(s, ""); err != nil {
+				return fmt.Errorf("pattern %q is not valid", s)
+			}
+			continue
+		}
+		e, err := header.ParseExpression(s)
+		if err != nil {
+			return err
+		}
+		if e.Op != "" {
+			return fmt.Errorf("%q is not a single license", s)
+		}
+		entries[i] = e.String()
+	}
+	return nil
+}
+
+// policyMatch reports whether the simple expression s, or the license
+// identifier id, matches one of the entries.
+func policyMatch(entries []string, s string) bool {
+	for _, entry := range entries {
+		if entry == s {
+			return true
+		}
+		if !strings.Contains(entry, "*") {
+			continue
+		}
+		if ok, _ := path.Match(strings.ToLower(entry), strings.ToLower(s)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// incompatible returns the license of the project that the simple
+// expression s is incompatible with, if any.
+func (p *policy) incompatible(s string) string {
+	if p.project == nil {
+		return ""
+	}
+	for _, id := range p.project.Licenses() {
+		if policyMatch(p.Incompatible[id], s) {
+			return id
+		}
+	}
+	return ""
+}
+
+// evaluate returns the outcome of the license expression e under the
+// policy, and the reasons for it unless it is allowed. Operands of AND must
+// all be allowed, and one operand of OR is enough.
+func (p *policy) evaluate(e *header.Expression) (string, []string) {
+	if e.Op == "" {
+		s := e.String()
+		if e.Exception != "" && !p.mentions(s) {
+			// the license rules apply unless the exception is listed
+			s = e.License
+		}
+		if id := p.incompatible(s); id != "" {
+			return policyIncompatible, []string{fmt.Sprintf("%s is incompatible with the %s license of the project", e, id)}
+		}
+		switch {
+		case policyMatch(p.Deny, s):
+			return policyDenied, []string{fmt.Sprintf("%s is denied", e)}
+		case policyMatch(p.Review, s):
+			return policyReview, []string{fmt.Sprintf("%s requires a review", e)}
+		case len(p.Allow) == 0 || policyMatch(p.Allow, s):
+			return policyAllowed, nil
+		}
+		return policyNotAllowed, []string{fmt.Sprintf("%s is not an allowed license", e)}
+	}
+
+	outcomes := make([]string, len(e.Operands))
+	reasons := make([][]string, len(e.Operands))
+	for i, o := range e.Operands {
+		outcomes[i], reasons[i] = p.evaluate(o)
+	}
+	best, worst := outcomes[0], outcomes[0]
+	for _, o := range outcomes[1:] {
+		if policyRank[o] < policyRank[best] {
+			best = o
+		}
+		if policyRank[o] > policyRank[worst] {
+			worst = o
+		}
+	}
+	outcome := worst
+	if e.Op == "OR" {
+		outcome = best
+	}
+	if outcome == policyAllowed {
+		return outcome, nil
+	}
+	// explain the operands that decided the outcome, or all the choices
+	var why []string
+	for i, o := range outcomes {
+		if e.Op == "OR" || o == outcome {
+			why = append(why, reasons[i]...)
+		}
+	}
+	return outcome, why
+}
+
+// mentions reports whether the simple expression s is listed as such by
+// the policy, rather than through its license identifier.
+func (p *policy) mentions(s string) bool {
+	if policyMatch(p.Allow, s) || policyMatch(p.Deny, s) || policyMatch(p.Review, s) {
+		return true
+	}
+	for _, entries := range p.Incompatible {
+		if policyMatch(entries, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// check evaluates the licenses of the files of the project at root, found
+// in their tags or identified in their header, and of its dependencies, returning a result for each file with licensing
+// information and each dependency, sorted by path. Dependencies are named
+// after their module path and version.
+func (p *policy) check(root string, deps []dependency) ([]fileResult, error) {
+	var results []fileResult
+	err := scanProject(root, func(path, name string, tags header.Tags, err error) {
+		if err != nil {
+			results = append(results, fileResult{Path: name, Status: policyError, Reason: err.Error()})
+			return
+		}
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			results = append(results, fileResult{Path: name, Status: policyError, Reason: err.Error()})
+			return
+		}
+		for _, l := range fileLicenses(b, tags) {
+			results = append(results, p.result(name, l))
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range deps {
+		name := d.Module + "@" + d.Version
+		switch {
+		case d.Error != "":
+			results = append(results, fileResult{Path: name, Status: policyError, Reason: d.Error})
+		case d.License == "" && d.Closest != "":
+			results = append(results, fileResult{Path: name, Status: policyReview, Reason: fmt.Sprintf("license not identified, closest to %s", d.Closest)})
+		case d.License == "":
+			results = append(results, fileResult{Path: name, Status: policyReview, Reason: "no license file"})
+		default:
+			results = append(results, p.result(name, d.License))
+		}
+	}
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Path < results[j].Path })
+	return results, nil
+}
+
+// result returns the outcome of the license expression l of the file or
+// module name.
+func (p *policy) result(name, l string) fileResult {
+	e, err := header.ParseExpression(l)
+	if err != nil {
+		return fileResult{Path: name, Status: policyInvalid, Reason: err.Error(), License: l}
+	}
+	outcome, reasons := p.evaluate(e)
+	return fileResult{Path: name, Status: outcome, Reason: strings.Join(reasons, ", "), License: e.String()}
+}
+
+// runPolicy implements the policy command. Its only subcommand, check,
+// verifies that the licenses of the files and Go module dependencies of a
+// project follow the policy file, and fails like -check if they do not.
+func runPolicy(args []string) error {
+	fset := flag.NewFlagSet("policy check", flag.ExitOnError)
+	format := fset.String("format", "text", "output format: text or json")
+	policyPath := fset.String("policy", "", "policy file (default: "+policyFile+" in the directory or one of its parents)")
+	withDeps := fset.Bool("deps", true, "check the Go modules required by the go.mod file of the directory, if any")
+	indirect := fset.Bool("indirect", true, "check the modules only required indirectly")
+	strictReview := fset.Bool("strict", false, "fail on licenses requiring a review")
+	fset.Usage = func() {
+		fmt.Fprintf(fset.Output(), "Usage: license policy check [flags] [directory]\n\nFlags:\n")
+		fset.PrintDefaults()
+	}
+	if len(args) == 0 || args[0] != "check" {
+		fset.Usage()
+		os.Exit(1)
+	}
+	fset.Parse(args[1:])
+	if fset.NArg() > 1 {
+		fset.Usage()
+		os.Exit(1)
+	}
+	if *format != "text" && *format != "json" {
+		return fmt.Errorf("-format %q is not one of text, json", *format)
+	}
+	root := "."
+	if fset.NArg() == 1 {
+		root = fset.Arg(0)
+	}
+
+	cfg, err := loadProjectConfig(root)
+	if err != nil {
+		return err
+	}
+	if *policyPath == "" {
+		if *policyPath, err = findProjectFile(root, policyFile); err != nil {
+			return err
+		}
+		if *policyPath == "" {
+			return fmt.Errorf("no %s file found, see -policy", policyFile)
+		}
+	}
+	p, err := loadPolicy(*policyPath)
+	if err != nil {
+		return err
+	}
+	if p.project == nil && (cfg.License != "" || setFlags()["l"]) {
+		// the configuration has been applied to -l, which takes precedence
+		lic, err := header.NewLicense(*license, "", header.SPDXOn, header.LicenseData{})
+		if err != nil {
+			return err
+		}
+		if p.project, err = header.ParseExpression(header.SPDXLicense(lic.Data.SPDXID)); err != nil {
+			return err
+		}
+	}
+
+	var deps []dependency
+	if *withDeps {
+		if _, err := os.Stat(filepath.Join(root, "go.mod")); err == nil {
+			if deps, err = loadDependencies(root, *indirect); err != nil {
+				return err
+			}
+		}
+	}
+	results, err := p.check(root, deps)
+	if err != nil {
+		return err
+	}
+
+	violations := 0
+	for _, res := range results {
+		if res.Status != policyAllowed && (res.Status != policyReview || *strictReview) {
+			violations++
+		}
+	}
+	if *format == "json" {
+		err = writeJSON(os.Stdout, struct {
+			Results []fileResult `json:"results"`
+		}{results})
+	} else {
+		err = writePolicyResults(os.Stdout, results)
+	}
+	if err != nil {
+		return err
+	}
+	if violations > 0 {
+		return fmt.Errorf("%d of %d licenses violate the policy", violations, len(results))
+	}
+	return nil
+}
+
+// writePolicyResults writes the results that are not allowed to w, one per
+// line with their outcome and its reasons.
+func writePolicyResults(w io.Writer, results []fileResult) error {
+	for _, res := range results {
+		if res.Status == policyAllowed {
+			continue
+		}
+		if _, err := fmt.Fprintf(w, "%s: %s: %s\n", res.Path, res.Status, res.Reason); err != nil {
+			return err
+		}
+	}
+	return nil
+}
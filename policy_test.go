@@ -0,0 +1,210 @@
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bhojpur/license/header"
+)
+
+func TestLoadPolicy(t *testing.T) {
+	tmp := tempDir(t)
+	tests := []struct {
+		description string
+		policy      string
+		want        *policy // nil if an error is expected
+		wantErr     string
+	}{
+		{
+			"empty policy",
+			"",
+			&policy{Incompatible: map[string][]string{}},
+			"",
+		},
+		{
+			"canonical identifiers",
+			"license: apache-2.0\nallow: [mit, BSD-*]\ndeny: [agpl-3.0-only]\nreview: [gpl-2.0-only WITH classpath-exception-2.0]\nincompatible:\n  apache-2.0: [GPL-*]\n",
+			&policy{
+				License:      "apache-2.0",
+				Allow:        []string{"MIT", "BSD-*"},
+				Deny:         []string{"AGPL-3.0-only"},
+				Review:       []string{"GPL-2.0-only WITH Classpath-exception-2.0"},
+				Incompatible: map[string][]string{"Apache-2.0": {"GPL-*"}},
+			},
+			"",
+		},
+		{"unknown field", "allowed: [MIT]\n", nil, "field allowed not found"},
+		{"unknown license", "deny: [Apache2]\n", nil, `deny: unknown license "Apache2", did you mean "Apache-2.0"?`},
+		{"compound expression", "allow: [MIT OR ISC]\n", nil, `allow: "MIT OR ISC" is not a single license`},
+		{"bad pattern", "review: [\"GPL-[*\"]\n", nil, `review: pattern "GPL-[*" is not valid`},
+		{"bad project license", "license: MIT OR\n", nil, "license: invalid license expression"},
+		{"pattern as project license", "incompatible:\n  Apache-*: [GPL-*]\n", nil, `incompatible: "Apache-*" is not an SPDX license identifier`},
+	}
+	for i, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			path := filepath.Join(tmp, fmt.Sprintf("policy%d.yaml", i))
+			if err := ioutil.WriteFile(path, []byte(tt.policy), 0644); err != nil {
+				t.Fatal(err)
+			}
+			p, err := loadPolicy(path)
+			if tt.want == nil {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("loadPolicy returned error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			p.project = nil
+			if !reflect.DeepEqual(p, tt.want) {
+				t.Errorf("loadPolicy returned %+v, want %+v", p, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolicyEvaluate(t *testing.T) {
+	p := &policy{
+		Allow:        []string{"MIT", "Apache-2.0", "BSD-*", "GPL-2.0-only WITH Classpath-exception-2.0"},
+		Deny:         []string{"AGPL-*"},
+		Review:       []string{"MPL-2.0"},
+		Incompatible: map[string][]string{"Apache-2.0": {"GPL-*"}},
+	}
+	tests := []struct {
+		license string
+		want    string
+		reasons string
+	}{
+		{"MIT", policyAllowed, ""},
+		{"bsd-3-clause", policyAllowed, ""},
+		{"MPL-2.0", policyReview, "MPL-2.0 requires a review"},
+		{"ISC", policyNotAllowed, "ISC is not an allowed license"},
+		{"AGPL-3.0-or-later", policyDenied, "AGPL-3.0-or-later is denied"},
+		{"MIT OR AGPL-3.0-only", policyAllowed, ""},
+		{"MIT AND MPL-2.0", policyReview, "MPL-2.0 requires a review"},
+		{"ISC OR AGPL-3.0-only", policyNotAllowed, "ISC is not an allowed license, AGPL-3.0-only is denied"},
+		{"MPL-2.0 AND (ISC OR MIT)", policyReview, "MPL-2.0 requires a review"},
+		{"GPL-2.0-only WITH Classpath-exception-2.0", policyAllowed, ""},
+		{"GPL-2.0-only", policyNotAllowed, "GPL-2.0-only is not an allowed license"},
+		{"BSD-3-Clause WITH LLVM-exception", policyAllowed, ""},
+	}
+	for _, tt := range tests {
+		e, err := header.ParseExpression(tt.license)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, reasons := p.evaluate(e)
+		if got != tt.want || strings.Join(reasons, ", ") != tt.reasons {
+			t.Errorf("evaluate(%q) returned %q, %q, want %q, %q", tt.license, got, reasons, tt.want, tt.reasons)
+		}
+	}
+
+	// with an Apache-2.0 project, the GPL licenses are incompatible
+	p.project, _ = header.ParseExpression("Apache-2.0")
+	for _, l := range []string{"GPL-3.0-only", "GPL-2.0-only WITH Classpath-exception-2.0"} {
+		e, _ := header.ParseExpression(l)
+		got, reasons := p.evaluate(e)
+		want := l + " is incompatible with the Apache-2.0 license of the project"
+		if got != policyIncompatible || len(reasons) != 1 || reasons[0] != want {
+			t.Errorf("evaluate(%q) returned %q, %q, want incompatible", l, got, reasons)
+		}
+	}
+}
+
+func TestPolicyCheck(t *testing.T) {
+	// a GPL-3.0 header with the license notice only, without SPDX tag
+	gpl, err := header.NewLicense("GPL-3.0-only", "", header.SPDXOff, header.LicenseData{Year: "2022", Holder: "Jane Doe"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	notice, err := header.Render("gpl.go", gpl.Template, gpl.Data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmp := tempDir(t)
+	writeFiles(t, tmp, map[string]string{
+		"a.go":        "// SPDX-License-Identifier: MIT\npackage a\n",
+		"b.go":        "// SPDX-License-Identifier: GPL-3.0-only\npackage a\n",
+		"c.go":        "// SPDX-License-Identifier: MIT AND Apache2\npackage a\n",
+		"gpl.go":      string(notice) + "\npackage a\n",
+		"untagged.go": "package a\n",
+	})
+	p := &policy{Deny: []string{"GPL-*"}}
+	deps := []dependency{
+		{Module: "example.com/a", Version: "v1.0.0", License: "MIT"},
+		{Module: "example.com/b", Version: "v1.0.0", Closest: "ISC"},
+		{Module: "example.com/c", Version: "v1.0.0"},
+		{Module: "example.com/d", Version: "v1.0.0", Error: "not found in the module cache"},
+	}
+	results, err := p.check(tmp, deps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []fileResult{
+		{Path: "a.go", Status: policyAllowed, License: "MIT"},
+		{Path: "b.go", Status: policyDenied, Reason: "GPL-3.0-only is denied", License: "GPL-3.0-only"},
+		{Path: "c.go", Status: policyInvalid, Reason: `invalid license expression "MIT AND Apache2": unknown license "Apache2", did you mean "Apache-2.0"?`, License: "MIT AND Apache2"},
+		{Path: "example.com/a@v1.0.0", Status: policyAllowed, License: "MIT"},
+		{Path: "example.com/b@v1.0.0", Status: policyReview, Reason: "license not identified, closest to ISC"},
+		{Path: "example.com/c@v1.0.0", Status: policyReview, Reason: "no license file"},
+		{Path: "example.com/d@v1.0.0", Status: policyError, Reason: "not found in the module cache"},
+		{Path: "gpl.go", Status: policyDenied, Reason: "GPL-3.0-only is denied", License: "GPL-3.0-only"},
+	}
+	if !reflect.DeepEqual(results, want) {
+		t.Errorf("check returned\n%+v\nwant\n%+v", results, want)
+	}
+}
+
+func TestPolicyCheckLegacyNotice(t *testing.T) {
+	// the legacy BSD-style notice written by -l bsd is a BSD-3-Clause header
+	bsd, err := header.NewLicense("bsd", "", header.SPDXOff, header.LicenseData{Year: "2022", Holder: "Jane Doe"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	notice, err := header.Render("bsd.go", bsd.Template, bsd.Data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmp := tempDir(t)
+	writeFiles(t, tmp, map[string]string{
+		"a.go":   "// SPDX-License-Identifier: MIT\npackage a\n",
+		"bsd.go": string(notice) + "\npackage a\n",
+	})
+	p := &policy{Allow: []string{"MIT", "BSD-3-Clause"}}
+	results, err := p.check(tmp, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []fileResult{
+		{Path: "a.go", Status: policyAllowed, License: "MIT"},
+		{Path: "bsd.go", Status: policyAllowed, License: "BSD-3-Clause"},
+	}
+	if !reflect.DeepEqual(results, want) {
+		t.Errorf("check returned\n%+v\nwant\n%+v", results, want)
+	}
+}
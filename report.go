@@ -0,0 +1,279 @@
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/bhojpur/license/header"
+)
+
+// reportFormats lists the formats supported by the -format flag.
+var reportFormats = []string{"json", "sarif", "junit"}
+
+func validFormat(format string) bool {
+	for _, f := range reportFormats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// fileResult is the result of checking a single file.
+type fileResult struct {
+	Path    string `json:"path"`
+	Status  string `json:"status"`
+	Reason  string `json:"reason,omitempty"`
+	License string `json:"license,omitempty"` // license detected in the file, if recognized
+}
+
+// checkReport collects the results of checking files. It is safe for
+// concurrent use.
+type checkReport struct {
+	mu      sync.Mutex
+	results []fileResult
+}
+
+// add records the result of checking the file at path.
+func (r *checkReport) add(path string, res header.CheckResult) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.results = append(r.results, fileResult{
+		Path:    filepath.ToSlash(path),
+		Status:  res.Status.String(),
+		Reason:  res.Reason,
+		License: res.License,
+	})
+}
+
+// write writes the results sorted by path to w in the given format.
+func (r *checkReport) write(w io.Writer, format string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	sort.Slice(r.results, func(i, j int) bool { return r.results[i].Path < r.results[j].Path })
+
+	switch format {
+	case "json":
+		return writeJSON(w, struct {
+			Results []fileResult `json:"results"`
+		}{r.results})
+	case "sarif":
+		return writeJSON(w, sarifReport(r.results))
+	case "junit":
+		return writeJUnit(w, r.results)
+	}
+	return fmt.Errorf("unknown report format %q", format)
+}
+
+func writeJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// SARIF 2.1.0 log, limited to the properties used by the report. See
+// https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId,omitempty"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+// sarifRules describes the failures reported by -check.
+var sarifRules = []sarifRule{
+	{header.StatusMissing.String(), sarifMessage{"The file has no license header."}},
+	{header.StatusMismatchedLicense.String(), sarifMessage{"The license header is for another license."}},
+	{header.StatusMismatchedHolder.String(), sarifMessage{"The license header names another copyright holder."}},
+	{header.StatusMismatchedYear.String(), sarifMessage{"The copyright years of the license header are out of date."}},
+	{header.StatusError.String(), sarifMessage{"The file could not be checked."}},
+}
+
+// sarifReport returns a SARIF log with a result for each failed check,
+// located at the first line of the file where the header belongs. Files are
+// located relative to the root of the source tree, see sarifFileLocation.
+func sarifReport(results []fileResult) sarifLog {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "license",
+			InformationURI: "https://github.com/bhojpur/license",
+			Rules:          sarifRules,
+		}},
+		Results: []sarifResult{},
+	}
+	for _, res := range results {
+		if res.Status == header.StatusOK.String() {
+			continue
+		}
+		run.Results = append(run.Results, sarifResult{
+			RuleID:  res.Status,
+			Level:   "error",
+			Message: sarifMessage{res.Reason},
+			Locations: []sarifLocation{{
+				PhysicalLocation: sarifPhysicalLocation{
+					ArtifactLocation: sarifFileLocation(res.Path),
+					Region:           sarifRegion{StartLine: 1},
+				},
+			}},
+		})
+	}
+	return sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	}
+}
+
+// sarifFileLocation returns the location of the file at the slash
+// separated path relative to %SRCROOT%, the root of its git repository or
+// else the working directory, as code scanning tools expect. Files outside
+// of it are located by their absolute file URI.
+func sarifFileLocation(path string) sarifArtifactLocation {
+	abs, err := filepath.Abs(filepath.FromSlash(path))
+	if err != nil {
+		return sarifArtifactLocation{URI: path}
+	}
+	root := repoRoot(filepath.Dir(abs))
+	if root == "" {
+		if root, err = os.Getwd(); err != nil {
+			return sarifArtifactLocation{URI: path}
+		}
+	}
+	rel, err := filepath.Rel(root, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		u := url.URL{Scheme: "file", Path: filepath.ToSlash(abs)}
+		return sarifArtifactLocation{URI: u.String()}
+	}
+	u := url.URL{Path: filepath.ToSlash(rel)}
+	return sarifArtifactLocation{URI: u.String(), URIBaseID: "%SRCROOT%"}
+}
+
+// JUnit XML report, with a test case for each checked file.
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Errors   int             `xml:"errors,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	ClassName string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Error     *junitFailure `xml:"error,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+}
+
+func writeJUnit(w io.Writer, results []fileResult) error {
+	suite := junitTestSuite{Name: "license", Tests: len(results)}
+	for _, res := range results {
+		tc := junitTestCase{ClassName: "license", Name: res.Path}
+		switch res.Status {
+		case header.StatusOK.String():
+		case header.StatusError.String():
+			tc.Error = &junitFailure{Message: res.Reason, Type: res.Status}
+			suite.Errors++
+		default:
+			tc.Failure = &junitFailure{Message: res.Reason, Type: res.Status}
+			suite.Failures++
+		}
+		suite.Cases = append(suite.Cases, tc)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(junitTestSuites{Suites: []junitTestSuite{suite}}); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,136 @@
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/bhojpur/license/header"
+)
+
+func testReport() *checkReport {
+	r := &checkReport{}
+	r.add("b/file.go", header.CheckResult{Status: header.StatusMissing, Reason: "missing license header"})
+	r.add("a/file.go", header.CheckResult{Status: header.StatusOK, License: "Apache-2.0"})
+	r.add("c/file.go", header.CheckResult{Status: header.StatusMismatchedLicense, Reason: "found MIT license header, want Apache-2.0", License: "MIT"})
+	r.add("d/file.go", header.CheckResult{Status: header.StatusError, Reason: "permission denied"})
+	return r
+}
+
+func TestReportJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := testReport().write(&buf, "json"); err != nil {
+		t.Fatal(err)
+	}
+	var got struct {
+		Results []fileResult `json:"results"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON report: %v\n%s", err, buf.Bytes())
+	}
+	want := []fileResult{
+		{Path: "a/file.go", Status: "ok", License: "Apache-2.0"},
+		{Path: "b/file.go", Status: "missing", Reason: "missing license header"},
+		{Path: "c/file.go", Status: "mismatched-license", Reason: "found MIT license header, want Apache-2.0", License: "MIT"},
+		{Path: "d/file.go", Status: "error", Reason: "permission denied"},
+	}
+	if !reflect.DeepEqual(got.Results, want) {
+		t.Errorf("JSON report returned %+v, want %+v", got.Results, want)
+	}
+}
+
+func TestReportSARIF(t *testing.T) {
+	var buf bytes.Buffer
+	if err := testReport().write(&buf, "sarif"); err != nil {
+		t.Fatal(err)
+	}
+	var got sarifLog
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("invalid SARIF report: %v\n%s", err, buf.Bytes())
+	}
+	if got.Version != "2.1.0" || len(got.Runs) != 1 {
+		t.Fatalf("SARIF report has version %q and %d runs, want 2.1.0 and 1 run", got.Version, len(got.Runs))
+	}
+	var ids []string
+	for _, res := range got.Runs[0].Results {
+		ids = append(ids, res.RuleID+" "+res.Locations[0].PhysicalLocation.ArtifactLocation.URI)
+	}
+	want := []string{"missing b/file.go", "mismatched-license c/file.go", "error d/file.go"}
+	if !reflect.DeepEqual(ids, want) {
+		t.Errorf("SARIF report has results %q, want %q", ids, want)
+	}
+}
+
+func TestSARIFFileLocation(t *testing.T) {
+	repo := tempDir(t)
+	run(t, "git", "init", "-q", repo)
+	outside := filepath.Join(tempDir(t), "a.go")
+	if repoRoot(filepath.Dir(outside)) != "" {
+		t.Skip("the temporary directory is inside a git repository")
+	}
+
+	tests := []struct {
+		path string
+		want sarifArtifactLocation
+	}{
+		{"b/file.go", sarifArtifactLocation{URI: "b/file.go", URIBaseID: "%SRCROOT%"}},
+		{filepath.ToSlash(filepath.Join(repo, "sub", "a b.go")), sarifArtifactLocation{URI: "sub/a%20b.go", URIBaseID: "%SRCROOT%"}},
+		{filepath.ToSlash(outside), sarifArtifactLocation{URI: "file://" + filepath.ToSlash(outside)}},
+	}
+	for _, tt := range tests {
+		if got := sarifFileLocation(tt.path); got != tt.want {
+			t.Errorf("sarifFileLocation(%q) = %+v, want %+v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestReportJUnit(t *testing.T) {
+	var buf bytes.Buffer
+	if err := testReport().write(&buf, "junit"); err != nil {
+		t.Fatal(err)
+	}
+	var got junitTestSuites
+	if err := xml.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JUnit report: %v\n%s", err, buf.Bytes())
+	}
+	if len(got.Suites) != 1 {
+		t.Fatalf("JUnit report has %d test suites, want 1", len(got.Suites))
+	}
+	s := got.Suites[0]
+	if s.Tests != 4 || s.Failures != 2 || s.Errors != 1 || len(s.Cases) != 4 {
+		t.Errorf("JUnit report has %d tests, %d failures, %d errors and %d test cases, want 4, 2, 1 and 4",
+			s.Tests, s.Failures, s.Errors, len(s.Cases))
+	}
+	if c := s.Cases[1]; c.Name != "b/file.go" || c.Failure == nil || c.Failure.Type != "missing" {
+		t.Errorf("JUnit report has test case %+v, want a missing failure for b/file.go", c)
+	}
+}
+
+func TestReportUnknownFormat(t *testing.T) {
+	if err := testReport().write(&bytes.Buffer{}, "xml"); err == nil {
+		t.Error("write with an unknown format returned no error")
+	}
+}
@@ -0,0 +1,293 @@
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Precedence of the information of a REUSE.toml annotation over the
+// information found in the files it applies to.
+const (
+	precedenceClosest   = "closest"   // the information of the file, if any, is used instead
+	precedenceAggregate = "aggregate" // both are used
+	precedenceOverride  = "override"  // the information of the file is ignored
+)
+
+// reuseAnnotation is the copyright and licensing information of files,
+// given by a REUSE.toml annotation or a paragraph of a .reuse/dep5 file.
+type reuseAnnotation struct {
+	paths      []*regexp.Regexp // patterns matched against slash separated paths relative to the project root
+	precedence string
+	copyrights []string
+	licenses   []string // SPDX license expressions
+}
+
+// matches reports whether the annotation applies to the file name, relative
+// to the project root.
+func (a *reuseAnnotation) matches(name string) bool {
+	for _, re := range a.paths {
+		if re.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// loadReuseAnnotations loads the annotations of the REUSE.toml or
+// .reuse/dep5 file of the project at root, if any. The last annotation
+// matching a file applies to it.
+func loadReuseAnnotations(root string) ([]reuseAnnotation, error) {
+	tomlFile, err := ioutil.ReadFile(filepath.Join(root, "REUSE.toml"))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	dep5, err := ioutil.ReadFile(filepath.Join(root, ".reuse", "dep5"))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	switch {
+	case tomlFile != nil && dep5 != nil:
+		return nil, fmt.Errorf("REUSE.toml and .reuse/dep5 cannot be used together")
+	case tomlFile != nil:
+		a, err := parseReuseTOML(tomlFile)
+		if err != nil {
+			return nil, fmt.Errorf("REUSE.toml: %w", err)
+		}
+		return a, nil
+	case dep5 != nil:
+		a, err := parseDep5(dep5)
+		if err != nil {
+			return nil, fmt.Errorf(".reuse/dep5: %w", err)
+		}
+		return a, nil
+	}
+	return nil, nil
+}
+
+// reuseTOML is the contents of a REUSE.toml file.
+type reuseTOML struct {
+	Version     int `toml:"version"`
+	Annotations []struct {
+		Path       tomlStrings `toml:"path"`
+		Precedence string      `toml:"precedence"`
+		Copyrights tomlStrings `toml:"SPDX-FileCopyrightText"`
+		Licenses   tomlStrings `toml:"SPDX-License-Identifier"`
+	} `toml:"annotations"`
+}
+
+// tomlStrings is a TOML value holding a string or an array of strings.
+type tomlStrings []string
+
+func (s *tomlStrings) UnmarshalTOML(v interface{}) error {
+	switch v := v.(type) {
+	case string:
+		*s = []string{v}
+		return nil
+	case []interface{}:
+		for _, e := range v {
+			str, ok := e.(string)
+			if !ok {
+				return fmt.Errorf("expected a string, found %v", e)
+			}
+			*s = append(*s, str)
+		}
+		return nil
+	}
+	return fmt.Errorf("expected a string or an array of strings, found %v", v)
+}
+
+// parseReuseTOML parses a REUSE.toml file. Keys other than those of the
+// specification are ignored, but tables other than [[annotations]] are
+// reported.
+func parseReuseTOML(b []byte) ([]reuseAnnotation, error) {
+	var f reuseTOML
+	md, err := toml.Decode(string(b), &f)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range md.Undecoded() {
+		if t := md.Type(key...); len(key) == 1 && (t == "Hash" || t == "ArrayHash") {
+			return nil, fmt.Errorf("unsupported table [%s]", key)
+		}
+	}
+	if !md.IsDefined("version") {
+		return nil, fmt.Errorf("missing version")
+	}
+	if f.Version != 1 {
+		return nil, fmt.Errorf("unsupported version %d, want 1", f.Version)
+	}
+	var annotations []reuseAnnotation
+	for _, a := range f.Annotations {
+		annotation, err := newTOMLAnnotation(a.Path, a.Precedence, a.Copyrights, a.Licenses)
+		if err != nil {
+			return nil, err
+		}
+		annotations = append(annotations, annotation)
+	}
+	return annotations, nil
+}
+
+// newTOMLAnnotation returns the annotation of the given REUSE.toml keys.
+func newTOMLAnnotation(paths []string, precedence string, copyrights, licenses []string) (reuseAnnotation, error) {
+	a := reuseAnnotation{
+		precedence: precedenceClosest,
+		copyrights: copyrights,
+		licenses:   licenses,
+	}
+	if precedence != "" {
+		a.precedence = precedence
+	}
+	switch a.precedence {
+	case precedenceClosest, precedenceAggregate, precedenceOverride:
+	default:
+		return a, fmt.Errorf("annotation precedence %q is not one of closest, aggregate or override", a.precedence)
+	}
+	if len(paths) == 0 {
+		return a, fmt.Errorf("annotation without path")
+	}
+	for _, p := range paths {
+		a.paths = append(a.paths, reuseGlob(p))
+	}
+	return a, nil
+}
+
+// reuseGlob compiles a REUSE.toml path pattern, where * matches any
+// character but /, ** any character, and \* a literal star.
+func reuseGlob(p string) *regexp.Regexp {
+	var re strings.Builder
+	re.WriteString(`\A`)
+	for i := 0; i < len(p); i++ {
+		switch {
+		case strings.HasPrefix(p[i:], `\*`):
+			re.WriteString(`\*`)
+			i++
+		case strings.HasPrefix(p[i:], "**/"):
+			re.WriteString(`(?:.*/)?`)
+			i += 2
+		case strings.HasPrefix(p[i:], "**"):
+			re.WriteString(`.*`)
+			i++
+		case p[i] == '*':
+			re.WriteString(`[^/]*`)
+		default:
+			re.WriteString(regexp.QuoteMeta(p[i : i+1]))
+		}
+	}
+	re.WriteString(`\z`)
+	return regexp.MustCompile(re.String())
+}
+
+// parseDep5 parses a .reuse/dep5 file, in the Debian machine-readable
+// copyright format. The information of its Files paragraphs is aggregated
+// with the information found in the files.
+func parseDep5(b []byte) ([]reuseAnnotation, error) {
+	var annotations []reuseAnnotation
+	for _, para := range dep5Paragraphs(b) {
+		files, ok := para["files"]
+		if !ok {
+			continue
+		}
+		a := reuseAnnotation{precedence: precedenceAggregate}
+		for _, f := range strings.Fields(files) {
+			a.paths = append(a.paths, dep5Glob(f))
+		}
+		for _, c := range strings.Split(para["copyright"], "\n") {
+			if c = strings.TrimSpace(c); c != "" {
+				a.copyrights = append(a.copyrights, c)
+			}
+		}
+		if l := strings.TrimSpace(strings.SplitN(para["license"], "\n", 2)[0]); l != "" {
+			a.licenses = []string{l}
+		}
+		if len(a.paths) == 0 {
+			return nil, fmt.Errorf("Files paragraph without patterns")
+		}
+		annotations = append(annotations, a)
+	}
+	return annotations, nil
+}
+
+// dep5Paragraphs splits a Debian control file into paragraphs of fields,
+// keyed by lower case field name. Continuation lines are joined with
+// newlines.
+func dep5Paragraphs(b []byte) []map[string]string {
+	var paras []map[string]string
+	var cur map[string]string
+	var field string
+	s := bufio.NewScanner(bytes.NewReader(b))
+	for s.Scan() {
+		line := s.Text()
+		switch {
+		case strings.TrimSpace(line) == "":
+			cur = nil
+		case strings.HasPrefix(line, "#"):
+		case line[0] == ' ' || line[0] == '\t':
+			if cur != nil && field != "" {
+				cont := strings.TrimSpace(line)
+				if cont == "." {
+					cont = ""
+				}
+				cur[field] += "\n" + cont
+			}
+		default:
+			i := strings.Index(line, ":")
+			if i < 0 {
+				continue
+			}
+			if cur == nil {
+				cur = make(map[string]string)
+				paras = append(paras, cur)
+			}
+			field = strings.ToLower(strings.TrimSpace(line[:i]))
+			cur[field] = strings.TrimSpace(line[i+1:])
+		}
+	}
+	return paras
+}
+
+// dep5Glob compiles a dep5 Files pattern, where * matches any characters,
+// including /, and ? a single character.
+func dep5Glob(p string) *regexp.Regexp {
+	var re strings.Builder
+	re.WriteString(`\A`)
+	for _, c := range strings.TrimPrefix(p, "./") {
+		switch c {
+		case '*':
+			re.WriteString(`.*`)
+		case '?':
+			re.WriteString(`.`)
+		default:
+			re.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	re.WriteString(`\z`)
+	return regexp.MustCompile(re.String())
+}
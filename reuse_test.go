@@ -0,0 +1,194 @@
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseReuseTOML(t *testing.T) {
+	annotations, err := parseReuseTOML([]byte(`version = 1
+SPDX-PackageName = "license" # not used
+
+[[annotations]]
+path = ["docs/**", "*.png"]
+precedence = "override"
+SPDX-FileCopyrightText = "2022 Alice"
+SPDX-License-Identifier = "CC-BY-4.0"
+
+[[annotations]]
+path = "vendor/**"
+SPDX-FileCopyrightText = [
+  "2021 Bob", # first
+  "2022 Carol",
+]
+SPDX-License-Identifier = "MIT"
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(annotations) != 2 {
+		t.Fatalf("parseReuseTOML returned %d annotations, want 2", len(annotations))
+	}
+	a := annotations[0]
+	if a.precedence != precedenceOverride || !reflect.DeepEqual(a.copyrights, []string{"2022 Alice"}) || !reflect.DeepEqual(a.licenses, []string{"CC-BY-4.0"}) {
+		t.Errorf("parseReuseTOML returned first annotation %+v", a)
+	}
+	a = annotations[1]
+	if a.precedence != precedenceClosest || !reflect.DeepEqual(a.copyrights, []string{"2021 Bob", "2022 Carol"}) || !reflect.DeepEqual(a.licenses, []string{"MIT"}) {
+		t.Errorf("parseReuseTOML returned second annotation %+v", a)
+	}
+
+	for _, tt := range []struct {
+		name    string
+		matches []bool // of annotations 0 and 1
+	}{
+		{"docs/a/b.md", []bool{true, false}},
+		{"logo.png", []bool{true, false}},
+		{"img/logo.png", []bool{false, false}},
+		{"vendor/x/y.go", []bool{false, true}},
+		{"main.go", []bool{false, false}},
+	} {
+		for i, want := range tt.matches {
+			if got := annotations[i].matches(tt.name); got != want {
+				t.Errorf("annotation %d matches(%q) = %t, want %t", i, tt.name, got, want)
+			}
+		}
+	}
+}
+
+func TestParseReuseTOMLSyntax(t *testing.T) {
+	// multi-line arrays with comments, literal strings and inline tables
+	annotations, err := parseReuseTOML([]byte(`version = 1
+annotations = [
+  # inline tables, with literal strings
+  { path = 'src/**', SPDX-License-Identifier = "Apache-2.0" }, # first
+  { path = ['docs/\*.md', "*.png"], SPDX-FileCopyrightText = ['2022 "Alice"'], SPDX-License-Identifier = 'CC-BY-4.0' },
+]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(annotations) != 2 {
+		t.Fatalf("parseReuseTOML returned %d annotations, want 2", len(annotations))
+	}
+	if a := annotations[0]; !reflect.DeepEqual(a.licenses, []string{"Apache-2.0"}) || !a.matches("src/main.go") {
+		t.Errorf("parseReuseTOML returned first annotation %+v", a)
+	}
+	a := annotations[1]
+	if !reflect.DeepEqual(a.copyrights, []string{`2022 "Alice"`}) || !reflect.DeepEqual(a.licenses, []string{"CC-BY-4.0"}) {
+		t.Errorf("parseReuseTOML returned second annotation %+v", a)
+	}
+	for name, want := range map[string]bool{"docs/*.md": true, "docs/a.md": false, "logo.png": true} {
+		if got := a.matches(name); got != want {
+			t.Errorf("second annotation matches(%q) = %t, want %t", name, got, want)
+		}
+	}
+}
+
+func TestParseReuseTOMLErrors(t *testing.T) {
+	tests := []struct {
+		description string
+		content     string
+	}{
+		{"missing version", "[[annotations]]\npath = \"*\"\n"},
+		{"unsupported version", "version = 2\n"},
+		{"unsupported table", "version = 1\n[package]\n"},
+		{"annotation without path", "version = 1\n[[annotations]]\nSPDX-License-Identifier = \"MIT\"\n"},
+		{"invalid precedence", "version = 1\n[[annotations]]\npath = \"*\"\nprecedence = \"first\"\n"},
+		{"unterminated string", "version = 1\n[[annotations]]\npath = \"*\n"},
+	}
+
+	for _, tt := range tests {
+		if _, err := parseReuseTOML([]byte(tt.content)); err == nil {
+			t.Errorf("parseReuseTOML with %s returned no error", tt.description)
+		}
+	}
+}
+
+func TestReuseGlob(t *testing.T) {
+	tests := []struct {
+		pattern, name string
+		want          bool
+	}{
+		{"*.go", "main.go", true},
+		{"*.go", "cmd/main.go", false},
+		{"**/*.go", "cmd/main.go", true},
+		{"**/*.go", "main.go", true},
+		{"src/**", "src/a/b.c", true},
+		{"src/**", "srcs/a", false},
+		{`a\*b`, "a*b", true},
+		{`a\*b`, "axb", false},
+		{"a.txt", "aatxt", false},
+	}
+
+	for _, tt := range tests {
+		if got := reuseGlob(tt.pattern).MatchString(tt.name); got != tt.want {
+			t.Errorf("reuseGlob(%q) matches %q = %t, want %t", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseDep5(t *testing.T) {
+	annotations, err := parseDep5([]byte(`Format: https://www.debian.org/doc/packaging-manuals/copyright-format/1.0/
+Upstream-Name: license
+
+Files: docs/* *.png
+Copyright: 2022 Alice
+ 2023 Bob
+License: CC-BY-4.0
+
+Files: vendor/?/*
+Copyright: 2021 Carol
+License: MIT
+ The MIT License text.
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(annotations) != 2 {
+		t.Fatalf("parseDep5 returned %d annotations, want 2", len(annotations))
+	}
+	a := annotations[0]
+	if a.precedence != precedenceAggregate || !reflect.DeepEqual(a.copyrights, []string{"2022 Alice", "2023 Bob"}) || !reflect.DeepEqual(a.licenses, []string{"CC-BY-4.0"}) {
+		t.Errorf("parseDep5 returned first annotation %+v", a)
+	}
+	if !reflect.DeepEqual(annotations[1].licenses, []string{"MIT"}) {
+		t.Errorf("parseDep5 returned second annotation %+v", annotations[1])
+	}
+
+	for _, tt := range []struct {
+		name    string
+		matches []bool
+	}{
+		{"docs/a/b.md", []bool{true, false}},
+		{"img/logo.png", []bool{true, false}}, // * matches slashes in dep5
+		{"vendor/x/y.go", []bool{false, true}},
+		{"vendor/xy/z.go", []bool{false, false}},
+	} {
+		for i, want := range tt.matches {
+			if got := annotations[i].matches(tt.name); got != want {
+				t.Errorf("annotation %d matches(%q) = %t, want %t", i, tt.name, got, want)
+			}
+		}
+	}
+}
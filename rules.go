@@ -0,0 +1,110 @@
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/bhojpur/license/header"
+)
+
+// printLicenses writes the identifier and name of the built-in license
+// templates to w, one per line.
+func printLicenses(w io.Writer) {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	for _, l := range header.Licenses() {
+		fmt.Fprintf(tw, "%s\t%s\n", l.ID, l.Name)
+	}
+	tw.Flush()
+}
+
+// newLicense returns the license of the given type, rendered from the
+// template read from templateFile if set, with data.
+func newLicense(license, templateFile string, spdx spdxFlag, data header.LicenseData) (*header.License, error) {
+	var text string
+	if templateFile != "" && spdx != spdxOnly && spdx != spdxReuse {
+		d, err := ioutil.ReadFile(templateFile)
+		if err != nil {
+			return nil, fmt.Errorf("license file: %w", err)
+		}
+		text = string(d)
+	}
+	return header.NewLicense(license, text, header.SPDXMode(spdx), data)
+}
+
+// newRules returns the rules configured in cfgs. Settings not specified by a
+// rule are inherited from def, which was built from the given license,
+// templateFile and spdx mode.
+func newRules(cfgs []ruleConfig, def *header.License, license, templateFile string, spdx spdxFlag) ([]header.Rule, error) {
+	var rules []header.Rule
+	for i, c := range cfgs {
+		if c.Skip {
+			rules = append(rules, header.Rule{Patterns: c.Paths, Skip: true})
+			continue
+		}
+		l, f, s, d := license, templateFile, spdx, def.Data
+		if c.License != "" || c.Template != "" {
+			// a template file is specific to the default license
+			l, f = c.License, c.Template
+			if l == "" {
+				l = license
+			}
+		}
+		if c.SPDX != nil {
+			s = *c.SPDX
+		}
+		if c.Holder != "" {
+			d.Holder = c.Holder
+		}
+		lic, err := newLicense(l, f, s, d)
+		if err != nil {
+			return nil, fmt.Errorf("rule %d: %w", i+1, err)
+		}
+		rules = append(rules, header.Rule{Patterns: c.Paths, License: lic})
+	}
+	return rules, nil
+}
+
+// fileName returns the name of the file at path relative to the directory
+// base, with slashes, which is used to match the rules and comment styles
+// of the configuration file.
+func fileName(base, path string) string {
+	rel := path
+	if abs, err := filepath.Abs(path); err == nil {
+		if r, err := filepath.Rel(base, abs); err == nil {
+			rel = r
+		}
+	}
+	return filepath.ToSlash(rel)
+}
+
+// filePath is the inverse of fileName.
+func filePath(base, name string) string {
+	path := filepath.FromSlash(name)
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(base, path)
+}
@@ -0,0 +1,158 @@
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bhojpur/license/header"
+)
+
+func TestNewLicense(t *testing.T) {
+	tests := []struct {
+		description  string
+		templateFile string
+		spdx         spdxFlag
+		want         string // rendered license
+		wantErr      error
+	}{
+		{"non-existent template file", "/does/not/exist", spdxOff, "", os.ErrNotExist},
+		{"custom template file", "testdata/custom.tpl", spdxOff, "Copyright 2022 H\n\nCustom License Template\n", nil},
+		{"template file ignored with SPDX only", "/does/not/exist", spdxOnly, "Copyright 2022 H. All rights resevred.\nSPDX-License-Identifier: MIT\n", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			l, err := newLicense("mit", tt.templateFile, tt.spdx, header.LicenseData{Year: "2022", Holder: "H"})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("newLicense returned error %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("newLicense returned error: %v", err)
+			}
+			b, err := header.ExecuteTemplate(l.Template, l.Data, "", "", "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := string(b[:len(b)-1]); got != tt.want {
+				t.Errorf("newLicense rendered %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewRules(t *testing.T) {
+	base := filepath.FromSlash("/repo")
+	def, err := newLicense("apache", "", spdxOff, header.LicenseData{Year: "2022", Holder: "H"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	only := spdxOnly
+	rules, err := newRules([]ruleConfig{
+		{Paths: []string{"examples/**"}, License: "mit"},
+		{Paths: []string{"sdk/**"}, Holder: "SDK", SPDX: &only},
+		{Paths: []string{"third_party/**"}, Skip: true},
+		{Paths: []string{"**/*.go"}, License: "bsd"},
+	}, def, "apache", "", spdxOff)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &header.Processor{License: def, Rules: rules}
+
+	tests := []struct {
+		path       string
+		wantSkip   bool
+		wantSPDXID string
+		wantHolder string
+		wantHeader string // first line of the rendered header
+	}{
+		{"/repo/main.c", false, "Apache-2.0", "H", "Copyright 2022 H. All rights reserved."},
+		{"/repo/examples/a/main.go", false, "MIT", "H", "Copyright (c) 2022 H. All rights reserved."},
+		{"/repo/sdk/main.go", false, "Apache-2.0", "SDK", "Copyright 2022 SDK. All rights resevred."},
+		{"/repo/third_party/x/main.go", true, "", "", ""},
+		{"/repo/cmd/main.go", false, "bsd", "H", "Copyright (c) 2022 H All rights reserved."},
+		{"/other/examples/main.c", false, "Apache-2.0", "H", "Copyright 2022 H. All rights reserved."},
+	}
+
+	for _, tt := range tests {
+		name := fileName(base, filepath.FromSlash(tt.path))
+		if got := filePath(base, name); got != filepath.FromSlash(tt.path) {
+			t.Errorf("filePath(%q) returned %q, want %q", name, got, tt.path)
+		}
+		l := p.LicenseFor(name)
+		if (l == nil) != tt.wantSkip {
+			t.Errorf("LicenseFor(%q) returned %v, want skip %t", name, l, tt.wantSkip)
+		}
+		if l == nil {
+			continue
+		}
+		if l.Data.SPDXID != tt.wantSPDXID || l.Data.Holder != tt.wantHolder {
+			t.Errorf("LicenseFor(%q) returned %+v, want license %q and holder %q", name, l.Data, tt.wantSPDXID, tt.wantHolder)
+		}
+		b, err := header.ExecuteTemplate(l.Template, l.Data, "", "", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := string(b[:len(tt.wantHeader)]); got != tt.wantHeader {
+			t.Errorf("LicenseFor(%q) rendered %q, want %q", name, b, tt.wantHeader)
+		}
+	}
+}
+
+func TestNewRulesErrors(t *testing.T) {
+	def, err := newLicense("apache", "", spdxOff, header.LicenseData{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = newRules([]ruleConfig{
+		{Paths: []string{"sdk/**"}, License: "mit"},
+		{Paths: []string{"x/**"}, License: "unknown"},
+	}, def, "apache", "", spdxOff)
+	want := `rule 2: unknown license "unknown"`
+	if err == nil || err.Error() != want {
+		t.Errorf("newRules returned error %v, want %q", err, want)
+	}
+}
+
+func TestPrintLicenses(t *testing.T) {
+	var buf bytes.Buffer
+	printLicenses(&buf)
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != len(header.Licenses()) {
+		t.Fatalf("printLicenses printed %d lines, want %d:\n%s", len(lines), len(header.Licenses()), buf.String())
+	}
+	for _, want := range []string{
+		"Apache-2.0         Apache License 2.0",
+		"GPL-3.0-or-later   GNU General Public License v3.0 or later",
+	} {
+		if !strings.Contains(buf.String(), want+"\n") {
+			t.Errorf("printLicenses output does not contain %q:\n%s", want, buf.String())
+		}
+	}
+}
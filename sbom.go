@@ -0,0 +1,699 @@
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bhojpur/license/header"
+)
+
+// sbomFormats lists the formats supported by the -format flag of the sbom
+// command.
+var sbomFormats = []string{"spdx", "spdx-json", "cyclonedx"}
+
+// sbom is the software bill of materials of a project: its files with the
+// licensing information found in them, and the Go modules it depends on.
+type sbom struct {
+	Name      string
+	Version   string    // version of the project, may be empty
+	Namespace string    // unique URI of the SPDX document
+	Serial    string    // UUID of the document
+	Created   time.Time // in UTC, to the second
+	Files     []sbomFile
+	Modules   []dependency
+
+	// LicenseRefs maps the LicenseRef- identifiers used by the files to
+	// their text in the LICENSES directory, if any.
+	LicenseRefs map[string]string
+}
+
+// sbomFile is a file of the project.
+type sbomFile struct {
+	Name       string   // slash separated path relative to the project
+	SHA1       string   // hex encoded checksum of the contents
+	SHA256     string   // hex encoded checksum of the contents
+	Licenses   []string // canonical SPDX expressions of the file
+	Copyrights []string // copyright notices of the file
+}
+
+// runSBOM implements the sbom command, which writes the software bill of
+// materials of a project in the SPDX or CycloneDX format.
+func runSBOM(args []string) error {
+	fset := flag.NewFlagSet("sbom", flag.ExitOnError)
+	format := fset.String("format", "spdx", "output format: "+strings.Join(sbomFormats, ", "))
+	output := fset.String("o", "", "write the document to this file instead of the standard output")
+	name := fset.String("name", "", "project name (default: the name of the directory)")
+	version := fset.String("version", "", "project version")
+	namespace := fset.String("namespace", "", "URI of the SPDX document (default: derived from the name and the contents)")
+	deps := fset.Bool("deps", true, "include the Go modules required by the go.mod file of the directory, if any")
+	indirect := fset.Bool("indirect", true, "include the modules only required indirectly")
+	fset.Usage = func() {
+		fmt.Fprintf(fset.Output(), "Usage: license sbom [flags] [directory]\n\nFlags:\n")
+		fset.PrintDefaults()
+	}
+	fset.Parse(args)
+	if fset.NArg() > 1 {
+		fset.Usage()
+		os.Exit(1)
+	}
+	if !validSBOMFormat(*format) {
+		return fmt.Errorf("-format %q is not one of %s", *format, strings.Join(sbomFormats, ", "))
+	}
+	root := "."
+	if fset.NArg() == 1 {
+		root = fset.Arg(0)
+	}
+	if *name == "" {
+		abs, err := filepath.Abs(root)
+		if err != nil {
+			return err
+		}
+		*name = filepath.Base(abs)
+	}
+	created, err := sbomTime()
+	if err != nil {
+		return err
+	}
+
+	b, err := buildSBOM(root, *output)
+	if err != nil {
+		return err
+	}
+	if *deps {
+		if _, err := os.Stat(filepath.Join(root, "go.mod")); err == nil {
+			if b.Modules, err = loadDependencies(root, *indirect); err != nil {
+				return err
+			}
+			for _, d := range b.Modules {
+				if d.Error != "" {
+					log.Printf("%s@%s: %s, its license is unknown", d.Module, d.Version, d.Error)
+				}
+			}
+		}
+	}
+	b.Name, b.Version, b.Created = *name, *version, created
+	b.setNamespace(*namespace)
+
+	if *output == "" {
+		return b.write(os.Stdout, *format)
+	}
+	var buf bytes.Buffer
+	if err := b.write(&buf, *format); err != nil {
+		return err
+	}
+	return replaceFile(*output, buf.Bytes(), 0644, time.Now())
+}
+
+func validSBOMFormat(format string) bool {
+	for _, f := range sbomFormats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// write writes the document to w in the given format.
+func (b *sbom) write(w io.Writer, format string) error {
+	switch format {
+	case "spdx":
+		return b.writeSPDX(w)
+	case "spdx-json":
+		return b.writeSPDXJSON(w)
+	case "cyclonedx":
+		return b.writeCycloneDX(w)
+	}
+	return fmt.Errorf("unknown SBOM format %q", format)
+}
+
+// sbomTime returns the creation time of the document: the time given by
+// the SOURCE_DATE_EPOCH environment variable for reproducible builds, or
+// the current time.
+func sbomTime() (time.Time, error) {
+	if s := os.Getenv("SOURCE_DATE_EPOCH"); s != "" {
+		sec, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("SOURCE_DATE_EPOCH %q is not a number of seconds", s)
+		}
+		return time.Unix(sec, 0).UTC(), nil
+	}
+	return time.Now().UTC().Truncate(time.Second), nil
+}
+
+// buildSBOM returns the files of the project at root, as checked by the
+// lint command, with their licensing information, identified from their
+// header when they have no SPDX-License-Identifier tag. The file at output, if
+// any, is left out as it is about to be replaced by the document. Invalid
+// license expressions are reported and recorded as NOASSERTION, as they
+// cannot appear in the document.
+func buildSBOM(root, output string) (*sbom, error) {
+	if output != "" {
+		var err error
+		if output, err = filepath.Abs(output); err != nil {
+			return nil, err
+		}
+	}
+	b := &sbom{LicenseRefs: make(map[string]string)}
+	var failed error
+	err := scanProject(root, func(path, name string, tags header.Tags, err error) {
+		if abs, _ := filepath.Abs(path); abs == output || failed != nil {
+			return
+		}
+		var contents []byte
+		if err == nil {
+			contents, err = ioutil.ReadFile(path)
+		}
+		if err != nil {
+			failed = err
+			return
+		}
+		sum1, sum256 := sha1.Sum(contents), sha256.Sum256(contents)
+		f := sbomFile{
+			Name:   name,
+			SHA1:   hex.EncodeToString(sum1[:]),
+			SHA256: hex.EncodeToString(sum256[:]),
+		}
+		for _, c := range tags.Copyrights {
+			f.Copyrights = appendUnique(f.Copyrights, c)
+		}
+		for _, l := range fileLicenses(contents, tags) {
+			e, err := header.ParseExpression(l)
+			if err != nil {
+				log.Printf("%s: %v", name, err)
+				f.Licenses = appendUnique(f.Licenses, "NOASSERTION")
+				continue
+			}
+			f.Licenses = appendUnique(f.Licenses, e.String())
+			for _, id := range e.Licenses() {
+				if strings.HasPrefix(id, "LicenseRef-") {
+					b.LicenseRefs[id] = ""
+				}
+			}
+		}
+		b.Files = append(b.Files, f)
+	})
+	if err == nil {
+		err = failed
+	}
+	if err != nil {
+		return nil, err
+	}
+	for id := range b.LicenseRefs {
+		matches, err := filepath.Glob(filepath.Join(root, "LICENSES", id+".*"))
+		if err != nil || len(matches) == 0 {
+			continue
+		}
+		text, err := ioutil.ReadFile(matches[0])
+		if err != nil {
+			return nil, err
+		}
+		b.LicenseRefs[id] = string(text)
+	}
+	return b, nil
+}
+
+// verificationCode returns the SPDX package verification code of the files:
+// the SHA1 checksum of their sorted SHA1 checksums.
+func (b *sbom) verificationCode() string {
+	sums := make([]string, len(b.Files))
+	for i, f := range b.Files {
+		sums[i] = f.SHA1
+	}
+	sort.Strings(sums)
+	sum := sha1.Sum([]byte(strings.Join(sums, "")))
+	return hex.EncodeToString(sum[:])
+}
+
+// setNamespace sets the serial number of the document, derived from its
+// contents so that the same sources give the same document, and its
+// namespace, unless one is given.
+func (b *sbom) setNamespace(namespace string) {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n%s\n%s\n", b.Name, b.Version, b.verificationCode())
+	for _, d := range b.Modules {
+		fmt.Fprintf(h, "%s %s %s\n", d.Module, d.Version, d.License)
+	}
+	u := h.Sum(nil)[:16]
+	u[6] = u[6]&0x0f | 0x50 // version 5, name based with SHA1 or better
+	u[8] = u[8]&0x3f | 0x80 // RFC 4122 variant
+	s := hex.EncodeToString(u)
+	b.Serial = s[:8] + "-" + s[8:12] + "-" + s[12:16] + "-" + s[16:20] + "-" + s[20:]
+	if namespace == "" {
+		namespace = "https://spdx.org/spdxdocs/" + strings.ReplaceAll(b.Name, " ", "-") + "-" + b.Serial
+	}
+	b.Namespace = namespace
+}
+
+// licenseIDs returns the license identifiers of the expressions, without
+// duplicates, or NONE if there are none. NOASSERTION is kept as is.
+func licenseIDs(exprs []string) []string {
+	var ids []string
+	for _, s := range exprs {
+		if s == "NOASSERTION" {
+			ids = appendUnique(ids, s)
+			continue
+		}
+		e, err := header.ParseExpression(s)
+		if err != nil {
+			continue
+		}
+		for _, id := range e.Licenses() {
+			ids = appendUnique(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return []string{"NONE"}
+	}
+	return ids
+}
+
+// conjunction returns the expressions combined with AND, or the empty
+// string if there are none. It is NOASSERTION if any of them is, as the
+// others only cover part of the licensing.
+func conjunction(exprs []string) string {
+	if len(exprs) == 1 {
+		return exprs[0]
+	}
+	e := &header.Expression{Op: "AND"}
+	for _, s := range exprs {
+		if s == "NOASSERTION" {
+			return s
+		}
+		o, err := header.ParseExpression(s)
+		if err != nil {
+			continue
+		}
+		e.Operands = append(e.Operands, o)
+	}
+	if len(e.Operands) == 0 {
+		return ""
+	}
+	return e.String()
+}
+
+// moduleLicense returns the license of the module as an SPDX expression, or
+// NOASSERTION if it is unknown.
+func moduleLicense(d dependency) string {
+	if d.License == "" {
+		return "NOASSERTION"
+	}
+	if _, err := header.ParseExpression(d.License); err != nil {
+		return "NOASSERTION"
+	}
+	return d.License
+}
+
+// modulePURL returns the package URL of the module, see
+// https://github.com/package-url/purl-spec.
+func modulePURL(d dependency) string {
+	return "pkg:golang/" + d.Module + "@" + strings.ReplaceAll(d.Version, "+", "%2B")
+}
+
+// copyrightText returns the copyright notices as the text of an SPDX
+// copyright field, or NONE if there are none.
+func copyrightText(notices []string) string {
+	if len(notices) == 0 {
+		return "NONE"
+	}
+	return strings.Join(notices, "\n")
+}
+
+// SPDX identifiers of the elements of the document.
+const (
+	spdxDocumentID = "SPDXRef-DOCUMENT"
+	spdxProjectID  = "SPDXRef-Project"
+)
+
+func spdxFileID(i int) string   { return fmt.Sprintf("SPDXRef-File-%d", i+1) }
+func spdxModuleID(i int) string { return fmt.Sprintf("SPDXRef-Module-%d", i+1) }
+
+// writeSPDX writes the document in the SPDX 2.3 tag-value format, see
+// https://spdx.github.io/spdx-spec/v2.3/.
+func (b *sbom) writeSPDX(w io.Writer) error {
+	text := func(s string) string {
+		if s == "NONE" || s == "NOASSERTION" {
+			return s
+		}
+		return "<text>" + s + "</text>"
+	}
+	bw := &errWriter{w: w}
+	bw.printf("SPDXVersion: SPDX-2.3\nDataLicense: CC0-1.0\nSPDXID: %s\n", spdxDocumentID)
+	bw.printf("DocumentName: %s\nDocumentNamespace: %s\n", b.Name, b.Namespace)
+	bw.printf("Creator: Tool: license\nCreated: %s\n", b.Created.Format(time.RFC3339))
+	bw.printf("Relationship: %s DESCRIBES %s\n", spdxDocumentID, spdxProjectID)
+
+	var infos []string
+	for _, f := range b.Files {
+		for _, id := range licenseIDs(f.Licenses) {
+			if id != "NONE" {
+				infos = appendUnique(infos, id)
+			}
+		}
+	}
+	sort.Strings(infos)
+	if len(infos) == 0 {
+		infos = []string{"NONE"}
+	}
+	bw.printf("\nPackageName: %s\nSPDXID: %s\n", b.Name, spdxProjectID)
+	if b.Version != "" {
+		bw.printf("PackageVersion: %s\n", b.Version)
+	}
+	bw.printf("PackageDownloadLocation: NOASSERTION\nFilesAnalyzed: true\n")
+	bw.printf("PackageVerificationCode: %s\n", b.verificationCode())
+	bw.printf("PackageLicenseConcluded: NOASSERTION\n")
+	for _, id := range infos {
+		bw.printf("PackageLicenseInfoFromFiles: %s\n", id)
+	}
+	bw.printf("PackageLicenseDeclared: NOASSERTION\nPackageCopyrightText: NOASSERTION\n")
+
+	for i, f := range b.Files {
+		bw.printf("\nFileName: ./%s\nSPDXID: %s\n", f.Name, spdxFileID(i))
+		bw.printf("FileChecksum: SHA1: %s\nFileChecksum: SHA256: %s\n", f.SHA1, f.SHA256)
+		bw.printf("LicenseConcluded: NOASSERTION\n")
+		for _, id := range licenseIDs(f.Licenses) {
+			bw.printf("LicenseInfoInFile: %s\n", id)
+		}
+		bw.printf("FileCopyrightText: %s\n", text(copyrightText(f.Copyrights)))
+		bw.printf("Relationship: %s CONTAINS %s\n", spdxProjectID, spdxFileID(i))
+	}
+
+	for i, d := range b.Modules {
+		bw.printf("\nPackageName: %s\nSPDXID: %s\nPackageVersion: %s\n", d.Module, spdxModuleID(i), d.Version)
+		bw.printf("PackageDownloadLocation: NOASSERTION\nFilesAnalyzed: false\n")
+		bw.printf("PackageLicenseConcluded: NOASSERTION\nPackageLicenseDeclared: %s\n", moduleLicense(d))
+		bw.printf("PackageCopyrightText: NOASSERTION\n")
+		bw.printf("ExternalRef: PACKAGE-MANAGER purl %s\n", modulePURL(d))
+		bw.printf("Relationship: %s DEPENDS_ON %s\n", spdxProjectID, spdxModuleID(i))
+	}
+
+	for _, id := range sortedRefs(b.LicenseRefs) {
+		bw.printf("\nLicenseID: %s\nExtractedText: %s\nLicenseName: %s\n", id, text(extractedText(b.LicenseRefs[id])), strings.TrimPrefix(id, "LicenseRef-"))
+	}
+	return bw.err
+}
+
+// extractedText returns the text of a LicenseRef- license, or NOASSERTION
+// if the project has no text for it.
+func extractedText(s string) string {
+	if s == "" {
+		return "NOASSERTION"
+	}
+	return s
+}
+
+func sortedRefs(m map[string]string) []string {
+	ids := make([]string, 0, len(m))
+	for id := range m {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// errWriter writes formatted text to w until the first error.
+type errWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (w *errWriter) printf(format string, args ...interface{}) {
+	if w.err == nil {
+		_, w.err = fmt.Fprintf(w.w, format, args...)
+	}
+}
+
+// SPDX 2.3 JSON document, limited to the properties used by the sbom
+// command. See https://spdx.github.io/spdx-spec/v2.3/.
+type spdxDocument struct {
+	SPDXVersion          string                 `json:"spdxVersion"`
+	DataLicense          string                 `json:"dataLicense"`
+	SPDXID               string                 `json:"SPDXID"`
+	Name                 string                 `json:"name"`
+	DocumentNamespace    string                 `json:"documentNamespace"`
+	CreationInfo         spdxCreationInfo       `json:"creationInfo"`
+	Packages             []spdxPackage          `json:"packages"`
+	Files                []spdxFile             `json:"files"`
+	Relationships        []spdxRelationship     `json:"relationships"`
+	ExtractedLicenseInfo []spdxExtractedLicense `json:"hasExtractedLicensingInfos,omitempty"`
+}
+
+type spdxCreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+type spdxPackage struct {
+	Name                 string                `json:"name"`
+	SPDXID               string                `json:"SPDXID"`
+	VersionInfo          string                `json:"versionInfo,omitempty"`
+	DownloadLocation     string                `json:"downloadLocation"`
+	FilesAnalyzed        bool                  `json:"filesAnalyzed"`
+	VerificationCode     *spdxVerificationCode `json:"packageVerificationCode,omitempty"`
+	LicenseConcluded     string                `json:"licenseConcluded"`
+	LicenseInfoFromFiles []string              `json:"licenseInfoFromFiles,omitempty"`
+	LicenseDeclared      string                `json:"licenseDeclared"`
+	CopyrightText        string                `json:"copyrightText"`
+	ExternalRefs         []spdxExternalRef     `json:"externalRefs,omitempty"`
+}
+
+type spdxVerificationCode struct {
+	Value string `json:"packageVerificationCodeValue"`
+}
+
+type spdxExternalRef struct {
+	Category string `json:"referenceCategory"`
+	Type     string `json:"referenceType"`
+	Locator  string `json:"referenceLocator"`
+}
+
+type spdxFile struct {
+	FileName           string         `json:"fileName"`
+	SPDXID             string         `json:"SPDXID"`
+	Checksums          []spdxChecksum `json:"checksums"`
+	LicenseConcluded   string         `json:"licenseConcluded"`
+	LicenseInfoInFiles []string       `json:"licenseInfoInFiles"`
+	CopyrightText      string         `json:"copyrightText"`
+}
+
+type spdxChecksum struct {
+	Algorithm string `json:"algorithm"`
+	Value     string `json:"checksumValue"`
+}
+
+type spdxRelationship struct {
+	Element string `json:"spdxElementId"`
+	Type    string `json:"relationshipType"`
+	Related string `json:"relatedSpdxElement"`
+}
+
+type spdxExtractedLicense struct {
+	LicenseID     string `json:"licenseId"`
+	ExtractedText string `json:"extractedText"`
+	Name          string `json:"name"`
+}
+
+// writeSPDXJSON writes the document in the SPDX 2.3 JSON format.
+func (b *sbom) writeSPDXJSON(w io.Writer) error {
+	doc := spdxDocument{
+		SPDXVersion:       "SPDX-2.3",
+		DataLicense:       "CC0-1.0",
+		SPDXID:            spdxDocumentID,
+		Name:              b.Name,
+		DocumentNamespace: b.Namespace,
+		CreationInfo: spdxCreationInfo{
+			Created:  b.Created.Format(time.RFC3339),
+			Creators: []string{"Tool: license"},
+		},
+		Files:         []spdxFile{},
+		Relationships: []spdxRelationship{{spdxDocumentID, "DESCRIBES", spdxProjectID}},
+	}
+	project := spdxPackage{
+		Name:             b.Name,
+		SPDXID:           spdxProjectID,
+		VersionInfo:      b.Version,
+		DownloadLocation: "NOASSERTION",
+		FilesAnalyzed:    true,
+		VerificationCode: &spdxVerificationCode{b.verificationCode()},
+		LicenseConcluded: "NOASSERTION",
+		LicenseDeclared:  "NOASSERTION",
+		CopyrightText:    "NOASSERTION",
+	}
+	for i, f := range b.Files {
+		ids := licenseIDs(f.Licenses)
+		for _, id := range ids {
+			if id != "NONE" {
+				project.LicenseInfoFromFiles = appendUnique(project.LicenseInfoFromFiles, id)
+			}
+		}
+		doc.Files = append(doc.Files, spdxFile{
+			FileName: "./" + f.Name,
+			SPDXID:   spdxFileID(i),
+			Checksums: []spdxChecksum{
+				{"SHA1", f.SHA1},
+				{"SHA256", f.SHA256},
+			},
+			LicenseConcluded:   "NOASSERTION",
+			LicenseInfoInFiles: ids,
+			CopyrightText:      copyrightText(f.Copyrights),
+		})
+		doc.Relationships = append(doc.Relationships, spdxRelationship{spdxProjectID, "CONTAINS", spdxFileID(i)})
+	}
+	sort.Strings(project.LicenseInfoFromFiles)
+	if len(project.LicenseInfoFromFiles) == 0 {
+		project.LicenseInfoFromFiles = []string{"NONE"}
+	}
+	doc.Packages = append(doc.Packages, project)
+
+	for i, d := range b.Modules {
+		doc.Packages = append(doc.Packages, spdxPackage{
+			Name:             d.Module,
+			SPDXID:           spdxModuleID(i),
+			VersionInfo:      d.Version,
+			DownloadLocation: "NOASSERTION",
+			LicenseConcluded: "NOASSERTION",
+			LicenseDeclared:  moduleLicense(d),
+			CopyrightText:    "NOASSERTION",
+			ExternalRefs:     []spdxExternalRef{{"PACKAGE-MANAGER", "purl", modulePURL(d)}},
+		})
+		doc.Relationships = append(doc.Relationships, spdxRelationship{spdxProjectID, "DEPENDS_ON", spdxModuleID(i)})
+	}
+	for _, id := range sortedRefs(b.LicenseRefs) {
+		doc.ExtractedLicenseInfo = append(doc.ExtractedLicenseInfo, spdxExtractedLicense{
+			LicenseID:     id,
+			ExtractedText: extractedText(b.LicenseRefs[id]),
+			Name:          strings.TrimPrefix(id, "LicenseRef-"),
+		})
+	}
+	return writeJSON(w, doc)
+}
+
+// CycloneDX 1.5 JSON document, limited to the properties used by the sbom
+// command. See https://cyclonedx.org/docs/1.5/json/.
+type cdxDocument struct {
+	BOMFormat    string          `json:"bomFormat"`
+	SpecVersion  string          `json:"specVersion"`
+	SerialNumber string          `json:"serialNumber"`
+	Version      int             `json:"version"`
+	Metadata     cdxMetadata     `json:"metadata"`
+	Components   []cdxComponent  `json:"components"`
+	Dependencies []cdxDependency `json:"dependencies"`
+}
+
+type cdxMetadata struct {
+	Timestamp string       `json:"timestamp"`
+	Tools     cdxTools     `json:"tools"`
+	Component cdxComponent `json:"component"`
+}
+
+type cdxTools struct {
+	Components []cdxComponent `json:"components"`
+}
+
+type cdxComponent struct {
+	Type      string       `json:"type"`
+	BOMRef    string       `json:"bom-ref,omitempty"`
+	Name      string       `json:"name"`
+	Version   string       `json:"version,omitempty"`
+	Hashes    []cdxHash    `json:"hashes,omitempty"`
+	Licenses  []cdxLicense `json:"licenses,omitempty"`
+	Copyright string       `json:"copyright,omitempty"`
+	PURL      string       `json:"purl,omitempty"`
+}
+
+type cdxHash struct {
+	Algorithm string `json:"alg"`
+	Content   string `json:"content"`
+}
+
+type cdxLicense struct {
+	Expression string `json:"expression"`
+}
+
+type cdxDependency struct {
+	Ref       string   `json:"ref"`
+	DependsOn []string `json:"dependsOn"`
+}
+
+// writeCycloneDX writes the document in the CycloneDX 1.5 JSON format. The
+// files are components of type file, and the licenses of each file are
+// combined in a single expression.
+func (b *sbom) writeCycloneDX(w io.Writer) error {
+	licenses := func(expr string) []cdxLicense {
+		if expr == "" || expr == "NOASSERTION" {
+			return nil
+		}
+		return []cdxLicense{{expr}}
+	}
+	doc := cdxDocument{
+		BOMFormat:    "CycloneDX",
+		SpecVersion:  "1.5",
+		SerialNumber: "urn:uuid:" + b.Serial,
+		Version:      1,
+		Metadata: cdxMetadata{
+			Timestamp: b.Created.Format(time.RFC3339),
+			Tools:     cdxTools{Components: []cdxComponent{{Type: "application", Name: "license"}}},
+			Component: cdxComponent{Type: "application", BOMRef: "project", Name: b.Name, Version: b.Version},
+		},
+		Components:   []cdxComponent{},
+		Dependencies: []cdxDependency{{Ref: "project", DependsOn: []string{}}},
+	}
+	for _, d := range b.Modules {
+		purl := modulePURL(d)
+		doc.Components = append(doc.Components, cdxComponent{
+			Type:     "library",
+			BOMRef:   purl,
+			Name:     d.Module,
+			Version:  d.Version,
+			Licenses: licenses(moduleLicense(d)),
+			PURL:     purl,
+		})
+		doc.Dependencies[0].DependsOn = append(doc.Dependencies[0].DependsOn, purl)
+	}
+	for _, f := range b.Files {
+		doc.Components = append(doc.Components, cdxComponent{
+			Type:   "file",
+			BOMRef: "file:" + f.Name,
+			Name:   f.Name,
+			Hashes: []cdxHash{
+				{"SHA-1", f.SHA1},
+				{"SHA-256", f.SHA256},
+			},
+			Licenses:  licenses(conjunction(f.Licenses)),
+			Copyright: strings.Join(f.Copyrights, "\n"),
+		})
+	}
+	return writeJSON(w, doc)
+}
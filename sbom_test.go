@@ -0,0 +1,332 @@
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bhojpur/license/header"
+)
+
+func TestBuildSBOM(t *testing.T) {
+	mit, err := header.LicenseText("MIT", header.LicenseData{Year: "2022", Holder: "Jane Doe"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the legacy BSD-style notice written by -l bsd
+	bsd, err := header.NewLicense("bsd", "", header.SPDXOff, header.LicenseData{Year: "2022", Holder: "Jane Doe"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	notice, err := header.Render("bsd.go", bsd.Template, bsd.Data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmp := tempDir(t)
+	writeFiles(t, tmp, map[string]string{
+		"bsd.go":                         string(notice) + "\npackage main\n",
+		"util.go":                        commentLines(string(mit)) + "package main\n",
+		"main.go":                        "// SPDX-FileCopyrightText: 2022 Jane Doe\n// SPDX-License-Identifier: mit OR Apache-2.0\npackage main\n",
+		"logo.png":                       "\x89PNG\x00",
+		"logo.png.license":               "SPDX-FileCopyrightText: 2022 Jane Doe\n\nSPDX-License-Identifier: CC0-1.0\n",
+		"internal/secret.go":             "// Copyright 2022 Jane Doe\n// SPDX-License-Identifier: LicenseRef-Secret\n// SPDX-License-Identifier: MIT AND Apache2\npackage internal\n",
+		"LICENSE":                        "license text",
+		"LICENSES/LicenseRef-Secret.txt": "Do not share.\n",
+		"bom.json":                       "previous document",
+	})
+
+	// the invalid expression is recorded as NOASSERTION, and the previous
+	// document is left out
+	b, err := buildSBOM(tmp, filepath.Join(tmp, "bom.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []sbomFile{
+		{
+			Name:       "bsd.go",
+			Licenses:   []string{"BSD-3-Clause"},
+			Copyrights: []string{"Copyright (c) 2022 Jane Doe All rights reserved."},
+		},
+		{
+			Name:       "internal/secret.go",
+			Licenses:   []string{"LicenseRef-Secret", "NOASSERTION"},
+			Copyrights: []string{"Copyright 2022 Jane Doe"},
+		},
+		{
+			Name:       "logo.png",
+			Licenses:   []string{"CC0-1.0"},
+			Copyrights: []string{"2022 Jane Doe"},
+		},
+		{
+			Name:       "main.go",
+			Licenses:   []string{"MIT OR Apache-2.0"},
+			Copyrights: []string{"2022 Jane Doe"},
+		},
+		{
+			Name:       "util.go",
+			Licenses:   []string{"MIT"},
+			Copyrights: []string{"Copyright (c) 2022 Jane Doe"},
+		},
+	}
+	for i := range b.Files {
+		if len(b.Files[i].SHA1) != 40 || len(b.Files[i].SHA256) != 64 {
+			t.Errorf("%s has checksums %q and %q", b.Files[i].Name, b.Files[i].SHA1, b.Files[i].SHA256)
+		}
+		b.Files[i].SHA1, b.Files[i].SHA256 = "", ""
+	}
+	if !reflect.DeepEqual(b.Files, want) {
+		t.Errorf("buildSBOM returned files\n%+v\nwant\n%+v", b.Files, want)
+	}
+	if want := map[string]string{"LicenseRef-Secret": "Do not share.\n"}; !reflect.DeepEqual(b.LicenseRefs, want) {
+		t.Errorf("buildSBOM returned license references %q, want %q", b.LicenseRefs, want)
+	}
+}
+
+// testSBOM returns a document with a file of each kind and a module.
+func testSBOM() *sbom {
+	b := &sbom{
+		Name:    "example",
+		Version: "v1.0.0",
+		Created: time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC),
+		Files: []sbomFile{
+			{Name: "a.go", SHA1: "aaaa", SHA256: "aaaaaaaa", Licenses: []string{"MIT OR Apache-2.0", "LicenseRef-Secret"}, Copyrights: []string{"2022 A", "2021 B"}},
+			{Name: "b.go", SHA1: "bbbb", SHA256: "bbbbbbbb"},
+		},
+		Modules: []dependency{
+			{Module: "example.com/m", Version: "v1.0.0+incompatible", License: "BSD-3-Clause"},
+			{Module: "example.com/unknown", Version: "v0.1.0", Closest: "MIT"},
+		},
+		LicenseRefs: map[string]string{"LicenseRef-Secret": ""},
+	}
+	b.setNamespace("")
+	return b
+}
+
+func TestSBOMNamespace(t *testing.T) {
+	b := testSBOM()
+	if !strings.HasPrefix(b.Namespace, "https://spdx.org/spdxdocs/example-") || !strings.HasSuffix(b.Namespace, b.Serial) {
+		t.Errorf("namespace is %q, serial %q", b.Namespace, b.Serial)
+	}
+	if len(b.Serial) != 36 || b.Serial[14] != '5' {
+		t.Errorf("serial %q is not a version 5 UUID", b.Serial)
+	}
+	if other := testSBOM(); other.Serial != b.Serial {
+		t.Errorf("the same contents gave serials %q and %q", b.Serial, other.Serial)
+	}
+	other := testSBOM()
+	other.Files[1].SHA1 = "cccc"
+	if other.setNamespace("urn:example"); other.Serial == b.Serial || other.Namespace != "urn:example" {
+		t.Errorf("other contents gave serial %q and namespace %q", other.Serial, other.Namespace)
+	}
+}
+
+func TestWriteSPDX(t *testing.T) {
+	var buf bytes.Buffer
+	b := testSBOM()
+	if err := b.writeSPDX(&buf); err != nil {
+		t.Fatal(err)
+	}
+	want := `SPDXVersion: SPDX-2.3
+DataLicense: CC0-1.0
+SPDXID: SPDXRef-DOCUMENT
+DocumentName: example
+DocumentNamespace: ` + b.Namespace + `
+Creator: Tool: license
+Created: 2022-01-02T03:04:05Z
+Relationship: SPDXRef-DOCUMENT DESCRIBES SPDXRef-Project
+
+PackageName: example
+SPDXID: SPDXRef-Project
+PackageVersion: v1.0.0
+PackageDownloadLocation: NOASSERTION
+FilesAnalyzed: true
+PackageVerificationCode: ` + b.verificationCode() + `
+PackageLicenseConcluded: NOASSERTION
+PackageLicenseInfoFromFiles: Apache-2.0
+PackageLicenseInfoFromFiles: LicenseRef-Secret
+PackageLicenseInfoFromFiles: MIT
+PackageLicenseDeclared: NOASSERTION
+PackageCopyrightText: NOASSERTION
+
+FileName: ./a.go
+SPDXID: SPDXRef-File-1
+FileChecksum: SHA1: aaaa
+FileChecksum: SHA256: aaaaaaaa
+LicenseConcluded: NOASSERTION
+LicenseInfoInFile: MIT
+LicenseInfoInFile: Apache-2.0
+LicenseInfoInFile: LicenseRef-Secret
+FileCopyrightText: <text>2022 A
+2021 B</text>
+Relationship: SPDXRef-Project CONTAINS SPDXRef-File-1
+
+FileName: ./b.go
+SPDXID: SPDXRef-File-2
+FileChecksum: SHA1: bbbb
+FileChecksum: SHA256: bbbbbbbb
+LicenseConcluded: NOASSERTION
+LicenseInfoInFile: NONE
+FileCopyrightText: NONE
+Relationship: SPDXRef-Project CONTAINS SPDXRef-File-2
+
+PackageName: example.com/m
+SPDXID: SPDXRef-Module-1
+PackageVersion: v1.0.0+incompatible
+PackageDownloadLocation: NOASSERTION
+FilesAnalyzed: false
+PackageLicenseConcluded: NOASSERTION
+PackageLicenseDeclared: BSD-3-Clause
+PackageCopyrightText: NOASSERTION
+ExternalRef: PACKAGE-MANAGER purl pkg:golang/example.com/m@v1.0.0%2Bincompatible
+Relationship: SPDXRef-Project DEPENDS_ON SPDXRef-Module-1
+
+PackageName: example.com/unknown
+SPDXID: SPDXRef-Module-2
+PackageVersion: v0.1.0
+PackageDownloadLocation: NOASSERTION
+FilesAnalyzed: false
+PackageLicenseConcluded: NOASSERTION
+PackageLicenseDeclared: NOASSERTION
+PackageCopyrightText: NOASSERTION
+ExternalRef: PACKAGE-MANAGER purl pkg:golang/example.com/unknown@v0.1.0
+Relationship: SPDXRef-Project DEPENDS_ON SPDXRef-Module-2
+
+LicenseID: LicenseRef-Secret
+ExtractedText: NOASSERTION
+LicenseName: Secret
+`
+	if got := buf.String(); got != want {
+		t.Errorf("writeSPDX wrote:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestWriteSPDXJSON(t *testing.T) {
+	var buf bytes.Buffer
+	b := testSBOM()
+	if err := b.writeSPDXJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var doc spdxDocument
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.SPDXVersion != "SPDX-2.3" || doc.CreationInfo.Created != "2022-01-02T03:04:05Z" || doc.DocumentNamespace != b.Namespace {
+		t.Errorf("writeSPDXJSON wrote document %+v", doc)
+	}
+	if len(doc.Packages) != 3 || len(doc.Files) != 2 || len(doc.Relationships) != 5 || len(doc.ExtractedLicenseInfo) != 1 {
+		t.Fatalf("writeSPDXJSON wrote %d packages, %d files, %d relationships and %d license references",
+			len(doc.Packages), len(doc.Files), len(doc.Relationships), len(doc.ExtractedLicenseInfo))
+	}
+	if got, want := doc.Packages[0].LicenseInfoFromFiles, []string{"Apache-2.0", "LicenseRef-Secret", "MIT"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("project has licenses %q, want %q", got, want)
+	}
+	if doc.Packages[0].VerificationCode == nil || doc.Packages[0].VerificationCode.Value != b.verificationCode() {
+		t.Errorf("project has verification code %+v", doc.Packages[0].VerificationCode)
+	}
+	if got := doc.Files[1]; got.CopyrightText != "NONE" || !reflect.DeepEqual(got.LicenseInfoInFiles, []string{"NONE"}) {
+		t.Errorf("file without tags written as %+v", got)
+	}
+	if got := doc.Packages[1]; got.LicenseDeclared != "BSD-3-Clause" || got.ExternalRefs[0].Locator != "pkg:golang/example.com/m@v1.0.0%2Bincompatible" {
+		t.Errorf("module written as %+v", got)
+	}
+	if got := doc.Packages[2].LicenseDeclared; got != "NOASSERTION" {
+		t.Errorf("unidentified module has license %q", got)
+	}
+}
+
+func TestWriteCycloneDX(t *testing.T) {
+	var buf bytes.Buffer
+	b := testSBOM()
+	if err := b.writeCycloneDX(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var doc cdxDocument
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.BOMFormat != "CycloneDX" || doc.SerialNumber != "urn:uuid:"+b.Serial || doc.Metadata.Component.Version != "v1.0.0" {
+		t.Errorf("writeCycloneDX wrote document %+v", doc)
+	}
+	want := []cdxComponent{
+		{
+			Type:     "library",
+			BOMRef:   "pkg:golang/example.com/m@v1.0.0%2Bincompatible",
+			Name:     "example.com/m",
+			Version:  "v1.0.0+incompatible",
+			Licenses: []cdxLicense{{"BSD-3-Clause"}},
+			PURL:     "pkg:golang/example.com/m@v1.0.0%2Bincompatible",
+		},
+		{
+			Type:    "library",
+			BOMRef:  "pkg:golang/example.com/unknown@v0.1.0",
+			Name:    "example.com/unknown",
+			Version: "v0.1.0",
+			PURL:    "pkg:golang/example.com/unknown@v0.1.0",
+		},
+		{
+			Type:      "file",
+			BOMRef:    "file:a.go",
+			Name:      "a.go",
+			Hashes:    []cdxHash{{"SHA-1", "aaaa"}, {"SHA-256", "aaaaaaaa"}},
+			Licenses:  []cdxLicense{{"(MIT OR Apache-2.0) AND LicenseRef-Secret"}},
+			Copyright: "2022 A\n2021 B",
+		},
+		{
+			Type:   "file",
+			BOMRef: "file:b.go",
+			Name:   "b.go",
+			Hashes: []cdxHash{{"SHA-1", "bbbb"}, {"SHA-256", "bbbbbbbb"}},
+		},
+	}
+	if !reflect.DeepEqual(doc.Components, want) {
+		t.Errorf("writeCycloneDX wrote components\n%+v\nwant\n%+v", doc.Components, want)
+	}
+	wantDeps := []cdxDependency{{Ref: "project", DependsOn: []string{want[0].PURL, want[1].PURL}}}
+	if !reflect.DeepEqual(doc.Dependencies, wantDeps) {
+		t.Errorf("writeCycloneDX wrote dependencies %+v, want %+v", doc.Dependencies, wantDeps)
+	}
+}
+
+func TestSBOMTime(t *testing.T) {
+	t.Setenv("SOURCE_DATE_EPOCH", "1641092645")
+	if got, err := sbomTime(); err != nil || !got.Equal(time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)) {
+		t.Errorf("sbomTime returned %v, %v", got, err)
+	}
+	t.Setenv("SOURCE_DATE_EPOCH", "yesterday")
+	if _, err := sbomTime(); err == nil {
+		t.Error("sbomTime accepted an invalid SOURCE_DATE_EPOCH")
+	}
+}
+
+// commentLines returns text as a block of // line comments.
+func commentLines(text string) string {
+	var b strings.Builder
+	for _, line := range strings.Split(strings.TrimSuffix(text, "\n"), "\n") {
+		b.WriteString(strings.TrimRight("// "+line, " ") + "\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,235 @@
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"crypto/ed25519"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/bhojpur/license/signature"
+)
+
+// publicKeySuffix is appended to the name of a private key file to name the
+// file of its public key.
+const publicKeySuffix = ".pub"
+
+// runKeygen implements the keygen command, which writes a new Ed25519 key
+// pair for the sign command.
+func runKeygen(args []string) error {
+	fset := flag.NewFlagSet("keygen", flag.ExitOnError)
+	force := fset.Bool("force", false, "overwrite existing key files")
+	fset.Usage = func() {
+		fmt.Fprintf(fset.Output(), "Usage: license keygen [flags] private-key-file\n\n"+
+			"The public key is written next to the private key, with a %s suffix.\n\nFlags:\n", publicKeySuffix)
+		fset.PrintDefaults()
+	}
+	fset.Parse(args)
+	if fset.NArg() != 1 {
+		fset.Usage()
+		os.Exit(1)
+	}
+	path := fset.Arg(0)
+
+	pub, priv, err := signature.GenerateKey(nil)
+	if err != nil {
+		return err
+	}
+	if err := writeKeys(path, pub, priv, *force); err != nil {
+		return err
+	}
+	fmt.Printf("key %s written to %s and %s\n", signature.KeyID(pub), path, path+publicKeySuffix)
+	return nil
+}
+
+// writeKeys writes the private key to path, only readable by its owner, and
+// the public key next to it. Existing files are only replaced with force.
+func writeKeys(path string, pub ed25519.PublicKey, priv ed25519.PrivateKey, force bool) error {
+	privPEM, err := signature.MarshalPrivateKey(priv)
+	if err != nil {
+		return err
+	}
+	pubPEM, err := signature.MarshalPublicKey(pub)
+	if err != nil {
+		return err
+	}
+	if !force {
+		for _, p := range []string{path, path + publicKeySuffix} {
+			if _, err := os.Lstat(p); err == nil {
+				return fmt.Errorf("%s already exists, see -force", p)
+			} else if !os.IsNotExist(err) {
+				return err
+			}
+		}
+	}
+	if err := replaceFile(path, privPEM, 0600, time.Now()); err != nil {
+		return err
+	}
+	return replaceFile(path+publicKeySuffix, pubPEM, 0644, time.Now())
+}
+
+// runSign implements the sign command, which writes a detached signature
+// next to each file.
+func runSign(args []string) error {
+	fset := flag.NewFlagSet("sign", flag.ExitOnError)
+	keyPath := fset.String("key", "", "private key file, see license keygen")
+	fset.Usage = func() {
+		fmt.Fprintf(fset.Output(), "Usage: license sign -key private-key-file file [file ...]\n\n"+
+			"The signature of each file is written next to it, with a %s suffix.\n\nFlags:\n", signature.Suffix)
+		fset.PrintDefaults()
+	}
+	fset.Parse(args)
+	if fset.NArg() == 0 || *keyPath == "" {
+		fset.Usage()
+		os.Exit(1)
+	}
+	b, err := ioutil.ReadFile(*keyPath)
+	if err != nil {
+		return err
+	}
+	key, err := signature.ParsePrivateKey(b)
+	if err != nil {
+		return fmt.Errorf("%s: %w", *keyPath, err)
+	}
+	now := time.Now()
+	for _, path := range fset.Args() {
+		s, err := signFile(path, key, now)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s: signed by %s\n", path, s.KeyID)
+	}
+	return nil
+}
+
+// signFile writes the signature of the file at path with the key at time t
+// to its signature file.
+func signFile(path string, key ed25519.PrivateKey, t time.Time) (*signature.Signature, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	s, err := signature.Sign(f, key, t)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	b, err := s.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	return s, replaceFile(path+signature.Suffix, b, 0644, time.Now())
+}
+
+// verification is the outcome of verifying the signature of a file.
+type verification struct {
+	Path      string     `json:"path"`
+	Valid     bool       `json:"valid"`
+	KeyID     string     `json:"key_id,omitempty"`
+	Timestamp *time.Time `json:"timestamp,omitempty"`
+	Error     string     `json:"error,omitempty"`
+}
+
+// runVerify implements the verify command, which checks the detached
+// signatures of files against trusted public keys, without network access.
+func runVerify(args []string) error {
+	fset := flag.NewFlagSet("verify", flag.ExitOnError)
+	var keyPaths stringSlice
+	fset.Var(&keyPaths, "key", "trusted public key file, may be repeated")
+	format := fset.String("format", "text", "output format: text or json")
+	fset.Usage = func() {
+		fmt.Fprintf(fset.Output(), "Usage: license verify -key public-key-file [flags] file [file ...]\n\n"+
+			"The signature of each file is read next to it, with a %s suffix.\n\nFlags:\n", signature.Suffix)
+		fset.PrintDefaults()
+	}
+	fset.Parse(args)
+	if fset.NArg() == 0 || len(keyPaths) == 0 {
+		fset.Usage()
+		os.Exit(1)
+	}
+	if *format != "text" && *format != "json" {
+		return fmt.Errorf("-format %q is not one of text, json", *format)
+	}
+	var keys []ed25519.PublicKey
+	for _, p := range keyPaths {
+		b, err := ioutil.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		key, err := signature.ParsePublicKey(b)
+		if err != nil {
+			return fmt.Errorf("%s: %w", p, err)
+		}
+		keys = append(keys, key)
+	}
+
+	var results []verification
+	invalid := 0
+	for _, path := range fset.Args() {
+		v := verification{Path: path}
+		s, err := signature.VerifyFile(path, keys...)
+		if s != nil {
+			v.KeyID, v.Timestamp = s.KeyID, &s.Timestamp
+		}
+		if err != nil {
+			v.Error = err.Error()
+			invalid++
+		} else {
+			v.Valid = true
+		}
+		results = append(results, v)
+	}
+	var err error
+	if *format == "json" {
+		err = writeJSON(os.Stdout, struct {
+			Results []verification `json:"results"`
+		}{results})
+	} else {
+		err = writeVerifications(os.Stdout, results)
+	}
+	if err != nil {
+		return err
+	}
+	if invalid > 0 {
+		return fmt.Errorf("%d of %d signatures could not be verified", invalid, len(results))
+	}
+	return nil
+}
+
+// writeVerifications writes the outcome of each verification to w.
+func writeVerifications(w io.Writer, results []verification) error {
+	for _, v := range results {
+		var err error
+		if v.Valid {
+			_, err = fmt.Fprintf(w, "%s: ok, signed by %s at %s\n", v.Path, v.KeyID, v.Timestamp.Format(time.RFC3339))
+		} else {
+			_, err = fmt.Fprintf(w, "%s: %s\n", v.Path, v.Error)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bhojpur/license/signature"
+)
+
+func TestWriteKeys(t *testing.T) {
+	tmp := tempDir(t)
+	path := filepath.Join(tmp, "signing.key")
+	pub, priv, err := signature.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := writeKeys(path, pub, priv, false); err != nil {
+		t.Fatal(err)
+	}
+	fi, err := os.Stat(path)
+	if err != nil || fi.Mode().Perm() != 0600 {
+		t.Errorf("private key file has mode %v (%v), want 0600", fi.Mode(), err)
+	}
+	if err := writeKeys(path, pub, priv, false); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("writeKeys over existing keys returned %v", err)
+	}
+	if err := writeKeys(path, pub, priv, true); err != nil {
+		t.Errorf("writeKeys with force returned %v", err)
+	}
+
+	file := filepath.Join(tmp, "program")
+	writeFiles(t, tmp, map[string]string{"program": "program binary"})
+	s, err := signFile(file, priv, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := signature.VerifyFile(file, pub); err != nil || got.KeyID != s.KeyID {
+		t.Errorf("VerifyFile of a signed file returned %+v, %v", got, err)
+	}
+}
+
+func TestWriteVerifications(t *testing.T) {
+	ts := time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)
+	var buf bytes.Buffer
+	err := writeVerifications(&buf, []verification{
+		{Path: "a", Valid: true, KeyID: "0123456789abcdef", Timestamp: &ts},
+		{Path: "b", KeyID: "0123456789abcdef", Timestamp: &ts, Error: signature.ErrDigest.Error()},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "a: ok, signed by 0123456789abcdef at 2022-01-02T03:04:05Z\n" +
+		"b: file does not match the signed hash\n"
+	if got := buf.String(); got != want {
+		t.Errorf("writeVerifications wrote:\n%s\nwant:\n%s", got, want)
+	}
+}
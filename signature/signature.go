@@ -0,0 +1,180 @@
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package signature signs files with Ed25519 keys and verifies their
+// detached signatures offline.
+//
+// A Signature holds the SHA-256 hash of the signed file, the ID of the
+// signing key and the time of signing, all covered by the Ed25519
+// signature. It is stored as JSON next to the file, with the Suffix
+// appended to its name. Keys are stored as PEM encoded PKCS #8 private keys
+// and PKIX public keys, as written by openssl genpkey -algorithm ed25519.
+package signature
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"time"
+)
+
+// Suffix is appended to the name of a file to name its signature file.
+const Suffix = ".sig"
+
+// Algorithm and Hash are the only algorithms of signatures.
+const (
+	Algorithm = "ed25519"
+	Hash      = "sha256"
+)
+
+// domain separates the signed messages from other uses of the keys.
+const domain = "bhojpur-license-signature-v1"
+
+var (
+	ErrUnknownKey = errors.New("signed by an unknown key")
+	ErrDigest     = errors.New("file does not match the signed hash")
+	ErrSignature  = errors.New("invalid signature")
+)
+
+// Signature is a detached signature of a file. The timestamp is given by
+// the signer, and only proves that the holder of the key claimed it.
+type Signature struct {
+	Algorithm string    `json:"algorithm"`
+	Hash      string    `json:"hash"`
+	Digest    string    `json:"digest"` // hex encoded hash of the file
+	KeyID     string    `json:"key_id"` // ID of the signing key, see KeyID
+	Timestamp time.Time `json:"timestamp"`
+	Signature []byte    `json:"signature"` // Ed25519 signature of the message
+}
+
+// message returns the message signed by s, covering all its fields.
+func (s *Signature) message() []byte {
+	return []byte(fmt.Sprintf("%s\n%s\n%s:%s\n%s\n%s\n", domain, s.Algorithm, s.Hash, s.Digest, s.KeyID, s.Timestamp.UTC().Format(time.RFC3339)))
+}
+
+// digest returns the hex encoded SHA-256 hash of the contents of r.
+func digest(r io.Reader) (string, error) {
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Sign returns the signature of the contents of r with the private key at
+// time t, which is rounded to the second.
+func Sign(r io.Reader, key ed25519.PrivateKey, t time.Time) (*Signature, error) {
+	d, err := digest(r)
+	if err != nil {
+		return nil, err
+	}
+	s := &Signature{
+		Algorithm: Algorithm,
+		Hash:      Hash,
+		Digest:    d,
+		KeyID:     KeyID(key.Public().(ed25519.PublicKey)),
+		Timestamp: t.UTC().Truncate(time.Second),
+	}
+	s.Signature = ed25519.Sign(key, s.message())
+	return s, nil
+}
+
+// Verify checks that s is a valid signature of the contents of r by one of
+// the public keys, selected by its ID. It returns ErrUnknownKey if no key
+// has the ID of the signature, ErrSignature if the signature is not valid
+// and ErrDigest if the contents do not match the signed hash.
+func (s *Signature) Verify(r io.Reader, keys ...ed25519.PublicKey) error {
+	if s.Algorithm != Algorithm || s.Hash != Hash {
+		return fmt.Errorf("unsupported signature algorithm %s with %s", s.Algorithm, s.Hash)
+	}
+	var key ed25519.PublicKey
+	for _, k := range keys {
+		if KeyID(k) == s.KeyID {
+			key = k
+			break
+		}
+	}
+	if key == nil {
+		return ErrUnknownKey
+	}
+	if !ed25519.Verify(key, s.message(), s.Signature) {
+		return ErrSignature
+	}
+	d, err := digest(r)
+	if err != nil {
+		return err
+	}
+	if d != s.Digest {
+		return ErrDigest
+	}
+	return nil
+}
+
+// Marshal returns the JSON encoding of s, as stored in signature files.
+func (s *Signature) Marshal() ([]byte, error) {
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(b, '\n'), nil
+}
+
+// Parse parses a signature file.
+func Parse(b []byte) (*Signature, error) {
+	var s Signature
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&s); err != nil {
+		return nil, fmt.Errorf("invalid signature file: %w", err)
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("invalid signature file: data after the signature")
+	}
+	if len(s.Signature) != ed25519.SignatureSize {
+		return nil, errors.New("invalid signature file: wrong signature size")
+	}
+	return &s, nil
+}
+
+// VerifyFile verifies the file name against its signature file, named with
+// the Suffix, and returns the signature.
+func VerifyFile(name string, keys ...ed25519.PublicKey) (*Signature, error) {
+	b, err := ioutil.ReadFile(name + Suffix)
+	if err != nil {
+		return nil, err
+	}
+	s, err := Parse(b)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return s, s.Verify(f, keys...)
+}
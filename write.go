@@ -0,0 +1,135 @@
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+)
+
+// tempFileRE matches the names of the temporary files of replaceFile, which
+// are skipped when walking directories.
+var tempFileRE = regexp.MustCompile(`^\..+\.[0-9]+\.tmp$`)
+
+// isTempFile reports whether the file at path is a temporary file of
+// replaceFile, left by a concurrent or interrupted run.
+func isTempFile(path string) bool {
+	return tempFileRE.MatchString(filepath.Base(path))
+}
+
+// writeFile replaces the contents of the file at path with b, preserving its
+// mode and modification time. Like ioutil.WriteFile, it fails if the file is
+// not writable.
+func writeFile(path string, b []byte) error {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	// refuse to replace files that could not be written in place
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	f.Close()
+	return replaceFile(path, b, fi.Mode(), fi.ModTime())
+}
+
+// replaceFile atomically replaces the file at path with one holding b, with
+// the given mode and modification time, and the owner and group of the
+// original file if there is one. The contents are written to a temporary
+// file in the same directory, which is renamed over the original once
+// complete, so that an interrupted run never leaves a truncated file.
+func replaceFile(path string, b []byte, mode os.FileMode, mtime time.Time) error {
+	dir, base := filepath.Split(path)
+	if dir == "" {
+		dir = "."
+	}
+	tmp, err := ioutil.TempFile(dir, "."+base+".*.tmp")
+	if err != nil {
+		return err
+	}
+	// removing the temporary file fails once it has been renamed
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), mode.Perm()); err != nil {
+		return err
+	}
+	if err := os.Chtimes(tmp.Name(), mtime, mtime); err != nil {
+		return err
+	}
+	if fi, err := os.Stat(path); err == nil {
+		if err := chownLike(tmp.Name(), fi); err != nil {
+			return err
+		}
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// createFile atomically creates the file at path holding b, failing if it
+// already exists.
+func createFile(path string, b []byte) error {
+	if _, err := os.Lstat(path); err == nil {
+		return fmt.Errorf("%s already exists", path)
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	return replaceFile(path, b, 0644, time.Now())
+}
+
+// backupSuffix is appended to the name of a file to name its backup.
+const backupSuffix = ".orig"
+
+// backupFile saves the original contents b of the file at path next to it,
+// with the backup suffix. An existing backup is kept, as it holds the
+// contents from before an earlier run.
+func backupFile(path string, b []byte) error {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path+backupSuffix, os.O_WRONLY|os.O_CREATE|os.O_EXCL, fi.Mode().Perm())
+	if os.IsExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
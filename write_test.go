@@ -0,0 +1,92 @@
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWriteFile(t *testing.T) {
+	tmp := tempDir(t)
+	path := filepath.Join(tmp, "file.sh")
+	if err := ioutil.WriteFile(path, []byte("old"), 0750); err != nil {
+		t.Fatal(err)
+	}
+	mtime := time.Date(2018, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := os.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := writeFile(path, []byte("new")); err != nil {
+		t.Fatalf("writeFile returned error: %v", err)
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "new" {
+		t.Errorf("writeFile wrote %q, want %q", b, "new")
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode().Perm() != 0750 || !fi.ModTime().Equal(mtime) {
+		t.Errorf("writeFile left mode %v and mtime %v, want %v and %v", fi.Mode().Perm(), fi.ModTime(), os.FileMode(0750), mtime)
+	}
+	names, err := ioutil.ReadDir(tmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 1 {
+		t.Errorf("writeFile left %d files in the directory, want 1", len(names))
+	}
+
+	if err := writeFile(filepath.Join(tmp, "missing.sh"), []byte("new")); !os.IsNotExist(err) {
+		t.Errorf("writeFile of a missing file returned error %v, want not exist", err)
+	}
+}
+
+func TestBackupFile(t *testing.T) {
+	tmp := tempDir(t)
+	path := filepath.Join(tmp, "file.c")
+	if err := ioutil.WriteFile(path, []byte("new"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, b := range []string{"first", "second"} {
+		if err := backupFile(path, []byte(b)); err != nil {
+			t.Fatalf("backupFile returned error: %v", err)
+		}
+	}
+	// the backup of the first run is kept
+	b, err := ioutil.ReadFile(path + backupSuffix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "first" {
+		t.Errorf("backup holds %q, want %q", b, "first")
+	}
+}